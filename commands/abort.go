@@ -1,15 +1,21 @@
 package commands
 
 import (
+	"bufio"
 	"context"
+	"fmt"
 
+	"github.com/FujishigeTemma/git-review/internal"
 	"github.com/FujishigeTemma/git-review/internal/git"
 	"github.com/FujishigeTemma/git-review/internal/output"
 	"github.com/FujishigeTemma/git-review/internal/repository"
 	"github.com/newmo-oss/ergo"
 )
 
-type AbortCmd struct{}
+type AbortCmd struct {
+	KeepNotes bool `name:"keep-notes" help:"Write comments to git notes on the original commits before cleaning up."`
+	Force     bool `short:"f" help:"Abort without confirming, even when comments would be lost (not needed with --keep-notes)."`
+}
 
 func (c *AbortCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
 	if err := requireMainWorktree(g); err != nil {
@@ -27,8 +33,55 @@ func (c *AbortCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outp
 		return ergo.Wrap(err, "failed to get session")
 	}
 
-	cleanupReview(g, repo, out, session)
-	out.Ok("Review aborted. Back on: " + session.Branch)
+	comments, err := q.ListAllComments(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list comments")
+	}
+	// With --keep-notes the comments survive in git notes, so there is nothing to confirm
+	if len(comments) > 0 && !c.Force && !c.KeepNotes {
+		if !out.Interactive {
+			return ergo.New(fmt.Sprintf("abort would delete %d %s; rerun with --force to confirm",
+				len(comments), internal.Pluralize(len(comments), "comment", "comments")))
+		}
+		if !confirmAbort(out, len(comments)) {
+			out.Info("Abort cancelled.")
+			return nil
+		}
+	}
+
+	if c.KeepNotes {
+		commits, err := q.ListCommits(ctx)
+		if err != nil {
+			return ergo.Wrap(err, "failed to list commits")
+		}
+		files, err := loadCommentFiles(ctx, q, comments)
+		if err != nil {
+			return err
+		}
+		writeCommitNotes(newNoteWriter(g, false), out, commits, comments, files, codeContext{})
+	}
+
+	backOn := cleanupReview(g, repo, out, session)
+	if backOn == "" {
+		out.Ok("Review aborted.")
+	} else {
+		out.Ok("Review aborted. Back on: " + backOn)
+	}
+	if c.KeepNotes {
+		out.Notef("  Comments kept in git notes on original commits.\n")
+	}
 
 	return nil
 }
+
+// confirmAbort asks before the review's comments are deleted; only 'y' proceeds.
+func confirmAbort(out *output.Output, n int) bool {
+	out.Printf("%s ", out.Bold(fmt.Sprintf("Abort and delete %d %s? [y/N]", n, internal.Pluralize(n, "comment", "comments"))))
+	key, err := readKey(out, bufio.NewReader(out.Stdin))
+	if err != nil {
+		out.Printf("\n")
+		return false
+	}
+	out.Printf("%c\n", key)
+	return key == 'y' || key == 'Y'
+}
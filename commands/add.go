@@ -2,9 +2,12 @@ package commands
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -14,29 +17,119 @@ import (
 	"github.com/FujishigeTemma/git-review/internal/git"
 	"github.com/FujishigeTemma/git-review/internal/output"
 	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/alecthomas/kong"
 	"github.com/google/uuid"
 	"github.com/guregu/null/v6"
 	"github.com/newmo-oss/ergo"
 )
 
 type AddCmd struct {
-	File    string `short:"f" help:"File path for the comment."`
-	Line    string `short:"l" help:"Line or range (e.g. 42, 10,35)."`
-	ReplyTo string `short:"r" name:"reply-to" help:"ID of parent comment to reply to."`
-	Author  string `short:"a" help:"Author name (default: worktree name)."`
-	Message string `arg:"" help:"Comment message."`
+	File     fileArgs `short:"f" placeholder:"FILE" help:"File path for the comment; repeat it for a comment on several files." completion:"files"`
+	Line     string   `short:"l" help:"Line or range (e.g. 42, 10,35, 10-35; 10, runs on from line 10, ,35 starts at line 1)." xor:"range"`
+	Hunk     int      `help:"Comment on the Nth changed hunk of --file (1-based) instead of --line." xor:"range"`
+	ReplyTo  string   `short:"r" name:"reply-to" help:"ID of parent comment to reply to." completion:"ids"`
+	Author   string   `short:"a" help:"Author name (default: $GIT_REVIEW_AUTHOR, else worktree name)."`
+	Question bool     `short:"q" help:"Mark the comment as a question that needs a response."`
+	Severity string   `help:"Severity of the thread: nit, minor, major or blocker."`
+	Summary  bool     `help:"Add a review-wide summary (e.g. a verdict on the whole branch) instead of a comment on the current commit."`
+	Also     string   `name:"also-commit" help:"Also reference another reviewed commit (hash prefix), for feedback on a change between the two." completion:"commits"`
+	Force    bool     `help:"Comment on --file even if the current commit does not change it, or at a line of a binary file."`
+	Resolve  bool     `help:"With --reply-to, also resolve the thread, in the same transaction as the reply."`
+	Fixup    string   `placeholder:"COMMIT" help:"Commit that fixes what the comment is about, in the review or not; shown as \"→ fixed in <hash>\" and resolves the thread."`
+	Assign   string   `placeholder:"NAME" help:"Ask NAME to act on the comment; shown as →@NAME and found with list --assigned-to."`
+	OnBase   bool     `name:"on-base" help:"Comment on the code as it was at the review's base, before the branch changed it; listed under Base and noted on the first commit."`
+
+	MessageFile string `short:"F" name:"message-file" help:"Read the comment message from a file (- for stdin)."`
+	Message     string `arg:"" optional:"" help:"Comment message. @path reads it from a file and @- from stdin; write \\@ for a leading @."`
+}
+
+// fileArgs is the repeatable --file. Over --serve-stdio, "file" may also be a single
+// string, as it was before a comment could be on several files.
+type fileArgs []string
+
+// Decode appends each --file to the list. Without it kong would decode the value as JSON,
+// since fileArgs implements json.Unmarshaler.
+func (f *fileArgs) Decode(ctx *kong.DecodeContext) error {
+	var file string
+	if err := ctx.Scan.PopValueInto("file", &file); err != nil {
+		return err
+	}
+	*f = append(*f, file)
+	return nil
+}
+
+func (f *fileArgs) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*f = nil
+		if one != "" {
+			*f = fileArgs{one}
+		}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(f))
+}
+
+// AfterApply turns a message of @path into --message-file, as git does with -F, and
+// unescapes a leading \@. Only the command line goes through kong: messages sent over
+// --serve-stdio are taken verbatim.
+func (c *AddCmd) AfterApply() error {
+	switch {
+	case strings.HasPrefix(c.Message, `\@`):
+		c.Message = c.Message[1:]
+	case len(c.Message) > 1 && c.Message[0] == '@' && c.MessageFile == "":
+		c.MessageFile, c.Message = c.Message[1:], ""
+	}
+	return nil
+}
+
+// readMessage fills in the message from --message-file, trimming trailing newlines.
+func (c *AddCmd) readMessage() error {
+	if c.MessageFile != "" {
+		if c.Message != "" {
+			return ergo.New("give the comment message as an argument or with --message-file, not both")
+		}
+		var data []byte
+		var err error
+		if c.MessageFile == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(c.MessageFile)
+		}
+		if err != nil {
+			return ergo.Wrap(err, "failed to read comment message", slog.String("path", c.MessageFile))
+		}
+		c.Message = strings.TrimRight(string(data), "\r\n")
+	}
+	if strings.TrimSpace(c.Message) == "" {
+		return ergo.New("the comment message is empty")
+	}
+	return nil
 }
 
+// parseLineRange parses a --line value: a single line, a range like 10,25 or 10-25, an
+// open-ended range like 10, (stored with no end line), or ,25 (lines 1 to 25).
 func parseLineRange(raw string) (start, end null.Int, err error) {
+	raw = strings.TrimSpace(raw)
 	if raw == "" {
 		return null.Int{}, null.Int{}, nil
 	}
-	if i := strings.IndexByte(raw, ','); i >= 0 {
-		s, err := strconv.ParseInt(raw[:i], 10, 64)
-		if err != nil {
+	// Accept both 10,25 and 10-25
+	if i := strings.IndexAny(raw, ",-"); i >= 0 {
+		from, to := strings.TrimSpace(raw[:i]), strings.TrimSpace(raw[i+1:])
+		if from == "" && to == "" {
 			return null.Int{}, null.Int{}, ergo.New("invalid line range", slog.String("range", raw))
 		}
-		e, err := strconv.ParseInt(raw[i+1:], 10, 64)
+		s := int64(1)
+		if from != "" {
+			if s, err = strconv.ParseInt(from, 10, 64); err != nil {
+				return null.Int{}, null.Int{}, ergo.New("invalid line range", slog.String("range", raw))
+			}
+		}
+		if to == "" {
+			return null.IntFrom(s), null.Int{}, nil
+		}
+		e, err := strconv.ParseInt(to, 10, 64)
 		if err != nil {
 			return null.Int{}, null.Int{}, ergo.New("invalid line range", slog.String("range", raw))
 		}
@@ -52,6 +145,28 @@ func parseLineRange(raw string) (start, end null.Int, err error) {
 	return null.IntFrom(n), null.IntFrom(n), nil
 }
 
+// hunkRange returns the line range of the nth (1-based) hunk of file in the given commit.
+// When n is out of range, the error lists the available hunks.
+func hunkRange(g *git.Git, sha, file string, n int) (start, end null.Int, err error) {
+	hunks, err := g.Hunks(sha, file)
+	if err != nil {
+		return null.Int{}, null.Int{}, ergo.Wrap(err, "failed to read diff", slog.String("file", file))
+	}
+	if len(hunks) == 0 {
+		return null.Int{}, null.Int{}, ergo.New("file has no changes in the current commit", slog.String("file", file))
+	}
+	if n < 1 || n > len(hunks) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "hunk out of range. Available hunks in %s:", file)
+		for i, h := range hunks {
+			fmt.Fprintf(&b, "\n  %d: L%s  %s", i+1, internal.FormatLineRange(null.IntFrom(h.Start), null.IntFrom(h.End)), h.Header)
+		}
+		return null.Int{}, null.Int{}, ergo.New(b.String(), slog.Int("hunk", n))
+	}
+	h := hunks[n-1]
+	return null.IntFrom(h.Start), null.IntFrom(h.End), nil
+}
+
 func (c *AddCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
 	if err := requireActive(repo); err != nil {
 		return err
@@ -59,34 +174,92 @@ func (c *AddCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output
 
 	ctx := context.Background()
 	q := repo.Queries()
-	now := time.Now().UTC().Format(time.RFC3339)
-	newID := uuid.Must(uuid.NewV7())
+
+	if err := c.readMessage(); err != nil {
+		return err
+	}
+	if err := validateSeverity("--severity", c.Severity); err != nil {
+		return err
+	}
+	if c.Severity != "" && c.ReplyTo != "" {
+		return ergo.New("--severity applies to a thread, not to a reply")
+	}
 
 	author := c.Author
 	if author == "" {
-		author = g.Reviewer
+		author = defaultAuthor(g)
 	}
 
 	var params db.InsertCommentParams
+	var moreFiles []string // files after the first, for a comment on several files
 
-	if c.ReplyTo != "" {
-		// Reply mode: find parent, inherit commit from parent
-		parent, err := q.FindCommentByPrefix(ctx, sql.NullString{String: c.ReplyTo, Valid: true})
+	if c.Summary && (c.ReplyTo != "" || len(c.File) > 0 || c.Line != "" || c.Hunk != 0 || c.Also != "") {
+		return ergo.New("--summary applies to the whole review and cannot be combined with --reply-to, --file, --line, --hunk or --also-commit")
+	}
+	if c.Also != "" && c.ReplyTo != "" {
+		return ergo.New("--also-commit applies to a thread, not to a reply")
+	}
+	if c.Resolve && c.ReplyTo == "" {
+		return ergo.New("--resolve requires --reply-to: it resolves the thread being replied to")
+	}
+	if c.OnBase && (c.Summary || c.ReplyTo != "" || c.Hunk != 0 || c.Also != "") {
+		return ergo.New("--on-base starts a thread on the base and cannot be combined with --summary, --reply-to, --hunk or --also-commit")
+	}
+	if c.Summary && c.Fixup != "" {
+		return ergo.New("--fixup applies to a thread, not to the review summary")
+	}
+	var fixup null.String
+	if c.Fixup != "" {
+		sha, err := g.Run("rev-parse", "--verify", c.Fixup+"^{commit}")
 		if err != nil {
-			return ergo.New("comment not found", slog.String("reply_to", c.ReplyTo))
+			return ergo.WithCode(
+				ergo.New("invalid ref", slog.String("ref", c.Fixup)),
+				internal.ErrCodeInvalidRef)
 		}
+		fixup = null.StringFrom(sha)
+	}
+	var resolve func(q *db.Queries) error // resolves the thread with the comment, for --resolve and --fixup
+	var resolveID uuid.UUID               // root of the thread resolve resolves
 
+	if c.Summary {
+		// Summary mode: no commit, so it does not depend on the reviewer's position
 		params = db.InsertCommentParams{
-			ID:        newID,
-			ParentID:  uuid.NullUUID{UUID: parent.ID, Valid: true},
-			Commit:    parent.Commit,
-			File:      parent.File,
-			StartLine: parent.StartLine,
-			EndLine:   parent.EndLine,
+			ID:        uuid.Must(uuid.NewV7()),
 			Body:      c.Message,
-			CreatedAt: now,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
 			CreatedBy: author,
 		}
+	} else if c.OnBase {
+		// Base mode: about code the branch started from, so it does not depend on the
+		// reviewer's position. It is kept on the first commit, where notes carry it.
+		var err error
+		if params, moreFiles, err = c.baseParams(ctx, g, q, author); err != nil {
+			return err
+		}
+	} else if c.ReplyTo != "" {
+		// Reply mode: find parent, inherit commit from parent
+		parent, err := findComment(ctx, q, c.ReplyTo)
+		if err != nil {
+			return err
+		}
+
+		params = replyParams(parent, c.Message, author)
+
+		if c.Resolve || fixup.Valid {
+			root, err := threadRoot(ctx, q, parent)
+			if err != nil {
+				return err
+			}
+			if root.ResolvedAt.Valid && c.Resolve {
+				return ergo.New("thread is already resolved", slog.String("comment_id", root.ID.String()))
+			}
+			if !root.ResolvedAt.Valid {
+				resolveID = root.ID
+				resolve = func(q *db.Queries) error {
+					return resolveThread(ctx, q, root, author, params.CreatedAt)
+				}
+			}
+		}
 	} else {
 		// Non-reply: get reviewer's current commit
 		reviewer, err := q.GetReviewer(ctx, g.Reviewer)
@@ -98,45 +271,360 @@ func (c *AddCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output
 			return ergo.New("No commit selected. Run 'git review next' first.")
 		}
 		commitSHA := reviewer.CurrentSha.String
+		warnIfDrifted(ctx, g, q, out, commitSHA)
 
 		startLine, endLine, err := parseLineRange(c.Line)
 		if err != nil {
 			return err
 		}
+		if len(c.File) > 1 && (c.Line != "" || c.Hunk != 0) {
+			return ergo.New("--line and --hunk apply to a single --file")
+		}
+		if c.Hunk != 0 {
+			if len(c.File) == 0 {
+				return ergo.New("--hunk requires --file")
+			}
+			startLine, endLine, err = hunkRange(g, commitSHA, c.File[0], c.Hunk)
+			if err != nil {
+				return err
+			}
+		}
 
 		var file null.String
-		if c.File != "" {
-			file = null.StringFrom(c.File)
+		for i, f := range c.File {
+			if slices.Contains(c.File[:i], f) {
+				return ergo.New("--file given twice", slog.String("file", f))
+			}
+			if !c.Force {
+				if err := requireChangedFile(ctx, g, q, commitSHA, f); err != nil {
+					return err
+				}
+			}
+		}
+		if len(c.File) == 1 && startLine.Valid && !c.Force {
+			if err := requireTextFile(g, commitSHA, c.File[0]); err != nil {
+				return err
+			}
+		}
+		if len(c.File) > 0 {
+			file = null.StringFrom(c.File[0])
+			moreFiles = c.File[1:]
+		}
+
+		var also null.String
+		if c.Also != "" {
+			other, err := findCommit(ctx, q, c.Also)
+			if err != nil {
+				return err
+			}
+			if other.Sha == commitSHA {
+				return ergo.New("--also-commit must name a commit other than the current one", slog.String("hash", c.Also))
+			}
+			also = null.StringFrom(other.Sha)
 		}
 
 		params = db.InsertCommentParams{
-			ID:        newID,
-			Commit:    commitSHA,
-			File:      file,
-			StartLine: startLine,
-			EndLine:   endLine,
-			Body:      c.Message,
-			CreatedAt: now,
-			CreatedBy: author,
+			ID:         uuid.Must(uuid.NewV7()),
+			Commit:     null.StringFrom(commitSHA),
+			File:       file,
+			StartLine:  startLine,
+			EndLine:    endLine,
+			Body:       c.Message,
+			CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+			CreatedBy:  author,
+			AlsoCommit: also,
+		}
+		if fixup.Valid {
+			resolveID = params.ID
+			resolve = func(q *db.Queries) error {
+				return resolveThread(ctx, q, db.Comment(params), author, params.CreatedAt)
+			}
 		}
 	}
 
-	if err := q.InsertComment(ctx, params); err != nil {
-		return ergo.Wrap(err, "failed to save comment")
+	params.NeedsResponse = c.Question
+	params.Fixup = fixup
+	if name := strings.TrimPrefix(c.Assign, "@"); name != "" {
+		params.Assignee = null.StringFrom(name)
+	}
+	if c.Severity != "" {
+		params.Severity = null.StringFrom(c.Severity)
 	}
 
-	idStr := internal.ShortID(newID)
-	if c.ReplyTo != "" {
+	if err := saveComment(ctx, g, repo, params, moreFiles, resolve); err != nil {
+		return err
+	}
+
+	if out.Porcelain {
+		out.Record("added", params.ID.String(), params.Commit.String)
+		if resolve != nil {
+			out.Record("resolved", resolveID.String())
+		}
+		return nil
+	}
+
+	idStr := internal.ShortID(params.ID)
+	span := ""
+	if params.AlsoCommit.Valid {
+		span = "(" + internal.ShortSHA(params.Commit.String) + "↔" + internal.ShortSHA(params.AlsoCommit.String) + ") "
+	}
+	if c.Summary {
+		out.Ok(fmt.Sprintf("[%s] Review summary: %s", idStr, c.Message))
+	} else if c.OnBase {
+		loc := strings.Join(c.File, ", ")
+		if lr := internal.FormatLineRange(params.StartLine, params.EndLine); lr != "" {
+			loc += ":" + lr
+		}
+		if loc != "" {
+			loc += " "
+		}
+		out.Ok(fmt.Sprintf("[%s] (base) %s%s", idStr, loc, c.Message))
+	} else if c.ReplyTo != "" {
 		out.Ok(fmt.Sprintf("[%s] %s", idStr, c.Message))
-	} else if c.File != "" {
-		loc := c.File
+		if resolve != nil {
+			out.Ok(fmt.Sprintf("Resolved [%s]", internal.ShortID(resolveID)))
+		}
+	} else if len(c.File) > 0 {
+		loc := strings.Join(c.File, ", ")
 		if lr := internal.FormatLineRange(params.StartLine, params.EndLine); lr != "" {
 			loc += ":" + lr
 		}
-		out.Ok(fmt.Sprintf("[%s] %s %s", idStr, loc, c.Message))
+		out.Ok(fmt.Sprintf("[%s] %s%s %s", idStr, span, loc, c.Message))
 	} else {
-		out.Ok(fmt.Sprintf("[%s] %s", idStr, c.Message))
+		out.Ok(fmt.Sprintf("[%s] %s%s", idStr, span, c.Message))
+	}
+	if fixup.Valid && c.ReplyTo == "" {
+		out.Ok(fmt.Sprintf("Resolved [%s]", idStr))
 	}
 
 	return nil
 }
+
+// baseParams builds a comment on the review's base for --on-base. Its files must exist
+// at the base, unless --force.
+func (c *AddCmd) baseParams(ctx context.Context, g *git.Git, q *db.Queries, author string) (db.InsertCommentParams, []string, error) {
+	session, err := q.GetSession(ctx)
+	if err != nil {
+		return db.InsertCommentParams{}, nil, ergo.Wrap(err, "failed to get session")
+	}
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return db.InsertCommentParams{}, nil, ergo.Wrap(err, "failed to list commits")
+	}
+	if len(commits) == 0 {
+		return db.InsertCommentParams{}, nil, ergo.New("the review has no commits to keep a base comment on")
+	}
+
+	startLine, endLine, err := parseLineRange(c.Line)
+	if err != nil {
+		return db.InsertCommentParams{}, nil, err
+	}
+	if len(c.File) > 1 && c.Line != "" {
+		return db.InsertCommentParams{}, nil, ergo.New("--line applies to a single --file")
+	}
+	for i, f := range c.File {
+		if slices.Contains(c.File[:i], f) {
+			return db.InsertCommentParams{}, nil, ergo.New("--file given twice", slog.String("file", f))
+		}
+		if !c.Force && !g.FileExists(session.BaseRef, f) {
+			return db.InsertCommentParams{}, nil, ergo.New(fmt.Sprintf("%s does not exist at the base %s.\n  Pass --force to comment on it anyway.", f, internal.ShortSHA(session.BaseRef)),
+				slog.String("file", f), slog.String("sha", session.BaseRef))
+		}
+	}
+
+	params := db.InsertCommentParams{
+		ID:        uuid.Must(uuid.NewV7()),
+		Commit:    null.StringFrom(commits[0].Sha),
+		StartLine: startLine,
+		EndLine:   endLine,
+		Body:      c.Message,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		CreatedBy: author,
+		OnBase:    true,
+	}
+	var moreFiles []string
+	if len(c.File) > 0 {
+		params.File = null.StringFrom(c.File[0])
+		moreFiles = c.File[1:]
+	}
+	return params, moreFiles, nil
+}
+
+// warnIfDrifted warns when the worktree no longer shows the commit the reviewer is on,
+// e.g. after a manual checkout: the comment still goes on the recorded commit, which may
+// not be the code the reviewer was reading.
+func warnIfDrifted(ctx context.Context, g *git.Git, q *db.Queries, out *output.Output, sha string) {
+	head, err := g.Run("rev-parse", "HEAD")
+	if err != nil {
+		return // don't block commenting on a git failure
+	}
+	session, err := q.GetSession(ctx)
+	if err != nil || session.AnnotateOnly {
+		return // an annotate-only review never checks the commit out
+	}
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return
+	}
+	if head == diffParent(g, session, commits, sha) {
+		return
+	}
+	pos := findCommitPosition(commits, sha) + 1
+	out.Warn(fmt.Sprintf("this worktree was checked out elsewhere (HEAD is %s); the comment goes on commit %d/%d %s.\n  Run 'git review jump %d' to show that commit again.",
+		internal.ShortSHA(head), pos, len(commits), internal.ShortSHA(sha), pos))
+}
+
+// requireChangedFile rejects a file comment on a commit that does not touch the file,
+// which usually means the reviewer is on the wrong commit. The error names the reviewed
+// commits that do change it.
+func requireChangedFile(ctx context.Context, g *git.Git, q *db.Queries, sha, file string) error {
+	changed, err := g.ChangedFiles(sha)
+	if err != nil || slices.Contains(changed, file) {
+		return nil // don't block commenting on a git failure
+	}
+
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	var changing []string
+	for _, cm := range commits {
+		if cm.Sha == sha {
+			continue
+		}
+		if files, err := g.ChangedFiles(cm.Sha); err == nil && slices.Contains(files, file) {
+			changing = append(changing, fmt.Sprintf("    %s %s", internal.ShortSHA(cm.Sha), cm.Message))
+		}
+	}
+
+	msg := fmt.Sprintf("%s is not changed in commit %s.", file, internal.ShortSHA(sha))
+	if len(changing) > 0 {
+		msg += "\n  It is changed in:\n" + strings.Join(changing, "\n") +
+			"\n  Switch with 'git review jump <hash>', or pass --force to comment here anyway."
+	} else {
+		msg += "\n  Pass --force to comment on it anyway."
+	}
+	return ergo.New(msg, slog.String("file", file), slog.String("sha", sha))
+}
+
+// requireTextFile refuses a line comment on a file git treats as binary, since its line
+// numbers point at nothing a reader can find.
+func requireTextFile(g *git.Git, sha, file string) error {
+	binary, err := g.IsBinary(sha, file)
+	if err != nil || !binary {
+		return nil // don't block commenting on a git failure
+	}
+	return ergo.New(fmt.Sprintf("%s is a binary file in commit %s, so line numbers are meaningless there.\n"+
+		"  Drop --line to comment on the whole file, or pass --force to keep it.", file, internal.ShortSHA(sha)),
+		slog.String("file", file), slog.String("sha", sha))
+}
+
+// threadRoot walks up from c to the root comment of its thread.
+func threadRoot(ctx context.Context, q *db.Queries, c db.Comment) (db.Comment, error) {
+	for c.ParentID.Valid {
+		parent, err := q.GetComment(ctx, c.ParentID.UUID)
+		if err != nil {
+			return db.Comment{}, ergo.Wrap(err, "failed to get parent comment", slog.String("comment_id", c.ParentID.UUID.String()))
+		}
+		c = parent
+	}
+	return c, nil
+}
+
+// replyParams builds a reply to parent, inheriting its commit and location.
+func replyParams(parent db.Comment, body, author string) db.InsertCommentParams {
+	return db.InsertCommentParams{
+		ID:        uuid.Must(uuid.NewV7()),
+		ParentID:  uuid.NullUUID{UUID: parent.ID, Valid: true},
+		Commit:    parent.Commit,
+		File:      parent.File,
+		StartLine: parent.StartLine,
+		EndLine:   parent.EndLine,
+		OnBase:    parent.OnBase,
+		Body:      body,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		CreatedBy: author,
+	}
+}
+
+// saveComment inserts a comment, recording the tree it was made against so later amends
+// can be flagged. Replies clear pending questions they answer. then, if not nil, runs in
+// the same transaction after the insert.
+func saveComment(ctx context.Context, g *git.Git, repo *repository.Repository, params db.InsertCommentParams, moreFiles []string, then func(q *db.Queries) error) error {
+	q := repo.Queries()
+
+	session, err := q.GetSession(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to get session")
+	}
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	// A base comment is about the base, which amends to the commit it is kept on do not change
+	if counterpart, ok := branchCounterparts(g, session, commits)[params.Commit.String]; ok && !params.OnBase {
+		if tree, err := g.TreeSHA(counterpart); err == nil {
+			params.Tree = null.StringFrom(tree)
+		}
+	}
+
+	return repo.WithTx(ctx, func(q *db.Queries) error {
+		var rec undoRecord
+		if params.ParentID.Valid {
+			if rec, err = snapshotThreads(ctx, q, params.ParentID.UUID); err != nil {
+				return err
+			}
+		}
+		rec.Added = []uuid.UUID{params.ID}
+
+		if err := q.InsertComment(ctx, params); err != nil {
+			return ergo.Wrap(err, "failed to save comment")
+		}
+		if err := insertMoreFiles(ctx, q, params.ID, moreFiles); err != nil {
+			return err
+		}
+		if params.ParentID.Valid {
+			if err := answerQuestions(ctx, q, params.ParentID.UUID, params.CreatedBy); err != nil {
+				return err
+			}
+		}
+		if then != nil {
+			if err := then(q); err != nil {
+				return err
+			}
+		}
+		return logAction(ctx, q, g.Reviewer, params.CreatedBy, "add", fmt.Sprintf("add of [%s]", internal.ShortID(params.ID)), rec)
+	})
+}
+
+// insertMoreFiles records the files after the first of a comment on several files.
+func insertMoreFiles(ctx context.Context, q *db.Queries, id uuid.UUID, files []string) error {
+	for i, f := range files {
+		if err := q.InsertCommentFile(ctx, db.InsertCommentFileParams{CommentID: id, Position: int64(i + 1), File: f}); err != nil {
+			return ergo.Wrap(err, "failed to save comment file", slog.String("file", f))
+		}
+	}
+	return nil
+}
+
+// answerQuestions clears the needs-response flag on the ancestors of a new reply,
+// except for questions asked by the reply's own author.
+func answerQuestions(ctx context.Context, q *db.Queries, parentID uuid.UUID, author string) error {
+	id := parentID
+	for {
+		cm, err := q.GetComment(ctx, id)
+		if err != nil {
+			return ergo.Wrap(err, "failed to get comment", slog.String("comment_id", id.String()))
+		}
+		if cm.NeedsResponse && cm.CreatedBy != author {
+			if err := q.ClearNeedsResponse(ctx, cm.ID); err != nil {
+				return ergo.Wrap(err, "failed to clear needs-response",
+					slog.String("comment_id", cm.ID.String()))
+			}
+		}
+		if !cm.ParentID.Valid {
+			return nil
+		}
+		id = cm.ParentID.UUID
+	}
+}
@@ -1,7 +1,9 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/guregu/null/v6"
@@ -25,6 +27,15 @@ func TestParseLineRange(t *testing.T) {
 		{"non-numeric end", "42,abc", null.Int{}, null.Int{}, true},
 		{"decimal", "10.5,20", null.Int{}, null.Int{}, true},
 		{"start exceeds end", "35,10", null.Int{}, null.Int{}, true},
+		{"hyphen range", "10-25", null.IntFrom(10), null.IntFrom(25), false},
+		{"hyphen same start and end", "5-5", null.IntFrom(5), null.IntFrom(5), false},
+		{"hyphen start exceeds end", "25-10", null.Int{}, null.Int{}, true},
+		{"open end", "10,", null.IntFrom(10), null.Int{}, false},
+		{"hyphen open end", "10-", null.IntFrom(10), null.Int{}, false},
+		{"open start", ",25", null.IntFrom(1), null.IntFrom(25), false},
+		{"spaces", " 10 , 25 ", null.IntFrom(10), null.IntFrom(25), false},
+		{"separator only", ",", null.Int{}, null.Int{}, true},
+		{"non-numeric open end", "abc,", null.Int{}, null.Int{}, true},
 	}
 
 	for _, tt := range tests {
@@ -44,6 +55,26 @@ func TestParseLineRange(t *testing.T) {
 	}
 }
 
+func TestFileArgsUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want []string
+	}{
+		{`"a.go"`, []string{"a.go"}},
+		{`""`, nil},
+		{`["a.go","b.go"]`, []string{"a.go", "b.go"}},
+	}
+	for _, tt := range tests {
+		var f fileArgs
+		if err := json.Unmarshal([]byte(tt.json), &f); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.json, err)
+		}
+		if !slices.Equal(f, tt.want) {
+			t.Errorf("Unmarshal(%s) = %q, want %q", tt.json, f, tt.want)
+		}
+	}
+}
+
 func nullIntEqual(a, b null.Int) bool {
 	if !a.Valid && !b.Valid {
 		return true
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/newmo-oss/ergo"
+)
+
+// issueLink turns matches of a pattern into links. URL may reference capture
+// groups as $1, ${name}, etc.
+type issueLink struct {
+	pattern *regexp.Regexp
+	url     string
+}
+
+// issueLinker renders issue references in comment bodies as links at display time.
+// Stored bodies are never changed.
+type issueLinker []issueLink
+
+// loadIssueLinker reads review.issueLinkPattern / review.issueLinkUrl from git config.
+// Both keys may be given several times; the n-th pattern pairs with the n-th URL.
+func loadIssueLinker(g *git.Git) (issueLinker, error) {
+	patterns := g.ConfigAll("review.issueLinkPattern")
+	urls := g.ConfigAll("review.issueLinkUrl")
+	if len(patterns) != len(urls) {
+		return nil, ergo.New("review.issueLinkPattern and review.issueLinkUrl must be set the same number of times",
+			slog.Int("patterns", len(patterns)), slog.Int("urls", len(urls)))
+	}
+
+	var links issueLinker
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, ergo.Wrap(err, "invalid review.issueLinkPattern "+pattern)
+		}
+		links = append(links, issueLink{pattern: re, url: urls[i]})
+	}
+	return links, nil
+}
+
+// render rewrites body, passing plain text through text and each issue reference through
+// link. When patterns overlap, the leftmost match wins, then the pattern configured first.
+func (l issueLinker) render(body string, text func(string) string, link func(label, href string) string) string {
+	var b strings.Builder
+	for body != "" {
+		var best *issueLink
+		var loc []int
+		for i := range l {
+			m := l[i].pattern.FindStringSubmatchIndex(body)
+			if m != nil && m[1] > m[0] && (loc == nil || m[0] < loc[0]) {
+				best, loc = &l[i], m
+			}
+		}
+		if best == nil {
+			b.WriteString(text(body))
+			break
+		}
+		href := best.pattern.ExpandString(nil, best.url, body, loc)
+		b.WriteString(text(body[:loc[0]]))
+		b.WriteString(link(body[loc[0]:loc[1]], string(href)))
+		body = body[loc[1]:]
+	}
+	return b.String()
+}
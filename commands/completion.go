@@ -0,0 +1,262 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/alecthomas/kong"
+	"github.com/newmo-oss/ergo"
+)
+
+// CompletionCmd emits a shell completion script generated from the kong model.
+// Arguments tagged with `completion:"<kind>"` are completed dynamically via `__complete`.
+type CompletionCmd struct {
+	Shell string `arg:"" enum:"bash,zsh,fish" help:"Shell to generate the completion script for (bash, zsh, fish)."`
+}
+
+// CompleteCmd prints dynamic completion candidates, one per line.
+type CompleteCmd struct {
+	Kind string `arg:"" enum:"ids,commits,files" help:"Kind of candidates to print."`
+}
+
+// completionCommand describes a visible subcommand for script generation.
+type completionCommand struct {
+	name       string
+	help       string
+	flags      []completionFlag
+	positional string // dynamic completion kind of the positional argument, or ""
+}
+
+// completionFlag describes a flag of a subcommand for script generation.
+type completionFlag struct {
+	long     string
+	short    rune
+	help     string
+	takesArg bool
+	kind     string // dynamic completion kind of the flag value, or ""
+}
+
+func (c *CompletionCmd) Run(kctx *kong.Context, out *output.Output) error {
+	cmds := completionCommands(kctx.Model.Node)
+	switch c.Shell {
+	case "bash":
+		fmt.Fprint(out.Stdout, bashCompletion(cmds))
+	case "zsh":
+		fmt.Fprint(out.Stdout, "#compdef git-review\n\nautoload -U +X bashcompinit && bashcompinit\n\n")
+		fmt.Fprint(out.Stdout, bashCompletion(cmds))
+	case "fish":
+		fmt.Fprint(out.Stdout, fishCompletion(cmds))
+	}
+	return nil
+}
+
+func (c *CompleteCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	candidates, err := completionCandidates(g, repo, c.Kind)
+	if err != nil {
+		return err
+	}
+	for _, s := range candidates {
+		fmt.Fprintln(out.Stdout, s)
+	}
+	return nil
+}
+
+// completionCandidates queries the active review (or the repository) for candidates of the given kind.
+// Without an active review, comment IDs and commits have no candidates and files fall back to ls-files.
+func completionCandidates(g *git.Git, repo *repository.Repository, kind string) ([]string, error) {
+	ctx := context.Background()
+
+	if repo == nil {
+		if kind == "files" {
+			return g.LsFiles()
+		}
+		return nil, nil
+	}
+	q := repo.Queries()
+
+	switch kind {
+	case "ids":
+		comments, err := q.ListAllComments(ctx)
+		if err != nil {
+			return nil, ergo.Wrap(err, "failed to load comments")
+		}
+		// Offer prefixes long enough to be accepted without an ambiguity error.
+		ids := make([]string, len(comments))
+		for i, cm := range comments {
+			ids[i] = cm.ID.String()
+		}
+		return internal.UniquePrefixes(ids, 8), nil
+	case "commits":
+		commits, err := q.ListCommits(ctx)
+		if err != nil {
+			return nil, ergo.Wrap(err, "failed to list commits")
+		}
+		shas := make([]string, len(commits))
+		for i, cm := range commits {
+			shas[i] = cm.Sha
+		}
+		return internal.UniquePrefixes(shas, 7), nil
+	default:
+		reviewer, err := q.GetReviewer(ctx, g.Reviewer)
+		if err != nil || !reviewer.CurrentSha.Valid {
+			return g.LsFiles()
+		}
+		return g.ChangedFiles(reviewer.CurrentSha.String)
+	}
+}
+
+// completionCommands collects the visible subcommands and their flags from the kong model.
+func completionCommands(app *kong.Node) []completionCommand {
+	var cmds []completionCommand
+	for _, n := range app.Children {
+		if n.Hidden {
+			continue
+		}
+		cmd := completionCommand{name: n.Name, help: n.Help}
+		for _, f := range n.Flags {
+			if f.Hidden {
+				continue
+			}
+			cmd.flags = append(cmd.flags, completionFlag{
+				long:     f.Name,
+				short:    f.Short,
+				help:     f.Help,
+				takesArg: !f.IsBool(),
+				kind:     f.Tag.Get("completion"),
+			})
+		}
+		for _, p := range n.Positional {
+			if kind := p.Tag.Get("completion"); kind != "" {
+				cmd.positional = kind
+				break
+			}
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+func bashCompletion(cmds []completionCommand) string {
+	var b strings.Builder
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.name
+	}
+
+	b.WriteString(`# bash completion for git-review
+#   source <(git-review completion bash)
+
+__git_review_complete() {
+	git-review __complete "$1" 2>/dev/null
+}
+
+_git_review() {
+	local cur prev cmd i start=1
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+	[[ "${COMP_WORDS[0]}" == git ]] && start=2
+	for ((i = start; i < COMP_CWORD; i++)); do
+		case "${COMP_WORDS[i]}" in
+		-*) ;;
+		*) cmd="${COMP_WORDS[i]}"; break ;;
+		esac
+	done
+
+	if [[ -z "$cmd" ]]; then
+`)
+	fmt.Fprintf(&b, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	b.WriteString("\t\treturn\n\tfi\n\n")
+
+	// Flag values: complete dynamically when tagged, otherwise offer nothing.
+	b.WriteString("\tcase \"$cmd $prev\" in\n")
+	for _, cmd := range cmds {
+		for _, f := range cmd.flags {
+			if !f.takesArg {
+				continue
+			}
+			pattern := fmt.Sprintf("%q", cmd.name+" --"+f.long)
+			if f.short != 0 {
+				pattern = fmt.Sprintf("%q|%s", cmd.name+" -"+string(f.short), pattern)
+			}
+			fmt.Fprintf(&b, "\t%s)\n", pattern)
+			if f.kind != "" {
+				fmt.Fprintf(&b, "\t\tCOMPREPLY=($(compgen -W \"$(__git_review_complete %s)\" -- \"$cur\"))\n", f.kind)
+			}
+			b.WriteString("\t\treturn\n\t\t;;\n")
+		}
+	}
+	b.WriteString("\tesac\n\n")
+
+	b.WriteString("\tif [[ \"$cur\" == -* ]]; then\n\t\tcase \"$cmd\" in\n")
+	for _, cmd := range cmds {
+		if len(cmd.flags) == 0 {
+			continue
+		}
+		longs := make([]string, len(cmd.flags))
+		for i, f := range cmd.flags {
+			longs[i] = "--" + f.long
+		}
+		fmt.Fprintf(&b, "\t\t%s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", cmd.name, strings.Join(longs, " "))
+	}
+	b.WriteString("\t\tesac\n\t\treturn\n\tfi\n\n")
+
+	b.WriteString("\tcase \"$cmd\" in\n")
+	for _, cmd := range cmds {
+		if cmd.positional == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s) COMPREPLY=($(compgen -W \"$(__git_review_complete %s)\" -- \"$cur\")) ;;\n", cmd.name, cmd.positional)
+	}
+	b.WriteString("\tesac\n}\n\ncomplete -F _git_review git-review\n")
+
+	return b.String()
+}
+
+func fishCompletion(cmds []completionCommand) string {
+	var b strings.Builder
+	b.WriteString(`# fish completion for git-review
+#   git-review completion fish | source
+
+function __git_review_complete
+	git-review __complete $argv[1] 2>/dev/null
+end
+
+complete -c git-review -f
+`)
+	for _, cmd := range cmds {
+		fmt.Fprintf(&b, "complete -c git-review -n __fish_use_subcommand -a %s -d %s\n", cmd.name, fishQuote(cmd.help))
+	}
+	for _, cmd := range cmds {
+		cond := fishQuote("__fish_seen_subcommand_from " + cmd.name)
+		for _, f := range cmd.flags {
+			line := fmt.Sprintf("complete -c git-review -n %s -l %s", cond, f.long)
+			if f.short != 0 {
+				line += " -s " + string(f.short)
+			}
+			if f.takesArg {
+				line += " -r"
+			}
+			if f.kind != "" {
+				line += " -a " + fishQuote("(__git_review_complete "+f.kind+")")
+			}
+			line += " -d " + fishQuote(f.help)
+			b.WriteString(line + "\n")
+		}
+		if cmd.positional != "" {
+			fmt.Fprintf(&b, "complete -c git-review -n %s -a %s\n", cond, fishQuote("(__git_review_complete "+cmd.positional+")"))
+		}
+	}
+	return b.String()
+}
+
+// fishQuote single-quotes s for fish, escaping embedded backslashes and quotes.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/newmo-oss/ergo"
+)
+
+// defaultBaseBranches are tried in order when neither a base ref nor review.baseBranches is given.
+var defaultBaseBranches = []string{"main", "master", "develop"}
+
+// reviewConfig holds user settings stored in git config under review.*,
+// so they outlive the per-review .git/review directory.
+type reviewConfig struct {
+	DefaultReviewer string   // review.defaultReviewer: reviewer name used by start when -a is omitted
+	BaseBranches    []string // review.baseBranches (multi-valued): candidates for base auto-detection
+}
+
+// loadConfig reads review.* settings, falling back to the built-in defaults.
+func loadConfig(g *git.Git) reviewConfig {
+	cfg := reviewConfig{BaseBranches: defaultBaseBranches}
+	if v := g.ConfigAll("review.defaultReviewer"); len(v) > 0 {
+		cfg.DefaultReviewer = v[len(v)-1] // last one wins, as with git config --get
+	}
+	if v := g.ConfigAll("review.baseBranches"); len(v) > 0 {
+		cfg.BaseBranches = v
+	}
+	return cfg
+}
+
+type ConfigCmd struct {
+	Key    string   `arg:"" optional:"" help:"Setting to change: defaultReviewer or baseBranches. Omit to show all settings."`
+	Values []string `arg:"" optional:"" help:"New value(s). baseBranches takes several, tried in order."`
+	Unset  bool     `help:"Remove the setting, restoring the default."`
+}
+
+func (c *ConfigCmd) Run(g *git.Git, out *output.Output) error {
+	if c.Key == "" {
+		if len(c.Values) > 0 || c.Unset {
+			return ergo.New("specify a setting: defaultReviewer or baseBranches")
+		}
+		cfg := loadConfig(g)
+		reviewer := cfg.DefaultReviewer
+		if reviewer == "" {
+			reviewer = "(none)"
+		}
+		out.Printf("defaultReviewer  %s\n", reviewer)
+		out.Printf("baseBranches     %s\n", strings.Join(cfg.BaseBranches, " "))
+		return nil
+	}
+
+	if c.Key != "defaultReviewer" && c.Key != "baseBranches" {
+		return ergo.New(fmt.Sprintf("unknown setting %q: expected defaultReviewer or baseBranches", c.Key))
+	}
+
+	key := "review." + c.Key
+	switch {
+	case c.Unset:
+		if len(c.Values) > 0 {
+			return ergo.New("--unset takes no values")
+		}
+	case len(c.Values) == 0:
+		return ergo.New(fmt.Sprintf("specify a value for %s, or --unset", c.Key))
+	case c.Key == "defaultReviewer" && len(c.Values) > 1:
+		return ergo.New("defaultReviewer takes a single name")
+	}
+
+	if err := g.SetConfigAll(key, c.Values); err != nil {
+		return ergo.Wrap(err, "failed to update git config")
+	}
+	if c.Unset {
+		out.Ok(fmt.Sprintf("Unset %s", key))
+	} else {
+		out.Ok(fmt.Sprintf("Set %s = %s", key, strings.Join(c.Values, " ")))
+	}
+	return nil
+}
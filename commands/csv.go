@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"cmp"
+	"encoding/csv"
+	"slices"
+	"strconv"
+
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/guregu/null/v6"
+	"github.com/newmo-oss/ergo"
+)
+
+var csvHeader = []string{"id", "parentId", "commit", "file", "startLine", "endLine", "body", "createdBy", "createdAt", "resolvedAt", "resolvedBy"}
+
+// printCSV writes one row per comment for spreadsheets: review summaries first, then by
+// commit in review order, each commit's comments in the order they were made. A comment
+// on several files lists them all in its file column, separated by commas.
+func printCSV(out *output.Output, commits []db.Commit, comments []db.Comment, files commentFiles) error {
+	rows := slices.Clone(comments)
+	slices.SortStableFunc(rows, func(a, b db.Comment) int {
+		return cmp.Or(
+			cmp.Compare(findCommitPosition(commits, a.Commit.String), findCommitPosition(commits, b.Commit.String)),
+			cmp.Compare(a.ID.String(), b.ID.String()))
+	})
+
+	w := csv.NewWriter(out.Stdout)
+	if err := w.Write(csvHeader); err != nil {
+		return ergo.Wrap(err, "failed to write CSV")
+	}
+	for _, c := range rows {
+		parentID := ""
+		if c.ParentID.Valid {
+			parentID = c.ParentID.UUID.String()
+		}
+		if err := w.Write([]string{
+			c.ID.String(),
+			parentID,
+			c.Commit.String,
+			files.label(c),
+			csvInt(c.StartLine),
+			csvInt(c.EndLine),
+			c.Body,
+			c.CreatedBy,
+			c.CreatedAt,
+			c.ResolvedAt.String,
+			c.ResolvedBy.String,
+		}); err != nil {
+			return ergo.Wrap(err, "failed to write CSV")
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// csvInt formats a nullable number as an empty cell when it is null.
+func csvInt(n null.Int) string {
+	if !n.Valid {
+		return ""
+	}
+	return strconv.FormatInt(n.Int64, 10)
+}
@@ -3,8 +3,12 @@ package commands
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 
+	"github.com/FujishigeTemma/git-review/internal"
 	"github.com/FujishigeTemma/git-review/internal/db"
 	"github.com/FujishigeTemma/git-review/internal/git"
 	"github.com/FujishigeTemma/git-review/internal/output"
@@ -14,7 +18,9 @@ import (
 )
 
 type DeleteCmd struct {
-	ID string `arg:"" help:"ID (or prefix) of the comment to delete."`
+	IDs     []string `arg:"" name:"id" help:"IDs (or prefixes) of the comments to delete." completion:"ids"`
+	Promote bool     `help:"When deleting a root, keep its replies: the oldest reply becomes the new root."`
+	DryRun  bool     `name:"dry-run" help:"Show which comments would be deleted with a thread, or moved to a new parent, without deleting anything."`
 }
 
 func (c *DeleteCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
@@ -23,11 +29,72 @@ func (c *DeleteCmd) Run(g *git.Git, repo *repository.Repository, out *output.Out
 	}
 
 	ctx := context.Background()
+	if c.DryRun {
+		return c.dryRun(ctx, repo, out)
+	}
 
+	var deleted, promoted []db.Comment
+	var failed int
 	if err := repo.WithTx(ctx, func(q *db.Queries) error {
-		target, err := q.FindCommentByPrefix(ctx, sql.NullString{String: c.ID, Valid: true})
+		var rec undoRecord
+		var err error
+		deleted, promoted, failed, rec, err = c.deleteComments(ctx, q, out)
+		if err != nil || len(deleted) == 0 {
+			return err
+		}
+		return logAction(ctx, q, g.Reviewer, defaultAuthor(g), "delete", commentsSummary("delete", deleted, "comment", "comments"), rec)
+	}); err != nil {
+		return err
+	}
+
+	switch {
+	case out.Porcelain:
+		for _, cm := range deleted {
+			out.Record("deleted", cm.ID.String())
+		}
+		for _, cm := range promoted {
+			out.Record("promoted", cm.ID.String())
+		}
+	case len(c.IDs) == 1 && len(promoted) == 1:
+		out.Ok(fmt.Sprintf("Comment deleted. [%s] is now the root of the thread.", internal.ShortID(promoted[0].ID)))
+	case len(c.IDs) == 1:
+		out.Ok("Comment deleted.")
+	default:
+		for _, cm := range deleted {
+			out.Ok(fmt.Sprintf("Deleted [%s]", internal.ShortID(cm.ID)))
+		}
+		for _, cm := range promoted {
+			out.Info(fmt.Sprintf("[%s] is now the root of its thread.", internal.ShortID(cm.ID)))
+		}
+	}
+	if failed > 0 {
+		return batchFailed(failed, len(c.IDs), "comments could not be deleted")
+	}
+	return nil
+}
+
+// deleteComments deletes the targets in order and returns them, the replies promoted to
+// roots in their place, how many IDs matched nothing, and the threads as they were for
+// undo. A root takes all its replies with it, unless --promote keeps them under the
+// oldest one, and the replies of a reply move up to its parent.
+func (c *DeleteCmd) deleteComments(ctx context.Context, q *db.Queries, out *output.Output) (deleted, promoted []db.Comment, failed int, rec undoRecord, err error) {
+	targets, failed, err := findComments(ctx, q, out, c.IDs, func(db.Comment) error { return nil })
+	if err != nil || len(targets) == 0 {
+		return nil, nil, failed, undoRecord{}, err
+	}
+	if rec, err = snapshotThreads(ctx, q, commentIDs(targets)...); err != nil {
+		return nil, nil, 0, undoRecord{}, err
+	}
+
+	for _, t := range targets {
+		// Re-read: an earlier deletion may have removed or re-parented it
+		target, err := q.GetComment(ctx, t.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			deleted = append(deleted, t)
+			continue
+		}
 		if err != nil {
-			return ergo.New("comment not found", slog.String("comment_id", c.ID))
+			return nil, nil, 0, undoRecord{}, ergo.Wrap(err, "failed to get comment", slog.String("comment_id", t.ID.String()))
 		}
 
 		// If non-root: re-parent children to this comment's parent
@@ -36,20 +103,174 @@ func (c *DeleteCmd) Run(g *git.Git, repo *repository.Repository, out *output.Out
 				ParentID:   target.ParentID,
 				ParentID_2: uuid.NullUUID{UUID: target.ID, Valid: true},
 			}); err != nil {
-				return ergo.Wrap(err, "failed to re-parent children")
+				return nil, nil, 0, undoRecord{}, ergo.Wrap(err, "failed to re-parent children")
+			}
+		}
+
+		if !target.ParentID.Valid && c.Promote {
+			p, err := promoteOldestReply(ctx, q, target)
+			if err != nil {
+				return nil, nil, 0, undoRecord{}, err
+			}
+			if p.ID != uuid.Nil {
+				promoted = append(promoted, p)
 			}
 		}
 
 		// Delete the comment (CASCADE handles root's children)
 		if err := q.DeleteComment(ctx, target.ID); err != nil {
-			return ergo.Wrap(err, "failed to delete comment")
+			return nil, nil, 0, undoRecord{}, ergo.Wrap(err, "failed to delete comment")
 		}
+		deleted = append(deleted, target)
+	}
+	return deleted, promoted, failed, rec, nil
+}
 
-		return nil
-	}); err != nil {
+// errDryRun rolls back the deletion that delete --dry-run makes to see its effects.
+var errDryRun = errors.New("dry run")
+
+// dryRun deletes the targets as Run would, inside a transaction it then rolls back, and
+// prints what changed: the comments deleted with each target, the replies promoted to
+// roots, and the comments moved to a new parent.
+func (c *DeleteCmd) dryRun(ctx context.Context, repo *repository.Repository, out *output.Output) error {
+	var before, after, deleted, promoted []db.Comment
+	var failed int
+	err := repo.WithTx(ctx, func(q *db.Queries) error {
+		var err error
+		if before, err = q.ListAllComments(ctx); err != nil {
+			return ergo.Wrap(err, "failed to load comments")
+		}
+		if deleted, promoted, failed, _, err = c.deleteComments(ctx, q, out); err != nil {
+			return err
+		}
+		if after, err = q.ListAllComments(ctx); err != nil {
+			return ergo.Wrap(err, "failed to load comments")
+		}
+		return errDryRun
+	})
+	if !errors.Is(err, errDryRun) {
 		return err
 	}
 
-	out.Ok("Comment deleted.")
+	// IDs made close together share their leading digits, so name each by its unique prefix
+	all := make([]string, len(before))
+	for i, cm := range before {
+		all[i] = cm.ID.String()
+	}
+	prefixOf := make(map[uuid.UUID]string, len(before))
+	for i, prefix := range internal.UniquePrefixes(all, 8) {
+		prefixOf[before[i].ID] = prefix
+	}
+	name := func(cs ...db.Comment) string {
+		names := make([]string, len(cs))
+		for i, cm := range cs {
+			names[i] = "[" + prefixOf[cm.ID] + "]"
+		}
+		return strings.Join(names, ", ")
+	}
+
+	remaining := make(map[uuid.UUID]db.Comment, len(after))
+	for _, cm := range after {
+		remaining[cm.ID] = cm
+	}
+	childrenMap := buildChildrenMap(before)
+	reported := map[uuid.UUID]bool{}
+	for _, t := range deleted {
+		if reported[t.ID] {
+			out.Printf("%s would already be deleted with its thread\n", name(t))
+			continue
+		}
+		reported[t.ID] = true
+		var cascade []db.Comment
+		for _, d := range descendants(childrenMap, t.ID) {
+			if _, ok := remaining[d.ID]; !ok && !reported[d.ID] {
+				cascade = append(cascade, d)
+				reported[d.ID] = true
+			}
+		}
+		if len(cascade) == 0 {
+			out.Printf("Would delete %s\n", name(t))
+		} else {
+			out.Printf("Would delete %s and its %d %s: %s\n", name(t),
+				len(cascade), internal.Pluralize(len(cascade), "reply", "replies"), name(cascade...))
+		}
+	}
+
+	// Group the comments that change parent under their new one
+	var parents []uuid.UUID
+	moved := map[uuid.UUID][]db.Comment{}
+	for _, cm := range before {
+		now, ok := remaining[cm.ID]
+		if !ok || !now.ParentID.Valid || now.ParentID == cm.ParentID {
+			continue
+		}
+		if moved[now.ParentID.UUID] == nil {
+			parents = append(parents, now.ParentID.UUID)
+		}
+		moved[now.ParentID.UUID] = append(moved[now.ParentID.UUID], cm)
+	}
+	for _, p := range promoted {
+		if rest := moved[p.ID]; len(rest) > 0 {
+			out.Printf("%s would become the root, with %s under it\n", name(p), name(rest...))
+		} else {
+			out.Printf("%s would become the root\n", name(p))
+		}
+		delete(moved, p.ID)
+	}
+	for _, p := range parents {
+		if rest := moved[p]; len(rest) > 0 {
+			out.Printf("%s would move under %s\n", name(rest...), name(db.Comment{ID: p}))
+		}
+	}
+
+	if failed > 0 {
+		return batchFailed(failed, len(c.IDs), "comments could not be deleted")
+	}
 	return nil
 }
+
+// promoteOldestReply makes the oldest direct reply to root a root in its place, carrying
+// over the thread's state (resolution, severity, assignee, fixup, extra files and the
+// like), and moves the other replies under it.
+// It returns the promoted comment, or a zero Comment when root has no replies.
+func promoteOldestReply(ctx context.Context, q *db.Queries, root db.Comment) (db.Comment, error) {
+	comments, err := q.ListAllComments(ctx)
+	if err != nil {
+		return db.Comment{}, ergo.Wrap(err, "failed to load comments")
+	}
+	// UUIDv7 IDs sort chronologically
+	var oldest db.Comment
+	for _, cm := range comments {
+		if cm.ParentID.Valid && cm.ParentID.UUID == root.ID && (oldest.ID == uuid.Nil || cm.ID.String() < oldest.ID.String()) {
+			oldest = cm
+		}
+	}
+	if oldest.ID == uuid.Nil {
+		return db.Comment{}, nil
+	}
+
+	if err := q.PromoteComment(ctx, db.PromoteCommentParams{
+		ResolvedAt:    root.ResolvedAt,
+		ResolvedBy:    root.ResolvedBy,
+		NeedsResponse: root.NeedsResponse,
+		Severity:      root.Severity,
+		AlsoCommit:    root.AlsoCommit,
+		Fixup:         root.Fixup,
+		Assignee:      root.Assignee,
+		OnBase:        root.OnBase,
+		ID:            oldest.ID,
+	}); err != nil {
+		return db.Comment{}, ergo.Wrap(err, "failed to promote reply", slog.String("comment_id", oldest.ID.String()))
+	}
+	// Deleting the root would otherwise take its extra files with it
+	if err := q.MoveCommentFiles(ctx, db.MoveCommentFilesParams{CommentID: oldest.ID, CommentID_2: root.ID}); err != nil {
+		return db.Comment{}, ergo.Wrap(err, "failed to move comment files", slog.String("comment_id", oldest.ID.String()))
+	}
+	if err := q.ReparentChildren(ctx, db.ReparentChildrenParams{
+		ParentID:   uuid.NullUUID{UUID: oldest.ID, Valid: true},
+		ParentID_2: uuid.NullUUID{UUID: root.ID, Valid: true},
+	}); err != nil {
+		return db.Comment{}, ergo.Wrap(err, "failed to re-parent replies")
+	}
+	return oldest, nil
+}
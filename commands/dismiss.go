@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/newmo-oss/ergo"
+)
+
+type DismissCmd struct {
+	ID string `arg:"" help:"ID (or prefix) of the question to dismiss." completion:"ids"`
+}
+
+func (c *DismissCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	q := repo.Queries()
+
+	comment, err := findComment(ctx, q, c.ID)
+	if err != nil {
+		return err
+	}
+
+	if !comment.NeedsResponse {
+		return ergo.New("comment is not awaiting a response")
+	}
+
+	if err := q.ClearNeedsResponse(ctx, comment.ID); err != nil {
+		return ergo.Wrap(err, "failed to clear needs-response")
+	}
+
+	out.Ok(fmt.Sprintf("Dismissed question [%s]", internal.ShortID(comment.ID)))
+
+	return nil
+}
@@ -3,7 +3,13 @@ package commands
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/FujishigeTemma/git-review/internal/db"
 	"github.com/FujishigeTemma/git-review/internal"
@@ -13,7 +19,18 @@ import (
 	"github.com/newmo-oss/ergo"
 )
 
-type FinishCmd struct{}
+type FinishCmd struct {
+	SquashNote  string `name:"squash-note" placeholder:"REF" help:"Also write all comments as one note on REF (e.g. the squash-merge commit), resolved after the branch is restored."`
+	SummaryNote bool   `name:"summary-note" help:"Also write a summary of reviewers and thread counts as a note on the branch tip."`
+	SummaryRef  string `name:"summary-ref" placeholder:"REF" help:"Write the --summary-note on REF instead of the branch tip."`
+	AutoResolve string `name:"auto-resolve" placeholder:"SEVERITY" help:"Resolve open threads at or below SEVERITY (nit, minor, major, blocker) before writing notes."`
+	Strict      bool   `help:"Refuse to finish while any thread is still open (after --auto-resolve), or while the branch has commits added or dropped since start."`
+	BlockOn     string `name:"block-on" placeholder:"REGEX" help:"Refuse to finish while a comment in an open thread matches REGEX (e.g. BLOCKER), listing those threads (after --auto-resolve)."`
+	Replace     bool   `help:"Overwrite the notes already on the commits instead of appending to them, e.g. when finishing a review again."`
+	Template    string `placeholder:"PATH" help:"Render a review summary with this Go text/template (fields: .Branch, .CommitCount, .CommentCount, .Unresolved, .Commits, .Comments, ...); it is printed, and used as the --summary-note."`
+	Output      string `placeholder:"FILE" help:"Write the --template summary to FILE instead of printing it."`
+	Context     int    `placeholder:"N" help:"Quote N lines of source around each line comment in the notes, from the commented commit; 0 quotes none."`
+}
 
 func (c *FinishCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
 	if err := requireMainWorktree(g); err != nil {
@@ -22,10 +39,195 @@ func (c *FinishCmd) Run(g *git.Git, repo *repository.Repository, out *output.Out
 	if err := requireActive(repo); err != nil {
 		return err
 	}
-	return finishReview(g, repo, out)
+	if c.SummaryRef != "" && !c.SummaryNote {
+		return ergo.New("--summary-ref requires --summary-note")
+	}
+	if err := validateSeverity("--auto-resolve", c.AutoResolve); err != nil {
+		return err
+	}
+	for _, ref := range []string{c.SquashNote, c.SummaryRef} {
+		if ref != "" && !g.RefExists(ref) {
+			return ergo.WithCode(
+				ergo.New("invalid ref", slog.String("ref", ref)),
+				internal.ErrCodeInvalidRef)
+		}
+	}
+	if c.Output != "" && c.Template == "" {
+		return ergo.New("--output requires --template")
+	}
+	if c.Context < 0 {
+		return ergo.New("--context must not be negative")
+	}
+	var blockOn *regexp.Regexp
+	if c.BlockOn != "" {
+		re, err := regexp.Compile(c.BlockOn)
+		if err != nil {
+			return ergo.Wrap(err, "invalid --block-on pattern", slog.String("pattern", c.BlockOn))
+		}
+		blockOn = re
+	}
+	// Parse the template up front so a mistake in it leaves the review running
+	var tmpl *template.Template
+	if c.Template != "" {
+		t, err := loadSummaryTemplate(c.Template)
+		if err != nil {
+			return err
+		}
+		tmpl = t
+	}
+	if err := checkBranchHistory(g, repo, out, c.Strict); err != nil {
+		return err
+	}
+	if err := c.applyResolvePolicy(repo, out, blockOn); err != nil {
+		return err
+	}
+	return c.finishReview(g, repo, out, tmpl)
 }
 
-func finishReview(g *git.Git, repo *repository.Repository, out *output.Output) error {
+// checkBranchHistory compares the commits on the review branch now with the reviewed
+// ones, which the notes go on. When commits were added, amended or dropped since start it
+// warns, listing them, or with strict refuses to finish. Commits that were already on the
+// branch at start but left out of the review, as with --single, are not new.
+func checkBranchHistory(g *git.Git, repo *repository.Repository, out *output.Output, strict bool) error {
+	ctx := context.Background()
+	q := repo.Queries()
+
+	session, err := q.GetSession(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to get session")
+	}
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	rangeSpec := session.BaseRef + "..refs/heads/" + session.Branch
+	all, err := g.RevList(rangeSpec)
+	if err != nil {
+		return nil // the branch is gone or renamed, so there is nothing to compare with
+	}
+	// Merges are only reviewed with start --include-merges, so a new one is not missing
+	options := []string{"--no-merges"}
+	if session.BranchSha.Valid {
+		options = append(options, "^"+session.BranchSha.String)
+	}
+	plain, _ := g.RevList(rangeSpec, options...)
+
+	reviewed := make(map[string]bool, len(commits))
+	for _, cm := range commits {
+		reviewed[cm.Sha] = true
+	}
+	var changes []string
+	for _, sha := range plain {
+		if !reviewed[sha] {
+			oneline, _ := g.Oneline(sha)
+			changes = append(changes, "    + "+oneline)
+		}
+	}
+	for _, cm := range commits {
+		if !slices.Contains(all, cm.Sha) {
+			changes = append(changes, fmt.Sprintf("    - %s %s", internal.ShortSHA(cm.Sha), cm.Message))
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s changed since the review started (+ new, - no longer on it); notes go on the reviewed commits:\n%s",
+		session.Branch, strings.Join(changes, "\n"))
+	if strict {
+		return ergo.WithCode(
+			ergo.New(msg+"\n  Start a new review, or finish without --strict.", slog.String("branch", session.Branch)),
+			internal.ErrCodeStaleCommit)
+	}
+	out.Warn(msg)
+	return nil
+}
+
+// applyResolvePolicy resolves the open threads --auto-resolve covers, as "finish-policy".
+// With --strict it first checks that no other thread would stay open, and with --block-on
+// that none of them matches blockOn, so a refused finish leaves the review untouched.
+func (c *FinishCmd) applyResolvePolicy(repo *repository.Repository, out *output.Output, blockOn *regexp.Regexp) error {
+	if c.AutoResolve == "" && !c.Strict && blockOn == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	open, err := repo.Queries().ListUnresolvedRoots(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list unresolved threads")
+	}
+
+	var covered, remaining []db.Comment
+	for _, root := range open {
+		if c.AutoResolve != "" && atOrBelow(root.Severity.String, c.AutoResolve) {
+			covered = append(covered, root)
+		} else {
+			remaining = append(remaining, root)
+		}
+	}
+	if blockOn != nil {
+		if err := checkBlockers(ctx, repo.Queries(), remaining, blockOn); err != nil {
+			return err
+		}
+	}
+	if n := len(remaining); c.Strict && n > 0 {
+		return ergo.WithCode(
+			ergo.New(fmt.Sprintf("%d unresolved %s would remain. Resolve %s or finish without --strict.",
+				n, internal.Pluralize(n, "thread", "threads"), internal.Pluralize(n, "it", "them"))),
+			internal.ErrCodeUnresolved)
+	}
+	if len(covered) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	if err := repo.WithTx(ctx, func(q *db.Queries) error {
+		for _, root := range covered {
+			if err := resolveThread(ctx, q, root, "finish-policy", now); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	out.Info(fmt.Sprintf("Auto-resolved %d %s at or below %s.", len(covered), internal.Pluralize(len(covered), "thread", "threads"), c.AutoResolve))
+	return nil
+}
+
+// checkBlockers fails when a comment in any of the open threads matches re, listing each
+// such thread by its root, with the first matching comment.
+func checkBlockers(ctx context.Context, q *db.Queries, open []db.Comment, re *regexp.Regexp) error {
+	comments, err := q.ListAllComments(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to load comments")
+	}
+	childrenMap := buildChildrenMap(comments)
+
+	var blocked []string
+	for _, root := range open {
+		for _, cm := range append([]db.Comment{root}, descendants(childrenMap, root.ID)...) {
+			if re.MatchString(cm.Body) {
+				blocked = append(blocked, fmt.Sprintf("  [%s] %s%s",
+					internal.ShortID(root.ID), fileLocation(root, nil), truncateBody(cm.Body, threadBodyWidth)))
+				break
+			}
+		}
+	}
+	if n := len(blocked); n > 0 {
+		return ergo.WithCode(
+			ergo.New(fmt.Sprintf("%d open %s %s --block-on %q:\n%s\n  Resolve %s or finish without --block-on.",
+				n, internal.Pluralize(n, "thread", "threads"), internal.Pluralize(n, "matches", "match"), re,
+				strings.Join(blocked, "\n"), internal.Pluralize(n, "it", "them"))),
+			internal.ErrCodeUnresolved)
+	}
+	return nil
+}
+
+// finishReview writes notes for every reviewed commit and cleans up. With --squash-note,
+// a consolidated note covering all commits is also written after the branch is restored,
+// so that the review survives a squash merge; --summary-note likewise adds a summary note.
+func (c *FinishCmd) finishReview(g *git.Git, repo *repository.Repository, out *output.Output, tmpl *template.Template) error {
 	ctx := context.Background()
 	q := repo.Queries()
 
@@ -44,38 +246,232 @@ func finishReview(g *git.Git, repo *repository.Repository, out *output.Output) e
 		out.Warn(fmt.Sprintf("failed to load comments: %v", err))
 	}
 
+	var summary string
+	if c.SummaryNote {
+		reviewers, err := q.ListReviewers(ctx)
+		if err != nil {
+			out.Warn(fmt.Sprintf("failed to list reviewers: %v", err))
+		}
+		// The reviewers' last commits are still open; finishing ends them now
+		spent, err := loadTimeSpent(ctx, q, reviewers, time.Now().UTC())
+		if err != nil {
+			out.Warn(fmt.Sprintf("failed to load time spent: %v", err))
+		}
+		summary = buildSummaryNote(session, commits, reviewers, comments, spent)
+	}
+
 	total := len(commits)
 	nComments := len(comments)
 
 	// Write comments to git notes on original commits
-	childrenMap := buildChildrenMap(comments)
-	for _, cm := range commits {
-		if note := buildCommitNotes(comments, childrenMap, cm.Sha); note != "" {
-			if err := g.NotesAppend(cm.Sha, note); err != nil {
-				out.Warn(fmt.Sprintf("failed to write notes for %s: %v", internal.ShortSHA(cm.Sha), err))
-			}
+	notes := newNoteWriter(g, c.Replace)
+	files, err := loadCommentFiles(ctx, q, comments)
+	if err != nil {
+		out.Warn(fmt.Sprintf("failed to load comment files: %v", err))
+	}
+
+	var rendered string
+	if tmpl != nil {
+		// Rendered before any note is written, so a template that fails at run time
+		// (e.g. on a missing field) leaves the review running
+		if rendered, err = renderSummary(tmpl, session, commits, comments, files); err != nil {
+			return err
+		}
+		if c.SummaryNote {
+			summary = rendered
 		}
 	}
+	squashSections := writeCommitNotes(notes, out, commits, comments, files, newCodeContext(g, c.Context))
 
-	cleanupReview(g, repo, out, session)
+	// Review summaries belong to no commit: they lead the squash note, and go on the
+	// branch tip as part of the --summary-note or, without it, as a note of their own
+	verdict := buildCommitNotes(comments, buildChildrenMap(comments), nil, "", codeContext{})
+	if verdict != "" {
+		squashSections = append([]string{"Review summary\n" + verdict}, squashSections...)
+	}
+
+	backOn := cleanupReview(g, repo, out, session)
+
+	// Resolve targets only now: during the review HEAD points at a detached parent
+	var squashNoted, summaryNoted string
+	if c.SquashNote != "" && len(squashSections) > 0 {
+		squashNoted = appendNoteOn(notes, out, c.SquashNote, strings.Join(squashSections, "\n\n"), "squash note")
+	}
+	if c.SummaryNote {
+		target := c.SummaryRef
+		if target == "" {
+			target = "refs/heads/" + session.Branch
+		}
+		summaryNoted = appendNoteOn(notes, out, target, summary, "summary note")
+	} else if verdict != "" {
+		summaryNoted = appendNoteOn(notes, out, "refs/heads/"+session.Branch, "Review summary:\n"+verdict, "summary note")
+	}
 
-	out.Printf("\n")
+	out.Notef("\n")
 	out.Ok("══ Review Complete ══")
-	out.Printf("\n")
+	out.Notef("\n")
 	out.Info(fmt.Sprintf("  Comments : %d across %d commits", nComments, total))
-	out.Info(fmt.Sprintf("  Back on  : %s", session.Branch))
-	out.Printf("\n")
-	out.Printf("  Comments written to git notes on original commits.\n")
+	if backOn != "" {
+		out.Info(fmt.Sprintf("  Back on  : %s", backOn))
+	}
+	out.Notef("\n")
+	if c.Replace {
+		out.Notef("  Comments written to git notes on original commits, replacing their earlier notes.\n")
+	} else {
+		out.Notef("  Comments written to git notes on original commits.\n")
+	}
+	if squashNoted != "" {
+		out.Notef("  All comments also written as one note on %s.\n", internal.ShortSHA(squashNoted))
+	}
+	if summaryNoted != "" {
+		out.Notef("  Review summary written as a note on %s.\n", internal.ShortSHA(summaryNoted))
+	}
+
+	if tmpl != nil {
+		if c.Output == "" {
+			out.Notef("\n")
+			out.Printf("%s", rendered)
+		} else if err := os.WriteFile(c.Output, []byte(rendered), 0o644); err != nil {
+			out.Warn(fmt.Sprintf("failed to write the summary to %s: %v", c.Output, err))
+		} else {
+			out.Notef("  Summary written to %s.\n", c.Output)
+		}
+	}
 
 	return nil
 }
 
-// buildCommitNotes builds a git notes string for all comments on a given commit SHA.
-func buildCommitNotes(allComments []db.Comment, childrenMap map[string][]db.Comment, commitSHA string) string {
+// noteWriter writes the git notes of one finish or abort. With replace, the first note
+// written to a commit overwrites the note it had; later ones in the same run append, so
+// e.g. a squash note on the last reviewed commit keeps that commit's own note.
+type noteWriter struct {
+	g       *git.Git
+	replace bool
+	written map[string]bool
+}
+
+func newNoteWriter(g *git.Git, replace bool) *noteWriter {
+	return &noteWriter{g: g, replace: replace, written: map[string]bool{}}
+}
+
+func (w *noteWriter) write(sha, note string) error {
+	first := !w.written[sha]
+	w.written[sha] = true
+	if w.replace && first {
+		return w.g.NotesReplace(sha, note)
+	}
+	return w.g.NotesAppend(sha, note)
+}
+
+// appendNoteOn adds note to the commit ref points at and returns its SHA,
+// or warns and returns "" on failure. what names the note in warnings.
+func appendNoteOn(w *noteWriter, out *output.Output, ref, note, what string) string {
+	target, err := w.g.Run("rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		out.Warn(fmt.Sprintf("failed to resolve %s for the %s: %v", ref, what, err))
+		return ""
+	}
+	if err := w.write(target, note); err != nil {
+		out.Warn(fmt.Sprintf("failed to write %s on %s: %v", what, internal.ShortSHA(target), err))
+		return ""
+	}
+	return target
+}
+
+// buildSummaryNote describes the review as a whole: thread counts, each reviewer's
+// activity and time spent, and which commits carry per-commit notes.
+func buildSummaryNote(session db.Session, commits []db.Commit, reviewers []db.Reviewer, comments []db.Comment, spent timeSpent) string {
+	s := computeStats(commits, reviewers, comments)
+
+	type threadCounts struct{ open, resolved int }
+	perReviewer := map[string]threadCounts{}
+	hasNotes := map[string]bool{}
+	for _, c := range comments {
+		if c.ParentID.Valid {
+			continue
+		}
+		hasNotes[c.Commit.String] = true
+		tc := perReviewer[c.CreatedBy]
+		if c.ResolvedAt.Valid {
+			tc.resolved++
+		} else {
+			tc.open++
+		}
+		perReviewer[c.CreatedBy] = tc
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Review summary: %s (%d %s)\n\n", session.Branch, len(commits), internal.Pluralize(len(commits), "commit", "commits"))
+	if verdict := buildCommitNotes(comments, buildChildrenMap(comments), nil, "", codeContext{}); verdict != "" {
+		b.WriteString(verdict + "\n\n")
+	}
+	fmt.Fprintf(&b, "Threads: %d (%d open, %d resolved)\n", s.Threads, s.OpenThreads, s.ResolvedThreads)
+	fmt.Fprintf(&b, "Comments: %d\n", s.Comments)
+	if spent.total >= 60 {
+		fmt.Fprintf(&b, "Time spent: %s\n", formatDuration(spent.total))
+	}
+
+	var reviewerLines []string
+	for _, r := range s.ByReviewer {
+		name := r.Name
+		if name == "" {
+			if r.Count == 0 {
+				continue // the main worktree's reviewer row, unused
+			}
+			name = "(default)"
+		}
+		tc := perReviewer[r.Name]
+		line := fmt.Sprintf("  %s: %d %s, %d open, %d resolved", name,
+			r.Count, internal.Pluralize(r.Count, "comment", "comments"), tc.open, tc.resolved)
+		if secs := spent.byReviewer[r.Name]; secs >= 60 {
+			line += ", " + formatDuration(secs)
+		}
+		reviewerLines = append(reviewerLines, line)
+	}
+	if len(reviewerLines) > 0 {
+		b.WriteString("\nReviewers:\n")
+		b.WriteString(strings.Join(reviewerLines, "\n"))
+		b.WriteString("\n")
+	}
+
+	var noted []string
+	for _, cm := range commits {
+		if hasNotes[cm.Sha] {
+			noted = append(noted, fmt.Sprintf("  %s %s", internal.ShortSHA(cm.Sha), cm.Message))
+		}
+	}
+	if len(noted) > 0 {
+		b.WriteString("\nPer-commit notes (git notes show <sha>):\n")
+		b.WriteString(strings.Join(noted, "\n"))
+		b.WriteString("\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// writeCommitNotes adds each commit's comments to its git notes. It returns one
+// "<sha> <subject>" headed section per commit with comments, for a consolidated note.
+func writeCommitNotes(w *noteWriter, out *output.Output, commits []db.Commit, comments []db.Comment, files commentFiles, code codeContext) []string {
+	childrenMap := buildChildrenMap(comments)
+	var sections []string
+	for _, cm := range commits {
+		if note := buildCommitNotes(comments, childrenMap, files, cm.Sha, code); note != "" {
+			if err := w.write(cm.Sha, note); err != nil {
+				out.Warn(fmt.Sprintf("failed to write notes for %s: %v", internal.ShortSHA(cm.Sha), err))
+			}
+			sections = append(sections, fmt.Sprintf("%s %s\n%s", internal.ShortSHA(cm.Sha), cm.Message, note))
+		}
+	}
+	return sections
+}
+
+// buildCommitNotes builds a git notes string for all comments on a given commit SHA,
+// or for the review summaries when commitSHA is empty.
+func buildCommitNotes(allComments []db.Comment, childrenMap map[string][]db.Comment, files commentFiles, commitSHA string, code codeContext) string {
 	// Collect top-level comments for this commit
 	var topLevel []db.Comment
 	for _, c := range allComments {
-		if c.Commit == commitSHA && !c.ParentID.Valid {
+		if c.Commit.String == commitSHA && !c.ParentID.Valid {
 			topLevel = append(topLevel, c)
 		}
 	}
@@ -83,23 +479,62 @@ func buildCommitNotes(allComments []db.Comment, childrenMap map[string][]db.Comm
 	var notes []string
 	for _, c := range topLevel {
 		authorTag := authorSuffix(c.CreatedBy)
+		commitTag := crossCommitTag(c, commitSHA)
 		if c.File.Valid {
-			loc := c.File.String
+			loc := files.label(c)
 			if lr := internal.FormatLineRange(c.StartLine, c.EndLine); lr != "" {
 				loc += ":" + lr
 			}
-			notes = append(notes, fmt.Sprintf("%s -- %s%s", loc, c.Body, authorTag))
+			notes = append(notes, fmt.Sprintf("%s%s -- %s%s", commitTag, loc, c.Body, authorTag))
+			if !c.OnBase { // the commit does not show the code a base comment is about
+				notes = append(notes, code.quote(commitSHA, c)...)
+			}
 		} else {
-			notes = append(notes, fmt.Sprintf("%s%s", c.Body, authorTag))
+			notes = append(notes, fmt.Sprintf("%s%s%s", commitTag, c.Body, authorTag))
 		}
 		for _, r := range descendants(childrenMap, c.ID) {
 			rAuthorTag := authorSuffix(r.CreatedBy)
-			commitTag := ""
-			if r.Commit != commitSHA {
-				commitTag = "(" + internal.ShortSHA(r.Commit) + ") "
-			}
-			notes = append(notes, fmt.Sprintf("  %s%s%s", commitTag, r.Body, rAuthorTag))
+			notes = append(notes, fmt.Sprintf("  %s%s%s", crossCommitTag(r, commitSHA), r.Body, rAuthorTag))
 		}
 	}
 	return strings.Join(notes, "\n")
 }
+
+// codeContext quotes the source around line comments in notes, so they can be read
+// without checking the commit out. The zero value quotes nothing.
+type codeContext struct {
+	lines  int
+	source func(sha, file string) []string // nil when the file cannot be quoted
+}
+
+// newCodeContext reads files from git, which caches them per commit and file.
+func newCodeContext(g *git.Git, lines int) codeContext {
+	return codeContext{lines: lines, source: func(sha, file string) []string {
+		src, err := g.FileLines(sha, file)
+		if err != nil || slices.ContainsFunc(src, func(l string) bool { return strings.ContainsRune(l, 0) }) {
+			return nil // deleted at that commit, or binary
+		}
+		return src
+	}}
+}
+
+// quote returns the commented lines of c's file with code.lines lines around them, each
+// indented under the comment and numbered, the commented ones marked with '>'.
+func (code codeContext) quote(sha string, c db.Comment) []string {
+	if code.lines == 0 || !c.StartLine.Valid {
+		return nil
+	}
+	src := code.source(sha, c.File.String)
+	start, end := c.StartLine.Int64, c.EndLine.ValueOr(c.StartLine.Int64)
+	from, to := max(start-int64(code.lines), 1), min(end+int64(code.lines), int64(len(src)))
+	width := len(fmt.Sprint(to))
+	var quoted []string
+	for n := from; n <= to; n++ {
+		mark := " "
+		if n >= start && n <= end {
+			mark = ">"
+		}
+		quoted = append(quoted, strings.TrimRight(fmt.Sprintf("    %s %*d | %s", mark, width, n, src[n-1]), " "))
+	}
+	return quoted
+}
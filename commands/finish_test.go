@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/FujishigeTemma/git-review/internal/db"
@@ -12,7 +13,7 @@ func newComment(id uuid.UUID, parentID uuid.NullUUID, commit, body, createdBy st
 	return db.Comment{
 		ID:        id,
 		ParentID:  parentID,
-		Commit:    commit,
+		Commit:    null.NewString(commit, commit != ""), // "" for a review summary
 		Body:      body,
 		CreatedBy: createdBy,
 		File:      file,
@@ -23,7 +24,7 @@ func newComment(id uuid.UUID, parentID uuid.NullUUID, commit, body, createdBy st
 
 func TestBuildCommitNotes_NoComments(t *testing.T) {
 	childrenMap := buildChildrenMap(nil)
-	got := buildCommitNotes(nil, childrenMap, "abc123")
+	got := buildCommitNotes(nil, childrenMap, nil, "abc123", codeContext{})
 	if got != "" {
 		t.Errorf("expected empty, got %q", got)
 	}
@@ -35,7 +36,7 @@ func TestBuildCommitNotes_GeneralComment(t *testing.T) {
 		newComment(id, uuid.NullUUID{}, "abc123", "Good work", "alice", null.String{}, null.Int{}, null.Int{}),
 	}
 	childrenMap := buildChildrenMap(comments)
-	got := buildCommitNotes(comments, childrenMap, "abc123")
+	got := buildCommitNotes(comments, childrenMap, nil, "abc123", codeContext{})
 	if got != "Good work @alice" {
 		t.Errorf("got %q, want %q", got, "Good work @alice")
 	}
@@ -48,7 +49,7 @@ func TestBuildCommitNotes_FileComment(t *testing.T) {
 			null.StringFrom("main.go"), null.IntFrom(10), null.IntFrom(10)),
 	}
 	childrenMap := buildChildrenMap(comments)
-	got := buildCommitNotes(comments, childrenMap, "abc123")
+	got := buildCommitNotes(comments, childrenMap, nil, "abc123", codeContext{})
 	want := "main.go:10 -- Fix this @bob"
 	if got != want {
 		t.Errorf("got %q, want %q", got, want)
@@ -62,13 +63,27 @@ func TestBuildCommitNotes_FileCommentWithRange(t *testing.T) {
 			null.StringFrom("main.go"), null.IntFrom(5), null.IntFrom(12)),
 	}
 	childrenMap := buildChildrenMap(comments)
-	got := buildCommitNotes(comments, childrenMap, "abc123")
+	got := buildCommitNotes(comments, childrenMap, nil, "abc123", codeContext{})
 	want := "main.go:5-12 -- Split this @bob"
 	if got != want {
 		t.Errorf("got %q, want %q", got, want)
 	}
 }
 
+func TestBuildCommitNotes_FileCommentOpenEnded(t *testing.T) {
+	id := uuid.Must(uuid.NewV7())
+	comments := []db.Comment{
+		newComment(id, uuid.NullUUID{}, "abc123", "From here down", "bob",
+			null.StringFrom("main.go"), null.IntFrom(10), null.Int{}),
+	}
+	childrenMap := buildChildrenMap(comments)
+	got := buildCommitNotes(comments, childrenMap, nil, "abc123", codeContext{})
+	want := "main.go:10 -- From here down @bob"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
 func TestBuildCommitNotes_WithReplies(t *testing.T) {
 	parentID := uuid.Must(uuid.NewV7())
 	childID := uuid.Must(uuid.NewV7())
@@ -77,7 +92,7 @@ func TestBuildCommitNotes_WithReplies(t *testing.T) {
 		newComment(childID, uuid.NullUUID{UUID: parentID, Valid: true}, "abc123", "Fixed!", "bob", null.String{}, null.Int{}, null.Int{}),
 	}
 	childrenMap := buildChildrenMap(comments)
-	got := buildCommitNotes(comments, childrenMap, "abc123")
+	got := buildCommitNotes(comments, childrenMap, nil, "abc123", codeContext{})
 	want := "Issue here @alice\n  Fixed! @bob"
 	if got != want {
 		t.Errorf("got %q, want %q", got, want)
@@ -92,20 +107,32 @@ func TestBuildCommitNotes_CrossCommitReply(t *testing.T) {
 		newComment(childID, uuid.NullUUID{UUID: parentID, Valid: true}, "def456", "Reply from other commit", "bob", null.String{}, null.Int{}, null.Int{}),
 	}
 	childrenMap := buildChildrenMap(comments)
-	got := buildCommitNotes(comments, childrenMap, "abc123")
+	got := buildCommitNotes(comments, childrenMap, nil, "abc123", codeContext{})
 	want := "Issue @alice\n  (def456) Reply from other commit @bob"
 	if got != want {
 		t.Errorf("got %q, want %q", got, want)
 	}
 }
 
+func TestBuildCommitNotes_SpansTwoCommits(t *testing.T) {
+	id := uuid.Must(uuid.NewV7())
+	c := newComment(id, uuid.NullUUID{}, "def456", "Renamed since", "alice", null.StringFrom("app.js"), null.IntFrom(2), null.IntFrom(2))
+	c.AlsoCommit = null.StringFrom("abc123")
+	comments := []db.Comment{c}
+	got := buildCommitNotes(comments, buildChildrenMap(comments), nil, "def456", codeContext{})
+	want := "(def456↔abc123) app.js:2 -- Renamed since @alice"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
 func TestBuildCommitNotes_EmptyAuthor(t *testing.T) {
 	id := uuid.Must(uuid.NewV7())
 	comments := []db.Comment{
 		newComment(id, uuid.NullUUID{}, "abc123", "Anonymous comment", "", null.String{}, null.Int{}, null.Int{}),
 	}
 	childrenMap := buildChildrenMap(comments)
-	got := buildCommitNotes(comments, childrenMap, "abc123")
+	got := buildCommitNotes(comments, childrenMap, nil, "abc123", codeContext{})
 	if got != "Anonymous comment" {
 		t.Errorf("got %q, want %q", got, "Anonymous comment")
 	}
@@ -117,8 +144,53 @@ func TestBuildCommitNotes_SkipsOtherCommits(t *testing.T) {
 		newComment(id, uuid.NullUUID{}, "other", "Not this one", "alice", null.String{}, null.Int{}, null.Int{}),
 	}
 	childrenMap := buildChildrenMap(comments)
-	got := buildCommitNotes(comments, childrenMap, "abc123")
+	got := buildCommitNotes(comments, childrenMap, nil, "abc123", codeContext{})
 	if got != "" {
 		t.Errorf("expected empty for other commit, got %q", got)
 	}
 }
+
+func TestBuildCommitNotes_QuotesSourceContext(t *testing.T) {
+	comments := []db.Comment{
+		newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "abc123", "Off by one", "alice", null.StringFrom("app.js"), null.IntFrom(2), null.Int{}),
+		newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "abc123", "Whole file", "alice", null.StringFrom("app.js"), null.Int{}, null.Int{}),
+	}
+	code := codeContext{lines: 1, source: func(sha, file string) []string {
+		return []string{"function f() {", "  return i + 1;", "}", "f();"}
+	}}
+	got := buildCommitNotes(comments, buildChildrenMap(comments), nil, "abc123", code)
+	want := "app.js:2 -- Off by one @alice\n" +
+		"      1 | function f() {\n" +
+		"    > 2 |   return i + 1;\n" +
+		"      3 | }\n" +
+		"app.js -- Whole file @alice"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildSummaryNote_CountsPerReviewer(t *testing.T) {
+	commits := []db.Commit{{Sha: "abc123", Message: "First", Position: 0}, {Sha: "def456", Message: "Second", Position: 1}}
+	reviewers := []db.Reviewer{{Name: ""}, {Name: "alice"}}
+	root := newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "abc123", "Fix", "alice", null.String{}, null.Int{}, null.Int{})
+	resolved := newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "abc123", "Nit", "alice", null.String{}, null.Int{}, null.Int{})
+	resolved.ResolvedAt = null.StringFrom("2026-01-01T00:00:00Z")
+	reply := newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{UUID: root.ID, Valid: true}, "abc123", "Done", "bob", null.String{}, null.Int{}, null.Int{})
+
+	got := buildSummaryNote(db.Session{Branch: "feature"}, commits, reviewers, []db.Comment{root, resolved, reply}, timeSpent{})
+	for _, want := range []string{
+		"Review summary: feature (2 commits)",
+		"Threads: 2 (1 open, 1 resolved)",
+		"Comments: 3",
+		"alice: 2 comments, 1 open, 1 resolved",
+		"bob: 1 comment, 0 open, 0 resolved",
+		"abc123 First",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "(default)") || strings.Contains(got, "def456") {
+		t.Errorf("summary should omit the unused default reviewer and commits without notes:\n%s", got)
+	}
+}
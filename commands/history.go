@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/guregu/null/v6"
+	"github.com/newmo-oss/ergo"
+)
+
+type HistoryCmd struct {
+	ID string `arg:"" help:"ID (or prefix) of the comment." completion:"ids"`
+}
+
+// Run prints when a comment was made and every change to it since, oldest first.
+func (c *HistoryCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	q := repo.Queries()
+
+	comment, err := findComment(ctx, q, c.ID)
+	if err != nil {
+		return err
+	}
+	changes, err := q.ListCommentHistory(ctx, comment.ID)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list comment history")
+	}
+
+	files, err := loadCommentFiles(ctx, q, []db.Comment{comment})
+	if err != nil {
+		return err
+	}
+
+	out.Printf("[%s] %s%s\n", internal.ShortID(comment.ID), fileLocation(comment, files), truncateBody(comment.Body, threadBodyWidth))
+	out.Printf("%s  %-10s %s\n", comment.CreatedAt, "created", comment.CreatedBy)
+	for _, ch := range changes {
+		out.Printf("%s  %-10s %s\n", ch.ChangedAt, ch.Action, ch.ChangedBy)
+	}
+	return nil
+}
+
+// recordHistory adds a change to a comment's history, keeping its body as it was before.
+func recordHistory(ctx context.Context, q *db.Queries, c db.Comment, action, by, at string) error {
+	if err := q.InsertCommentHistory(ctx, db.InsertCommentHistoryParams{
+		CommentID: c.ID,
+		Action:    action,
+		Body:      c.Body,
+		ChangedBy: by,
+		ChangedAt: at,
+	}); err != nil {
+		return ergo.Wrap(err, "failed to record comment history", slog.String("comment_id", c.ID.String()))
+	}
+	return nil
+}
+
+// resolveThread resolves a root comment and records it in the comment's history.
+func resolveThread(ctx context.Context, q *db.Queries, c db.Comment, by, at string) error {
+	if err := q.ResolveComment(ctx, db.ResolveCommentParams{
+		ResolvedAt: null.StringFrom(at),
+		ResolvedBy: null.StringFrom(by),
+		ID:         c.ID,
+	}); err != nil {
+		return ergo.Wrap(err, "failed to resolve comment", slog.String("comment_id", c.ID.String()))
+	}
+	return recordHistory(ctx, q, c, "resolved", by, at)
+}
+
+// unresolveThread reopens a root comment and records it in the comment's history.
+func unresolveThread(ctx context.Context, q *db.Queries, c db.Comment, by, at string) error {
+	if err := q.UnresolveComment(ctx, c.ID); err != nil {
+		return ergo.Wrap(err, "failed to unresolve comment", slog.String("comment_id", c.ID.String()))
+	}
+	return recordHistory(ctx, q, c, "unresolved", by, at)
+}
@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/guregu/null/v6"
+	"github.com/newmo-oss/ergo"
+)
+
+type ImportCmd struct {
+	Path      string `arg:"" help:"JSON written by 'git review state' (- for stdin)."`
+	Force     bool   `help:"Replace the review in progress, discarding its comments."`
+	Autostash bool   `help:"Stash uncommitted changes before importing and restore them on finish or abort."`
+}
+
+// Run restores a review exported with 'git review state': the session, its commits and
+// every comment with its ID, thread and timestamps. The reviewer is then put back on
+// the commit recorded in the export.
+func (c *ImportCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireMainWorktree(g); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	st, err := c.readState()
+	if err != nil {
+		return err
+	}
+	if err := validateImport(g, st); err != nil {
+		return err
+	}
+
+	count, err := repo.Queries().SessionExists(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to check session")
+	}
+	if count > 0 && !c.Force {
+		return ergo.WithCode(
+			ergo.New("Review already in progress. Finish or abort it, or pass --force to replace it."),
+			internal.ErrCodeReviewActive)
+	}
+
+	// Putting the reviewer on a commit checks it out, like start; a review being replaced
+	// already owns the tree, and its stash is carried over to be restored at the end
+	var stashSHA null.String
+	if count > 0 {
+		session, err := repo.Queries().GetSession(ctx)
+		if err != nil {
+			return ergo.Wrap(err, "failed to get session")
+		}
+		stashSHA = session.StashSha
+	} else if stashSHA, err = protectLocalChanges(g, out, c.Autostash); err != nil {
+		return err
+	}
+
+	var branchSHA null.String
+	headSHA, err := g.Run("rev-parse", st.Branch)
+	if err != nil {
+		headSHA = st.Commits[len(st.Commits)-1] // the branch is not here; fall back to its last reviewed commit
+	} else {
+		branchSHA = null.StringFrom(headSHA)
+	}
+
+	if err := repo.WithTx(ctx, func(q *db.Queries) error {
+		if count > 0 {
+			if err := clearReview(ctx, q); err != nil {
+				return err
+			}
+		}
+		if err := q.InsertSession(ctx, db.InsertSessionParams{
+			BaseRef:   st.BaseRef,
+			Branch:    st.Branch,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+			HeadSha:   headSHA,
+			StashSha:  stashSHA,
+			BranchSha: branchSHA,
+		}); err != nil {
+			return ergo.Wrap(err, "failed to insert session")
+		}
+		for i, sha := range st.Commits {
+			msg, _ := g.Subject(sha)
+			author, err := g.Author(sha)
+			if err := q.InsertCommit(ctx, db.InsertCommitParams{Sha: sha, Message: msg, Position: int64(i), Author: null.NewString(author, err == nil)}); err != nil {
+				return ergo.Wrap(err, "failed to insert commit", slog.String("sha", sha))
+			}
+		}
+		if err := q.InsertReviewer(ctx, db.InsertReviewerParams{Name: g.Reviewer}); err != nil {
+			return ergo.Wrap(err, "failed to insert reviewer", slog.String("name", g.Reviewer))
+		}
+		return importComments(ctx, q, st.Comments)
+	}); err != nil {
+		if count == 0 {
+			restoreAutostash(g, out, stashSHA)
+		}
+		return ergo.Wrap(err, "failed to import review")
+	}
+
+	// Restore the exported position, or start at the first commit like start does
+	pos := st.Current.ValueOr(0)
+	if pos < 0 || pos >= int64(len(st.Commits)) {
+		pos = 0
+	}
+	target, err := repo.Queries().GetCommitByPosition(ctx, pos)
+	if err != nil {
+		return ergo.Wrap(err, "failed to get commit")
+	}
+	if err := jumpTo(g, repo, g.Reviewer, target); err != nil {
+		return err
+	}
+
+	out.Ok(fmt.Sprintf("Imported review of %s: %d %s, %d %s",
+		st.Branch, len(st.Commits), internal.Pluralize(len(st.Commits), "commit", "commits"),
+		len(st.Comments), internal.Pluralize(len(st.Comments), "comment", "comments")))
+	out.Notef("  On commit %d/%d: %s\n", pos+1, len(st.Commits), target.Message)
+	return nil
+}
+
+func (c *ImportCmd) readState() (stateOutput, error) {
+	var r io.Reader = os.Stdin
+	if c.Path != "-" {
+		f, err := os.Open(c.Path)
+		if err != nil {
+			return stateOutput{}, ergo.Wrap(err, "failed to open export", slog.String("path", c.Path))
+		}
+		defer f.Close()
+		r = f
+	}
+	var st *stateOutput
+	if err := json.NewDecoder(r).Decode(&st); err != nil {
+		return stateOutput{}, ergo.Wrap(err, "failed to parse export", slog.String("path", c.Path))
+	}
+	if st == nil || len(st.Commits) == 0 {
+		return stateOutput{}, ergo.New("the export holds no review", slog.String("path", c.Path))
+	}
+	return *st, nil
+}
+
+// validateImport checks that this repository has every commit the export refers to,
+// and that comments only refer to the exported commits, before anything is written.
+func validateImport(g *git.Git, st stateOutput) error {
+	var missing []string
+	for _, sha := range append([]string{st.BaseRef}, st.Commits...) {
+		if !g.CommitExists(sha) {
+			missing = append(missing, internal.ShortSHA(sha))
+		}
+	}
+	if len(missing) > 0 {
+		return ergo.WithCode(
+			ergo.New(fmt.Sprintf("commits of the exported review are missing from this repository: %s\n  Fetch the branch %s first.",
+				strings.Join(missing, ", "), st.Branch)),
+			internal.ErrCodeInvalidRef)
+	}
+
+	known := make(map[string]bool, len(st.Commits))
+	for _, sha := range st.Commits {
+		known[sha] = true
+	}
+	for _, sc := range st.Comments {
+		for _, sha := range []null.String{sc.Commit, sc.AlsoCommit} {
+			if sha.Valid && !known[sha.String] {
+				return ergo.New(fmt.Sprintf("a comment refers to commit %s, which is not part of the exported review",
+					internal.ShortSHA(sha.String)), slog.String("comment_id", sc.ID))
+			}
+		}
+	}
+	return nil
+}
+
+// importComments inserts comments parents first, so every reply's parent exists.
+func importComments(ctx context.Context, q *db.Queries, comments []stateComment) error {
+	type pendingComment struct {
+		params    db.InsertCommentParams
+		moreFiles []string
+	}
+	pending := make([]pendingComment, 0, len(comments))
+	for _, sc := range comments {
+		params, err := fromStateComment(sc)
+		if err != nil {
+			return err
+		}
+		var more []string
+		if len(sc.Files) > 1 {
+			more = sc.Files[1:]
+		}
+		pending = append(pending, pendingComment{params, more})
+	}
+
+	inserted := map[string]bool{}
+	for len(pending) > 0 {
+		var next []pendingComment
+		for _, pc := range pending {
+			params := pc.params
+			if params.ParentID.Valid && !inserted[params.ParentID.UUID.String()] {
+				next = append(next, pc)
+				continue
+			}
+			if err := q.InsertComment(ctx, params); err != nil {
+				return ergo.Wrap(err, "failed to insert comment", slog.String("comment_id", params.ID.String()))
+			}
+			if err := insertMoreFiles(ctx, q, params.ID, pc.moreFiles); err != nil {
+				return err
+			}
+			inserted[params.ID.String()] = true
+		}
+		if len(next) == len(pending) {
+			return ergo.New(fmt.Sprintf("%d %s reply to comments missing from the export",
+				len(next), internal.Pluralize(len(next), "comment", "comments")))
+		}
+		pending = next
+	}
+	return nil
+}
+
+// clearReview deletes the review in progress, dependents first.
+func clearReview(ctx context.Context, q *db.Queries) error {
+	for _, del := range []func(context.Context) error{
+		q.DeleteAllActions, q.DeleteTimeSpent, q.DeleteAllComments, q.DeleteReviewers, q.DeleteCommits, q.DeleteSession,
+	} {
+		if err := del(ctx); err != nil {
+			return ergo.Wrap(err, "failed to clear the review in progress")
+		}
+	}
+	return nil
+}
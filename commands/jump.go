@@ -2,9 +2,12 @@ package commands
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
 	"log/slog"
+	"strconv"
 
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
 	"github.com/FujishigeTemma/git-review/internal/git"
 	"github.com/FujishigeTemma/git-review/internal/output"
 	"github.com/FujishigeTemma/git-review/internal/repository"
@@ -12,7 +15,9 @@ import (
 )
 
 type JumpCmd struct {
-	Hash string `arg:"" help:"Commit hash (or prefix) to jump to."`
+	Hash           string `arg:"" optional:"" help:"Commit hash (or prefix), or 1-based position as shown by status, to jump to." completion:"commits"`
+	NextUnresolved bool   `name:"next-unresolved" help:"Jump to the next commit after the current one with an unresolved thread, wrapping around."`
+	File           string `short:"f" placeholder:"PATH" help:"Jump to the first reviewed commit that changes PATH." completion:"files"`
 }
 
 func (c *JumpCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
@@ -23,9 +28,36 @@ func (c *JumpCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outpu
 	ctx := context.Background()
 	q := repo.Queries()
 
-	target, err := q.FindCommitBySHAPrefix(ctx, sql.NullString{String: c.Hash, Valid: true})
-	if err != nil {
-		return ergo.New("commit not found", slog.String("hash", c.Hash))
+	var target db.Commit
+	switch {
+	case c.NextUnresolved && c.Hash != "":
+		return ergo.New("a commit cannot be combined with --next-unresolved", slog.String("commit", c.Hash))
+	case c.File != "" && (c.Hash != "" || c.NextUnresolved):
+		return ergo.New("--file cannot be combined with a commit or --next-unresolved", slog.String("file", c.File))
+	case c.File != "":
+		t, err := firstCommitChanging(ctx, g, q, c.File)
+		if err != nil {
+			return err
+		}
+		target = t
+	case c.NextUnresolved:
+		next, ok, err := nextUnresolvedCommit(ctx, q, g.Reviewer)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			out.Info("No unresolved threads remain.")
+			return nil
+		}
+		target = next
+	case c.Hash == "":
+		return ergo.New("specify a commit hash or position, --file, or --next-unresolved")
+	default:
+		t, err := resolveJumpTarget(ctx, q, c.Hash)
+		if err != nil {
+			return err
+		}
+		target = t
 	}
 
 	if err := jumpTo(g, repo, g.Reviewer, target); err != nil {
@@ -38,12 +70,91 @@ func (c *JumpCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outpu
 	}
 
 	oneline, _ := g.Oneline(target.Sha)
-	stat, _ := g.DiffStagedStat()
-	out.Printf("\n")
-	out.Printf("  %s [%d/%d] %s\n", out.Bold("→"), target.Position+1, int64(len(commits)), oneline)
+	stat := positionStat(g, repo, target)
+	out.Notef("\n")
+	out.Notef("  %s [%d/%d] %s\n", out.Bold("→"), target.Position+1, int64(len(commits)), oneline)
 	if stat != "" {
-		out.Printf("\n%s\n", stat)
+		out.Notef("\n%s\n", stat)
 	}
 
 	return nil
 }
+
+// resolveJumpTarget treats a plain number as a 1-based position and anything else as a
+// hash prefix. A number of four or more digits that is also a hash prefix is taken as the
+// hash, since git abbreviations are never shorter than that.
+func resolveJumpTarget(ctx context.Context, q *db.Queries, arg string) (db.Commit, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return findCommit(ctx, q, arg)
+	}
+	if len(arg) >= 4 {
+		if cm, err := findCommit(ctx, q, arg); err == nil {
+			return cm, nil
+		}
+	}
+
+	total, err := q.CountCommits(ctx)
+	if err != nil {
+		return db.Commit{}, ergo.Wrap(err, "failed to count commits")
+	}
+	if n < 1 || int64(n) > total {
+		return db.Commit{}, ergo.New(fmt.Sprintf("position %d is out of range: the review has %d %s (1-%d)",
+			n, total, internal.Pluralize(int(total), "commit", "commits"), total))
+	}
+	cm, err := q.GetCommitByPosition(ctx, int64(n-1))
+	if err != nil {
+		return db.Commit{}, ergo.Wrap(err, "failed to get commit", slog.Int("position", n))
+	}
+	return cm, nil
+}
+
+// nextUnresolvedCommit returns the first commit after the reviewer's current one that has
+// an unresolved thread, continuing from the first commit when none follows. The current
+// commit comes last, so it is only returned when no other commit has open threads.
+func nextUnresolvedCommit(ctx context.Context, q *db.Queries, reviewer string) (db.Commit, bool, error) {
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return db.Commit{}, false, ergo.Wrap(err, "failed to list commits")
+	}
+	roots, err := q.ListUnresolvedRoots(ctx)
+	if err != nil {
+		return db.Commit{}, false, ergo.Wrap(err, "failed to load comments")
+	}
+	open := map[string]bool{}
+	for _, r := range roots {
+		open[r.Commit.String] = true
+	}
+
+	current := int64(-1)
+	if r, err := q.GetReviewer(ctx, reviewer); err == nil && r.CurrentSha.Valid {
+		current = findCommitPosition(commits, r.CurrentSha.String)
+	}
+	for i := range int64(len(commits)) {
+		cm := commits[(current+1+i)%int64(len(commits))]
+		if open[cm.Sha] {
+			return cm, true, nil
+		}
+	}
+	return db.Commit{}, false, nil
+}
+
+// firstCommitChanging returns the reviewed commit that comes first in review order among
+// those that change file. The stored commits are tested one by one, since the branch may
+// have moved, or not be checked out at all, since start.
+func firstCommitChanging(ctx context.Context, g *git.Git, q *db.Queries, file string) (db.Commit, error) {
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return db.Commit{}, ergo.Wrap(err, "failed to list commits")
+	}
+	for _, cm := range commits {
+		changes, err := g.ChangesPath(cm.Sha, file)
+		if err != nil {
+			return db.Commit{}, ergo.Wrap(err, "failed to list the files a commit changes", slog.String("commit", cm.Sha), slog.String("file", file))
+		}
+		if changes {
+			return cm, nil
+		}
+	}
+	return db.Commit{}, ergo.New(fmt.Sprintf("%s is not changed in any reviewed commit", file), slog.String("file", file))
+}
@@ -1,10 +1,12 @@
 package commands
 
 import (
+	"cmp"
 	"context"
-	"database/sql"
 	"fmt"
-	"log/slog"
+	"html"
+	"maps"
+	"slices"
 	"sort"
 	"strings"
 
@@ -13,16 +15,58 @@ import (
 	"github.com/FujishigeTemma/git-review/internal/git"
 	"github.com/FujishigeTemma/git-review/internal/output"
 	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/google/uuid"
 	"github.com/newmo-oss/ergo"
 )
 
 type ListCmd struct {
-	ID         string `arg:"" optional:"" help:"Comment ID to show specific thread."`
-	Commit     string `help:"Filter by commit hash prefix." name:"commit"`
+	ID         string `arg:"" optional:"" help:"Comment ID to show specific thread." completion:"ids"`
+	Commit     string `help:"Filter by commit hash prefix." name:"commit" completion:"commits"`
 	Unresolved bool   `help:"Show only unresolved threads." name:"unresolved"`
-	Creator    string `help:"Filter by creator." name:"creator"`
-	File       string `help:"Filter by file path." name:"file"`
+	Creator    string `help:"Show whole threads started by this author, including everyone's replies." name:"creator"`
+	By         string `help:"Show only the comments this author wrote, roots and replies alike, regardless of who started the thread." name:"by"`
+	AssignedTo string `help:"Show only threads with a comment assigned to this name (add --assign)." name:"assigned-to"`
+	File       string `help:"Filter by file path." name:"file" completion:"files"`
 	TopLevel   bool   `help:"Show only top-level comments (no replies)." name:"top-level"`
+
+	ReviewedOnly bool `help:"Show only threads on commits at or before your current position, leaving out commits you have not reached and the review summary." name:"reviewed-only"`
+
+	CollapseResolved bool `help:"Show resolved threads as a single line with a reply count." name:"collapse-resolved"`
+	NeedsResponse    bool `help:"Show only threads with a question awaiting a response." name:"needs-response"`
+
+	Flat    bool   `help:"List all comments in one chronological stream instead of per-commit sections." name:"flat" xor:"layout"`
+	ByFile  bool   `help:"Group comments by file across all commits instead of by commit, tagging each with its commit." name:"by-file" xor:"layout"`
+	Stat    bool   `help:"Print one line per commit with its comment and open/resolved thread counts instead of the threads." name:"stat" xor:"layout"`
+	GFM     bool   `help:"Render threads as GitHub-flavored Markdown task lists with nested replies, for pasting into a pull request." name:"gfm" xor:"layout"`
+	Oneline bool   `help:"Print one line per thread with its location, the start of its body and a reply count, without the replies." name:"oneline" xor:"layout"`
+	Sort    string `help:"Order of top-level comments within each section: commit (as added), created, -created (newest first), or file (by path, then line)." enum:"commit,created,-created,file" default:"commit"`
+	Format  string `help:"Output format: markdown, html, sarif, or csv (one row per comment). Issue references configured with review.issueLinkPattern/review.issueLinkUrl are rendered as links." enum:"markdown,html,sarif,csv" default:"markdown"`
+
+	IncludeResolved bool `help:"With --format=sarif, also export resolved threads (as suppressed results)." name:"include-resolved"`
+
+	Depth int `help:"Show replies at most N levels below each thread root, counting the rest; 0 shows all." placeholder:"N"`
+
+	Limit  int `help:"Show at most N threads (after filtering), oldest first; 0 shows all." placeholder:"N"`
+	Offset int `help:"Skip the first N threads (after filtering), for paging with --limit." placeholder:"N"`
+}
+
+// commentFilter holds the list filters. Set fields are ANDed together.
+type commentFilter struct {
+	commit        string
+	unresolved    bool
+	creator       string
+	by            string
+	file          string
+	needsResponse bool
+	assignedTo    string
+	reviewedOnly  bool
+	reviewedUpTo  int64 // position of the last commit reviewed, -1 when none
+
+	files commentFiles // lets file match any file of a comment on several files
+}
+
+func (f commentFilter) isZero() bool {
+	return f.commit == "" && !f.unresolved && f.creator == "" && f.by == "" && f.file == "" && !f.needsResponse && f.assignedTo == "" && !f.reviewedOnly
 }
 
 func (c *ListCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
@@ -33,9 +77,26 @@ func (c *ListCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outpu
 	ctx := context.Background()
 	q := repo.Queries()
 
+	links, err := loadIssueLinker(g)
+	if err != nil {
+		return err
+	}
+
 	// If ID specified, show that thread only
 	if c.ID != "" {
-		return c.showThread(ctx, q, out)
+		if c.Format == "sarif" || c.Format == "csv" {
+			return ergo.New("--format=" + c.Format + " cannot be combined with a comment ID")
+		}
+		return c.showThread(ctx, g, q, out, links)
+	}
+	if c.Stat && c.Format != "markdown" {
+		return ergo.New("--stat cannot be combined with --format=" + c.Format)
+	}
+	if c.GFM && c.Format != "markdown" {
+		return ergo.New("--gfm cannot be combined with --format=" + c.Format)
+	}
+	if c.Oneline && c.Format != "markdown" {
+		return ergo.New("--oneline cannot be combined with --format=" + c.Format)
 	}
 
 	session, err := q.GetSession(ctx)
@@ -53,94 +114,706 @@ func (c *ListCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outpu
 		out.Warn(fmt.Sprintf("failed to load comments: %v", err))
 	}
 
+	files, err := loadCommentFiles(ctx, q, allComments)
+	if err != nil {
+		return err
+	}
+	roles, err := loadReviewerRoles(ctx, q)
+	if err != nil {
+		return err
+	}
+
 	// Build lookup maps once for efficient tree operations
 	childrenMap := buildChildrenMap(allComments)
 	idMap := buildIDMap(allComments)
 
+	p := threadPrinter{
+		out:         out,
+		childrenMap: childrenMap,
+		files:       files,
+		preAmend:    preAmendIDs(allComments, currentTrees(g, session, commits)),
+		links:       links,
+		html:        c.Format == "html",
+		depth:       c.Depth,
+		roles:       roles,
+	}
+
+	reviewedUpTo := int64(-1)
+	if c.ReviewedOnly {
+		reviewer, err := q.GetReviewer(ctx, g.Reviewer)
+		if err != nil {
+			return ergo.Wrap(err, "failed to get reviewer")
+		}
+		reviewedUpTo = findCommitPosition(commits, reviewer.CurrentSha.String)
+	}
+
 	// Apply filters to get the set of relevant root comment IDs
-	comments := filterComments(allComments, commits, idMap, c.Commit, c.Unresolved, c.Creator, c.File)
+	comments := filterComments(allComments, commits, idMap, commentFilter{
+		commit:        c.Commit,
+		unresolved:    c.Unresolved || (c.Format == "sarif" && !c.IncludeResolved),
+		creator:       c.Creator,
+		by:            c.By,
+		file:          c.File,
+		needsResponse: c.NeedsResponse,
+		assignedTo:    strings.TrimPrefix(c.AssignedTo, "@"),
+		reviewedOnly:  c.ReviewedOnly,
+		reviewedUpTo:  reviewedUpTo,
+		files:         files,
+	})
+
+	if c.By != "" {
+		comments = detachOrphans(comments)
+		p.childrenMap = buildChildrenMap(comments)
+	}
+
+	if c.Stat {
+		printStat(out, commits, comments)
+		return nil
+	}
+
+	if c.Depth < 0 {
+		return ergo.New("--depth must not be negative")
+	}
+	if c.Limit < 0 || c.Offset < 0 {
+		return ergo.New("--limit and --offset must not be negative")
+	}
+	paged := c.Limit > 0 || c.Offset > 0
+	var pg threadPage
+	if paged {
+		comments, pg = pageThreads(comments, c.Offset, c.Limit)
+	}
+
+	switch {
+	case c.Format == "sarif":
+		return printSARIF(out, session, commits, comments, p.childrenMap)
+	case c.Format == "csv":
+		if c.TopLevel {
+			comments = slices.DeleteFunc(comments, func(cm db.Comment) bool { return cm.ParentID.Valid })
+		}
+		return printCSV(out, commits, comments, files)
+	case c.Flat:
+		c.printFlat(p, session, commits, comments)
+	case c.ByFile:
+		c.printByFile(p, session, commits, comments)
+	case c.GFM:
+		c.printGFM(p, session, commits, comments)
+	case c.Oneline:
+		c.printOneline(p, commits, comments)
+	case p.html:
+		c.printHTML(p, session, commits, comments)
+	default:
+		c.printMarkdown(p, session, commits, comments)
+	}
+
+	if paged {
+		if p.html {
+			out.Printf("<p>%s</p>\n", pg)
+		} else {
+			out.Printf("%s\n", pg)
+		}
+	}
 
+	return nil
+}
+
+// printStat prints one line per commit with its comment count and how many of its
+// threads are open and resolved, for a quick scan before reading the threads.
+func printStat(out *output.Output, commits []db.Commit, comments []db.Comment) {
+	type tally struct{ comments, open, resolved int }
+	tallies := map[string]*tally{}
+	for _, cc := range comments {
+		t := tallies[cc.Commit.String]
+		if t == nil {
+			t = &tally{}
+			tallies[cc.Commit.String] = t
+		}
+		t.comments++
+		if !cc.ParentID.Valid {
+			if cc.ResolvedAt.Valid {
+				t.resolved++
+			} else {
+				t.open++
+			}
+		}
+	}
+
+	describe := func(t *tally) string {
+		if t == nil {
+			return "no comments"
+		}
+		var threads []string
+		if t.open > 0 {
+			threads = append(threads, fmt.Sprintf("%d open", t.open))
+		}
+		if t.resolved > 0 {
+			threads = append(threads, fmt.Sprintf("%d resolved", t.resolved))
+		}
+		s := fmt.Sprintf("%d %s", t.comments, internal.Pluralize(t.comments, "comment", "comments"))
+		if len(threads) > 0 {
+			s += " (" + strings.Join(threads, ", ") + ")"
+		}
+		return s
+	}
+
+	for _, cm := range commits {
+		out.Printf("%d/%d %s %s — %s\n", cm.Position+1, len(commits), internal.ShortSHA(cm.Sha), cm.Message, describe(tallies[cm.Sha]))
+	}
+	if t := tallies[""]; t != nil {
+		out.Printf("Review summary — %s\n", describe(t))
+	}
+}
+
+// onelineMinBody is the fewest characters of a body --oneline shows, however narrow the terminal.
+const onelineMinBody = 20
+
+// printOneline prints one line per thread in review order: its root's ID, commit, location
+// and body, cut to fit the terminal, then "(+N)" for its replies and its status tags.
+func (c *ListCmd) printOneline(p threadPrinter, commits []db.Commit, comments []db.Comment) {
+	sections := []string{""} // review summaries first
+	for _, cm := range commits {
+		sections = append(sections, cm.Sha)
+	}
+
+	out := p.out
+	for _, sha := range sections {
+		general, files := groupCommitComments(comments, p.files, sha)
+		sortSection(general, files, c.Sort)
+		roots := general
+		for _, fe := range files {
+			roots = append(roots, fe.comments...)
+		}
+
+		where := "summary"
+		if sha != "" {
+			where = fmt.Sprintf("%d/%d %s", findCommitPosition(commits, sha)+1, len(commits), internal.ShortSHA(sha))
+		}
+		for _, tc := range roots {
+			at := where
+			if tc.OnBase {
+				at = "base"
+			}
+			head := fmt.Sprintf("[%s] %s %s", internal.ShortID(tc.ID), at, fileLocation(tc, p.files))
+			tail := ""
+			if n := countReplies(tc, p.childrenMap); n > 0 {
+				tail = fmt.Sprintf(" (+%d)", n)
+			}
+			tail += p.tags(tc)
+
+			width := threadBodyWidth
+			if w := out.Width(); w > 0 {
+				width = max(w-len([]rune(head+tail)), onelineMinBody)
+			}
+			line := head + truncateBody(tc.Body, width) + tail
+			if tc.ResolvedAt.Valid {
+				line = out.Green(line)
+			} else {
+				line = out.Yellow(line)
+			}
+			out.Printf("%s\n", line)
+		}
+	}
+}
+
+// threadPage describes which threads a --limit/--offset page holds.
+type threadPage struct {
+	first, last int // 1-based, inclusive; 0 when the page is empty
+	total       int
+}
+
+func (pg threadPage) String() string {
+	if pg.first == 0 {
+		return fmt.Sprintf("No threads on this page (%d in total).", pg.total)
+	}
+	return fmt.Sprintf("Showing %d-%d of %d %s.", pg.first, pg.last, pg.total, internal.Pluralize(pg.total, "thread", "threads"))
+}
+
+// pageThreads keeps the threads in [offset, offset+limit) of the roots ordered by ID,
+// which for UUIDv7 is creation order, so pages stay stable as comments are added.
+// A limit of 0 means no limit.
+func pageThreads(comments []db.Comment, offset, limit int) ([]db.Comment, threadPage) {
+	var roots []string
+	for _, cm := range comments {
+		if !cm.ParentID.Valid {
+			roots = append(roots, cm.ID.String())
+		}
+	}
+	slices.Sort(roots)
+
+	pg := threadPage{total: len(roots)}
+	start := min(offset, len(roots))
+	end := len(roots)
+	if limit > 0 {
+		end = min(start+limit, len(roots))
+	}
+	if start < end {
+		pg.first, pg.last = start+1, end
+	}
+
+	onPage := map[string]bool{}
+	for _, id := range roots[start:end] {
+		onPage[id] = true
+	}
+	idMap := buildIDMap(comments)
+	var result []db.Comment
+	for _, cm := range comments {
+		if onPage[findRoot(idMap, cm).ID.String()] {
+			result = append(result, cm)
+		}
+	}
+	return result, pg
+}
+
+// printFlat lists every thread in one stream, oldest first unless --sort says otherwise.
+// Each line is tagged with its commit and location since there are no sections.
+func (c *ListCmd) printFlat(p threadPrinter, session db.Session, commits []db.Commit, comments []db.Comment) {
+	var roots []db.Comment
+	for _, cc := range comments {
+		if !cc.ParentID.Valid {
+			roots = append(roots, cc)
+		}
+	}
+	order := c.Sort
+	if order == "commit" || order == "" {
+		order = "created"
+	}
+	switch order {
+	case "file":
+		slices.SortStableFunc(roots, func(a, b db.Comment) int {
+			return cmp.Or(cmp.Compare(a.File.String, b.File.String), cmp.Compare(a.StartLine.Int64, b.StartLine.Int64))
+		})
+	default:
+		sortSection(roots, nil, order)
+	}
+
+	out := p.out
+	if p.html {
+		out.Printf("<h1>Review Comments</h1>\n")
+		out.Printf("<p>Branch: %s<br>\nCommits: %d</p>\n", html.EscapeString(session.Branch), len(commits))
+		if len(roots) == 0 {
+			out.Printf("<p>No comments</p>\n")
+			return
+		}
+		out.Printf("<ul>\n")
+	} else {
+		out.Printf("\n")
+		out.Printf("# Review Comments\n")
+		out.Printf("\n")
+		out.Printf("Branch: %s\n", session.Branch)
+		out.Printf("Commits: %d\n", len(commits))
+		out.Printf("\n")
+		if len(roots) == 0 {
+			out.Printf("No comments\n")
+		}
+	}
+
+	for _, tc := range roots {
+		// An empty section commit makes every line carry its commit SHA
+		switch {
+		case c.TopLevel:
+			p.printLine("", p.formatComment(tc, "", fileLocation(tc, p.files)))
+		case c.CollapseResolved && tc.ResolvedAt.Valid:
+			p.printCollapsedThread(tc, "", "", fileLocation(tc, p.files))
+		default:
+			p.printThread(tc, "", "", fileLocation(tc, p.files))
+		}
+	}
+
+	if p.html {
+		out.Printf("</ul>\n")
+	} else {
+		out.Printf("\n")
+	}
+}
+
+// printByFile lists threads under one section per file path, across all commits, so a
+// file's feedback can be read together as it evolves through the branch. Within a file,
+// threads follow the review order and then line unless --sort says otherwise. Review
+// summaries come first and other comments not on a file last; lines carry their commit.
+// A comment on several files is listed under each of them.
+func (c *ListCmd) printByFile(p threadPrinter, session db.Session, commits []db.Commit, comments []db.Comment) {
+	summary, _ := groupCommitComments(comments, p.files, "")
+	var general []db.Comment
+	byFile := map[string][]db.Comment{}
+	for _, cc := range comments {
+		switch {
+		case cc.ParentID.Valid || !cc.Commit.Valid:
+		case cc.File.Valid:
+			for _, f := range p.files.of(cc) {
+				byFile[f] = append(byFile[f], cc)
+			}
+		default:
+			general = append(general, cc)
+		}
+	}
+	paths := slices.Sorted(maps.Keys(byFile))
+
+	order := func(roots []db.Comment) {
+		if c.Sort == "created" || c.Sort == "-created" {
+			sortSection(roots, nil, c.Sort)
+			return
+		}
+		slices.SortStableFunc(roots, func(a, b db.Comment) int {
+			if c.Sort == "file" {
+				return cmp.Compare(a.StartLine.Int64, b.StartLine.Int64)
+			}
+			return cmp.Or(
+				cmp.Compare(findCommitPosition(commits, a.Commit.String), findCommitPosition(commits, b.Commit.String)),
+				cmp.Compare(a.StartLine.Int64, b.StartLine.Int64))
+		})
+	}
+
+	out := p.out
+	if p.html {
+		out.Printf("<h1>Review Comments</h1>\n")
+		out.Printf("<p>Branch: %s<br>\nCommits: %d</p>\n", html.EscapeString(session.Branch), len(commits))
+	} else {
+		out.Printf("\n")
+		out.Printf("# Review Comments\n")
+		out.Printf("\n")
+		out.Printf("Branch: %s\n", session.Branch)
+		out.Printf("Commits: %d\n", len(commits))
+	}
+	if len(summary) == 0 && len(paths) == 0 && len(general) == 0 {
+		if p.html {
+			out.Printf("<p>No comments</p>\n")
+		} else {
+			out.Printf("\nNo comments\n\n")
+		}
+		return
+	}
+
+	section := func(title string, roots []db.Comment, inFile bool) {
+		order(roots)
+		if p.html {
+			out.Printf("<h2>%s</h2>\n<ul>\n", html.EscapeString(title))
+		} else {
+			out.Printf("\n---\n\n## %s\n\n", title)
+		}
+		for _, tc := range roots {
+			loc := ""
+			if inFile {
+				loc = lineLocation(tc)
+			}
+			// An empty section commit makes every line carry its commit SHA
+			switch {
+			case c.TopLevel:
+				p.printLine("", p.formatComment(tc, "", loc))
+			case c.CollapseResolved && tc.ResolvedAt.Valid:
+				p.printCollapsedThread(tc, "", "", loc)
+			default:
+				p.printThread(tc, "", "", loc)
+			}
+		}
+		if p.html {
+			out.Printf("</ul>\n")
+		}
+	}
+
+	if len(summary) > 0 {
+		section("Review Summary", summary, false)
+	}
+	for _, path := range paths {
+		section(path, byFile[path], true)
+	}
+	if len(general) > 0 {
+		section("General", general, false)
+	}
+	if !p.html {
+		out.Printf("\n")
+	}
+}
+
+func (c *ListCmd) printMarkdown(p threadPrinter, session db.Session, commits []db.Commit, comments []db.Comment) {
 	total := len(commits)
 
+	out := p.out
 	out.Printf("\n")
 	out.Printf("# Review Comments\n")
 	out.Printf("\n")
 	out.Printf("Branch: %s\n", session.Branch)
 	out.Printf("Commits: %d\n", total)
 
-	for _, cm := range commits {
+	// Review summaries are not tied to a commit and come first
+	if summary, _ := groupCommitComments(comments, p.files, ""); len(summary) > 0 {
+		sortSection(summary, nil, c.Sort)
 		out.Printf("\n")
 		out.Printf("---\n")
 		out.Printf("\n")
-		out.Printf("## Commit %d/%d %s: %s\n", cm.Position+1, total, internal.ShortSHA(cm.Sha), cm.Message)
+		out.Printf("## Review Summary\n")
 		out.Printf("\n")
+		c.printThreads(p, summary, "", false)
+	}
 
-		// Collect top-level comments for this commit from filtered set
-		var commitTopLevel []db.Comment
-		for _, cc := range comments {
-			if cc.Commit == cm.Sha && !cc.ParentID.Valid {
-				commitTopLevel = append(commitTopLevel, cc)
-			}
+	// Threads on the base come next, since the commits build on it
+	base, comments := splitBaseThreads(comments)
+	if len(base) > 0 {
+		general, files := groupRoots(base, p.files)
+		sortSection(general, files, c.Sort)
+		out.Printf("\n")
+		out.Printf("---\n")
+		out.Printf("\n")
+		out.Printf("## Base %s\n", internal.ShortSHA(session.BaseRef))
+		out.Printf("\n")
+		c.printThreads(p, general, baseSection, false)
+		for _, fe := range files {
+			out.Printf("%s\n", fe.file)
+			c.printThreads(p, fe.comments, baseSection, true)
 		}
+	}
 
-		if len(commitTopLevel) == 0 {
+	for _, cm := range commits {
+		out.Printf("\n")
+		out.Printf("---\n")
+		out.Printf("\n")
+		out.Printf("## Commit %d/%d %s: %s\n", cm.Position+1, total, internal.ShortSHA(cm.Sha), cm.Message)
+		out.Printf("\n")
+
+		general, files := groupCommitComments(comments, p.files, cm.Sha)
+		sortSection(general, files, c.Sort)
+		if len(general) == 0 && len(files) == 0 {
 			out.Printf("No comments\n")
 			continue
 		}
 
-		// General comments (no file)
-		for _, tc := range commitTopLevel {
-			if tc.File.Valid {
-				continue
-			}
-			if c.TopLevel {
-				printCommentLine(out, tc, cm.Sha, "")
-			} else {
-				printThreadFlat(out, childrenMap, tc, cm.Sha)
-			}
+		c.printThreads(p, general, cm.Sha, false)
+		for _, fe := range files {
+			out.Printf("%s\n", fe.file)
+			c.printThreads(p, fe.comments, cm.Sha, true)
 		}
+	}
+	out.Printf("\n")
+}
+
+// printHTML renders the same layout as printMarkdown as an HTML fragment, with threads as nested lists.
+func (c *ListCmd) printHTML(p threadPrinter, session db.Session, commits []db.Commit, comments []db.Comment) {
+	total := len(commits)
+
+	out := p.out
+	out.Printf("<h1>Review Comments</h1>\n")
+	out.Printf("<p>Branch: %s<br>\nCommits: %d</p>\n", html.EscapeString(session.Branch), total)
 
-		// File-specific comments grouped by file
-		type fileEntry struct {
-			file     string
-			comments []db.Comment
+	if summary, _ := groupCommitComments(comments, p.files, ""); len(summary) > 0 {
+		sortSection(summary, nil, c.Sort)
+		out.Printf("<h2>Review Summary</h2>\n<ul>\n")
+		c.printThreads(p, summary, "", false)
+		out.Printf("</ul>\n")
+	}
+
+	base, comments := splitBaseThreads(comments)
+	if len(base) > 0 {
+		general, files := groupRoots(base, p.files)
+		sortSection(general, files, c.Sort)
+		out.Printf("<h2>Base %s</h2>\n", internal.ShortSHA(session.BaseRef))
+		if len(general) > 0 {
+			out.Printf("<ul>\n")
+			c.printThreads(p, general, baseSection, false)
+			out.Printf("</ul>\n")
 		}
-		seen := map[string]int{}
-		var fileEntries []fileEntry
-		for _, tc := range commitTopLevel {
-			if !tc.File.Valid {
-				continue
-			}
-			f := tc.File.String
-			if idx, ok := seen[f]; ok {
-				fileEntries[idx].comments = append(fileEntries[idx].comments, tc)
-			} else {
-				seen[f] = len(fileEntries)
-				fileEntries = append(fileEntries, fileEntry{file: f, comments: []db.Comment{tc}})
-			}
+		for _, fe := range files {
+			out.Printf("<h3>%s</h3>\n<ul>\n", html.EscapeString(fe.file))
+			c.printThreads(p, fe.comments, baseSection, true)
+			out.Printf("</ul>\n")
 		}
+	}
 
-		for _, fe := range fileEntries {
-			out.Printf("%s\n", fe.file)
-			for _, tc := range fe.comments {
-				if c.TopLevel {
-					printCommentLine(out, tc, cm.Sha, "  ")
-				} else {
-					printFileThreadFlat(out, childrenMap, tc, cm.Sha)
-				}
-			}
+	for _, cm := range commits {
+		out.Printf("<h2>Commit %d/%d %s: %s</h2>\n", cm.Position+1, total, internal.ShortSHA(cm.Sha), html.EscapeString(cm.Message))
+
+		general, files := groupCommitComments(comments, p.files, cm.Sha)
+		sortSection(general, files, c.Sort)
+		if len(general) == 0 && len(files) == 0 {
+			out.Printf("<p>No comments</p>\n")
+			continue
+		}
+
+		if len(general) > 0 {
+			out.Printf("<ul>\n")
+			c.printThreads(p, general, cm.Sha, false)
+			out.Printf("</ul>\n")
+		}
+		for _, fe := range files {
+			out.Printf("<h3>%s</h3>\n<ul>\n", html.EscapeString(fe.file))
+			c.printThreads(p, fe.comments, cm.Sha, true)
+			out.Printf("</ul>\n")
 		}
 	}
+}
+
+// printGFM renders the same layout as printMarkdown as GitHub-flavored Markdown: each
+// thread is a task-list item, checked once resolved, with its location as inline code
+// and its replies nested below it. Unlike the default output it has no colors or IDs.
+func (c *ListCmd) printGFM(p threadPrinter, session db.Session, commits []db.Commit, comments []db.Comment) {
+	total := len(commits)
+
+	out := p.out
+	out.Printf("# Review Comments\n")
 	out.Printf("\n")
+	out.Printf("Branch `%s`, %d %s\n", session.Branch, total, internal.Pluralize(total, "commit", "commits"))
 
-	return nil
+	if summary, _ := groupCommitComments(comments, p.files, ""); len(summary) > 0 {
+		sortSection(summary, nil, c.Sort)
+		out.Printf("\n")
+		out.Printf("## Review Summary\n")
+		out.Printf("\n")
+		c.printGFMThreads(p, summary, "")
+	}
+
+	for _, cm := range commits {
+		out.Printf("\n")
+		out.Printf("## Commit %d/%d `%s`: %s\n", cm.Position+1, total, internal.ShortSHA(cm.Sha), cm.Message)
+		out.Printf("\n")
+
+		general, files := groupCommitComments(comments, p.files, cm.Sha)
+		sortSection(general, files, c.Sort)
+		if len(general) == 0 && len(files) == 0 {
+			out.Printf("_No comments_\n")
+			continue
+		}
+
+		c.printGFMThreads(p, general, cm.Sha)
+		for _, fe := range files {
+			c.printGFMThreads(p, fe.comments, cm.Sha)
+		}
+	}
+}
+
+// printGFMThreads prints the given root comments as task-list items, honoring
+// --top-level and --collapse-resolved like printThreads.
+func (c *ListCmd) printGFMThreads(p threadPrinter, roots []db.Comment, sectionCommit string) {
+	for _, tc := range roots {
+		box := "[ ]"
+		if tc.ResolvedAt.Valid {
+			box = "[x]"
+		}
+		line := p.gfmItem(tc, sectionCommit, "  ")
+		collapse := c.CollapseResolved && tc.ResolvedAt.Valid
+		if n := len(descendants(p.childrenMap, tc.ID)); collapse && n > 0 {
+			line += fmt.Sprintf(" (%d %s)", n, internal.Pluralize(n, "reply", "replies"))
+		}
+		p.out.Printf("- %s %s\n", box, line)
+		if c.TopLevel || collapse {
+			continue
+		}
+		p.printGFMReplies(tc, sectionCommit, "  ", 1)
+	}
+}
+
+// printGFMReplies prints the replies to c as a bullet list nested one level deeper per
+// reply depth. Below --depth, a single item counts the replies left out.
+func (p threadPrinter) printGFMReplies(c db.Comment, sectionCommit string, indent string, level int) {
+	if p.depth > 0 && level > p.depth {
+		if n := countReplies(c, p.childrenMap); n > 0 {
+			p.out.Printf("%s- %s\n", indent, moreRepliesHint(n))
+		}
+		return
+	}
+	for _, child := range p.childrenMap[c.ID.String()] {
+		p.out.Printf("%s- %s\n", indent, p.gfmItem(child, sectionCommit, indent+"  "))
+		p.printGFMReplies(child, sectionCommit, indent+"  ", level+1)
+	}
+}
+
+// gfmItem renders a comment as the text of a list item: "`path:10-25` body @author [tags]",
+// with the body's continuation lines indented by indent so they stay inside the item.
+func (p threadPrinter) gfmItem(c db.Comment, sectionCommit string, indent string) string {
+	loc := ""
+	if c.File.Valid && !c.ParentID.Valid {
+		loc = p.files.label(c)
+		if lr := internal.FormatLineRange(c.StartLine, c.EndLine); lr != "" {
+			loc += ":" + lr
+		}
+		loc = "`" + loc + "` "
+	}
+	body := strings.ReplaceAll(p.links.render(c.Body, p.text, p.link), "\n", "\n"+indent)
+	return crossCommitTag(c, sectionCommit) + loc + body + p.author(c.CreatedBy) + p.tags(c)
+}
+
+// printThreads prints the given root comments according to the display flags.
+func (c *ListCmd) printThreads(p threadPrinter, roots []db.Comment, sectionCommit string, inFile bool) {
+	indent := ""
+	if inFile {
+		indent = "  "
+	}
+	for _, tc := range roots {
+		loc := ""
+		if inFile {
+			loc = lineLocation(tc)
+		}
+		switch {
+		case c.TopLevel:
+			p.printCommentLine(tc, sectionCommit, indent)
+		case c.CollapseResolved && tc.ResolvedAt.Valid:
+			p.printCollapsedThread(tc, sectionCommit, indent, loc)
+		default:
+			p.printThread(tc, sectionCommit, indent, loc)
+		}
+	}
+}
+
+// fileComments holds the top-level comments on one file, in first-seen order.
+type fileComments struct {
+	file     string
+	comments []db.Comment
+}
+
+// groupCommitComments splits the top-level comments on a commit into general comments
+// (no file) and comments grouped by file, a comment on several files under all of them
+// together. An empty sha selects the review summaries.
+func groupCommitComments(comments []db.Comment, onFiles commentFiles, sha string) ([]db.Comment, []fileComments) {
+	var roots []db.Comment
+	for _, cc := range comments {
+		if cc.Commit.String == sha && !cc.ParentID.Valid {
+			roots = append(roots, cc)
+		}
+	}
+	return groupRoots(roots, onFiles)
+}
+
+// groupRoots splits thread roots into general comments and comments grouped by file, as
+// groupCommitComments does for one commit.
+func groupRoots(roots []db.Comment, onFiles commentFiles) ([]db.Comment, []fileComments) {
+	var general []db.Comment
+	var files []fileComments
+	seen := map[string]int{}
+	for _, cc := range roots {
+		if !cc.File.Valid {
+			general = append(general, cc)
+			continue
+		}
+		f := onFiles.label(cc)
+		if idx, ok := seen[f]; ok {
+			files[idx].comments = append(files[idx].comments, cc)
+		} else {
+			seen[f] = len(files)
+			files = append(files, fileComments{file: f, comments: []db.Comment{cc}})
+		}
+	}
+	return general, files
+}
+
+// baseSection is the section commit of the Base section, where threads started with
+// add --on-base are listed without their "(base)" tag.
+const baseSection = "base"
+
+// splitBaseThreads takes the roots of threads started with add --on-base out of comments.
+// They are kept on the first commit, but the default and HTML layouts show them in a Base
+// section of their own, before the commits.
+func splitBaseThreads(comments []db.Comment) (base, rest []db.Comment) {
+	for _, cc := range comments {
+		if cc.OnBase && !cc.ParentID.Valid {
+			base = append(base, cc)
+		} else {
+			rest = append(rest, cc)
+		}
+	}
+	return base, rest
 }
 
 // showThread displays a single thread (root + all descendants).
-func (c *ListCmd) showThread(ctx context.Context, q *db.Queries, out *output.Output) error {
-	root, err := q.FindCommentByPrefix(ctx, sql.NullString{String: c.ID, Valid: true})
+func (c *ListCmd) showThread(ctx context.Context, g *git.Git, q *db.Queries, out *output.Output, links issueLinker) error {
+	root, err := findComment(ctx, q, c.ID)
 	if err != nil {
-		return ergo.New("comment not found", slog.String("comment_id", c.ID))
+		return err
 	}
 
 	// Walk up to find the thread root
@@ -159,27 +832,54 @@ func (c *ListCmd) showThread(ctx context.Context, q *db.Queries, out *output.Out
 		return ergo.Wrap(err, "failed to load comments")
 	}
 
-	childrenMap := buildChildrenMap(allComments)
+	session, err := q.GetSession(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to get session")
+	}
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+
+	roles, err := loadReviewerRoles(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	p := threadPrinter{
+		out:         out,
+		childrenMap: buildChildrenMap(allComments),
+		preAmend:    preAmendIDs(allComments, currentTrees(g, session, commits)),
+		links:       links,
+		html:        c.Format == "html",
+		depth:       c.Depth,
+		roles:       roles,
+	}
+	if p.html {
+		out.Printf("<ul>\n")
+		p.printThreadFlat(root, root.Commit.String)
+		out.Printf("</ul>\n")
+		return nil
+	}
 	out.Printf("\n")
-	printThreadFlat(out, childrenMap, root, root.Commit)
+	p.printThreadFlat(root, root.Commit.String)
 	out.Printf("\n")
 
 	return nil
 }
 
 // filterComments applies filters, returning only matching root comments and their descendants.
-// Filters are ANDed together.
-func filterComments(allComments []db.Comment, commits []db.Commit, idMap map[string]db.Comment, commit string, unresolved bool, creator string, file string) []db.Comment {
-	hasFilter := commit != "" || unresolved || creator != "" || file != ""
-	if !hasFilter {
+// The needs-response filter matches a thread when any comment in it awaits a response.
+func filterComments(allComments []db.Comment, commits []db.Commit, idMap map[string]db.Comment, f commentFilter) []db.Comment {
+	if f.isZero() {
 		return allComments
 	}
 
 	// Build commit SHA lookup for prefix matching
 	var matchCommitSHA string
-	if commit != "" {
+	if f.commit != "" {
 		for _, cm := range commits {
-			if strings.HasPrefix(cm.Sha, commit) {
+			if strings.HasPrefix(cm.Sha, f.commit) {
 				matchCommitSHA = cm.Sha
 				break
 			}
@@ -189,6 +889,23 @@ func filterComments(allComments []db.Comment, commits []db.Commit, idMap map[str
 		}
 	}
 
+	questionRoots := map[string]bool{}
+	if f.needsResponse {
+		for _, cm := range allComments {
+			if cm.NeedsResponse {
+				questionRoots[findRoot(idMap, cm).ID.String()] = true
+			}
+		}
+	}
+	assignedRoots := map[string]bool{}
+	if f.assignedTo != "" {
+		for _, cm := range allComments {
+			if cm.Assignee.Valid && cm.Assignee.String == f.assignedTo {
+				assignedRoots[findRoot(idMap, cm).ID.String()] = true
+			}
+		}
+	}
+
 	// Build a set of root IDs that pass filters
 	rootIDs := map[string]bool{}
 	for _, cm := range allComments {
@@ -196,25 +913,38 @@ func filterComments(allComments []db.Comment, commits []db.Commit, idMap map[str
 			continue // only filter roots
 		}
 
-		if matchCommitSHA != "" && cm.Commit != matchCommitSHA {
+		if matchCommitSHA != "" && cm.Commit.String != matchCommitSHA {
+			continue
+		}
+		if f.unresolved && cm.ResolvedAt.Valid {
 			continue
 		}
-		if unresolved && cm.ResolvedAt.Valid {
+		if f.creator != "" && cm.CreatedBy != f.creator {
 			continue
 		}
-		if creator != "" && cm.CreatedBy != creator {
+		if f.file != "" && !slices.Contains(f.files.of(cm), f.file) {
 			continue
 		}
-		if file != "" && (!cm.File.Valid || cm.File.String != file) {
+		if f.needsResponse && !questionRoots[cm.ID.String()] {
+			continue
+		}
+		if f.assignedTo != "" && !assignedRoots[cm.ID.String()] {
+			continue
+		}
+		if f.reviewedOnly && (!cm.Commit.Valid || findCommitPosition(commits, cm.Commit.String) > f.reviewedUpTo) {
 			continue
 		}
 
 		rootIDs[cm.ID.String()] = true
 	}
 
-	// Return comments that are either matching roots or descendants of matching roots
+	// Return comments that are either matching roots or descendants of matching roots,
+	// narrowed to one author's comments for --by
 	var result []db.Comment
 	for _, cm := range allComments {
+		if f.by != "" && cm.CreatedBy != f.by {
+			continue
+		}
 		if !cm.ParentID.Valid {
 			if rootIDs[cm.ID.String()] {
 				result = append(result, cm)
@@ -230,6 +960,50 @@ func filterComments(allComments []db.Comment, commits []db.Commit, idMap map[str
 	return result
 }
 
+// detachOrphans turns replies whose parent was filtered out into standalone entries,
+// so that they are listed in their commit's section instead of being dropped.
+func detachOrphans(comments []db.Comment) []db.Comment {
+	shown := make(map[uuid.UUID]bool, len(comments))
+	for _, cm := range comments {
+		shown[cm.ID] = true
+	}
+	result := make([]db.Comment, len(comments))
+	for i, cm := range comments {
+		if cm.ParentID.Valid && !shown[cm.ParentID.UUID] {
+			cm.ParentID = uuid.NullUUID{}
+		}
+		result[i] = cm
+	}
+	return result
+}
+
+// sortSection reorders a commit section's top-level comments in place for --sort.
+// Replies are looked up per root when printing, so they stay attached to their roots.
+// By creation time, file groups follow their first comment; by file, groups are sorted
+// by path and comments by start line. "commit" keeps the order comments were added in.
+func sortSection(general []db.Comment, files []fileComments, order string) {
+	switch order {
+	case "created", "-created":
+		// UUIDv7 IDs sort chronologically
+		byCreated := func(a, b db.Comment) int { return cmp.Compare(a.ID.String(), b.ID.String()) }
+		if order == "-created" {
+			byCreated = func(a, b db.Comment) int { return cmp.Compare(b.ID.String(), a.ID.String()) }
+		}
+		slices.SortStableFunc(general, byCreated)
+		for i := range files {
+			slices.SortStableFunc(files[i].comments, byCreated)
+		}
+		slices.SortStableFunc(files, func(a, b fileComments) int { return byCreated(a.comments[0], b.comments[0]) })
+	case "file":
+		for i := range files {
+			slices.SortStableFunc(files[i].comments, func(a, b db.Comment) int {
+				return cmp.Compare(a.StartLine.Int64, b.StartLine.Int64) // file-level comments (no line) first
+			})
+		}
+		slices.SortStableFunc(files, func(a, b fileComments) int { return cmp.Compare(a.file, b.file) })
+	}
+}
+
 // buildChildrenMap builds a parentID -> children lookup for efficient tree traversal.
 func buildChildrenMap(allComments []db.Comment) map[string][]db.Comment {
 	m := make(map[string][]db.Comment, len(allComments))
@@ -282,33 +1056,218 @@ func descendants(childrenMap map[string][]db.Comment, id fmt.Stringer) []db.Comm
 	return result
 }
 
-func printThreadFlat(out *output.Output, childrenMap map[string][]db.Comment, tc db.Comment, sectionCommit string) {
-	printCommentLine(out, tc, sectionCommit, "")
-	for _, d := range descendants(childrenMap, tc.ID) {
-		printCommentLine(out, d, sectionCommit, "  ")
+// threadPrinter renders comment threads for list output, as Markdown-style lines or HTML list items.
+type threadPrinter struct {
+	out         *output.Output
+	childrenMap map[string][]db.Comment
+	files       commentFiles
+	preAmend    map[string]bool // IDs of comments made before their commit was amended
+	links       issueLinker
+	html        bool
+	depth       int               // levels of replies shown below a root; 0 shows all
+	roles       map[string]string // reviewer name -> role set with start --role
+}
+
+func (p threadPrinter) printThreadFlat(tc db.Comment, sectionCommit string) {
+	p.printThread(tc, sectionCommit, "", "")
+}
+
+func (p threadPrinter) printFileThreadFlat(tc db.Comment, sectionCommit string) {
+	p.printThread(tc, sectionCommit, "  ", lineLocation(tc))
+}
+
+// printThread prints a root comment followed by all its replies, indented one level
+// (nested in a sub-list for HTML).
+func (p threadPrinter) printThread(tc db.Comment, sectionCommit string, indent string, loc string) {
+	replies, hidden := p.visibleReplies(tc.ID)
+	if !p.html {
+		p.out.Printf("%s%s\n", indent, p.formatComment(tc, sectionCommit, loc))
+		for _, d := range replies {
+			p.printCommentLine(d, sectionCommit, indent+"  ")
+		}
+		if hidden > 0 {
+			p.printLine(indent+"  ", moreRepliesHint(hidden))
+		}
+		return
+	}
+
+	p.out.Printf("<li>%s", p.formatComment(tc, sectionCommit, loc))
+	if len(replies) > 0 {
+		p.out.Printf("\n<ul>\n")
+		for _, d := range replies {
+			p.printCommentLine(d, sectionCommit, "")
+		}
+		if hidden > 0 {
+			p.printLine("", moreRepliesHint(hidden))
+		}
+		p.out.Printf("</ul>\n")
+	}
+	p.out.Printf("</li>\n")
+}
+
+// visibleReplies returns the replies below id, in descendants order, that are at most
+// p.depth levels deep (all of them when depth is 0), and how many are left out.
+func (p threadPrinter) visibleReplies(id fmt.Stringer) ([]db.Comment, int) {
+	all := descendants(p.childrenMap, id)
+	if p.depth <= 0 {
+		return all, 0
+	}
+	within := map[string]bool{}
+	level := []string{id.String()}
+	for range p.depth {
+		var next []string
+		for _, parent := range level {
+			for _, c := range p.childrenMap[parent] {
+				within[c.ID.String()] = true
+				next = append(next, c.ID.String())
+			}
+		}
+		level = next
+	}
+	var shown []db.Comment
+	for _, c := range all {
+		if within[c.ID.String()] {
+			shown = append(shown, c)
+		}
 	}
+	return shown, len(all) - len(shown)
 }
 
-func printFileThreadFlat(out *output.Output, childrenMap map[string][]db.Comment, tc db.Comment, sectionCommit string) {
-	loc := ""
-	if lr := internal.FormatLineRange(tc.StartLine, tc.EndLine); lr != "" {
-		loc = "L" + lr + ": "
+// moreRepliesHint marks replies left out by --depth.
+func moreRepliesHint(n int) string {
+	return fmt.Sprintf("(… %d more %s)", n, internal.Pluralize(n, "reply", "replies"))
+}
+
+// printCollapsedThread prints only the root line of a thread, followed by its reply count.
+func (p threadPrinter) printCollapsedThread(tc db.Comment, sectionCommit string, indent string, loc string) {
+	line := p.formatComment(tc, sectionCommit, loc)
+	if n := len(descendants(p.childrenMap, tc.ID)); n > 0 {
+		line += fmt.Sprintf(" (%d %s)", n, internal.Pluralize(n, "reply", "replies"))
 	}
-	commitTag := crossCommitTag(tc, sectionCommit)
-	suffix := authorSuffix(tc.CreatedBy)
-	tag := resolvedTag(tc)
-	out.Printf("  [%s] %s%s%s%s%s\n", internal.ShortID(tc.ID), commitTag, loc, tc.Body, suffix, tag)
+	p.printLine(indent, line)
+}
+
+func (p threadPrinter) printCommentLine(c db.Comment, sectionCommit string, indent string) {
+	p.printLine(indent, p.formatComment(c, sectionCommit, ""))
+}
 
-	for _, d := range descendants(childrenMap, tc.ID) {
-		printCommentLine(out, d, sectionCommit, "    ")
+// printLine prints one rendered comment: an indented line, or a list item for HTML.
+func (p threadPrinter) printLine(indent string, line string) {
+	if p.html {
+		p.out.Printf("<li>%s</li>\n", line)
+		return
 	}
+	p.out.Printf("%s%s\n", indent, line)
 }
 
-func printCommentLine(out *output.Output, c db.Comment, sectionCommit string, indent string) {
+// formatComment renders "[id] (commit) loc body @author [resolved]" for a single comment.
+// Issue references in the body become links; everything else is escaped for HTML.
+func (p threadPrinter) formatComment(c db.Comment, sectionCommit string, loc string) string {
 	commitTag := crossCommitTag(c, sectionCommit)
-	suffix := authorSuffix(c.CreatedBy)
+	suffix := p.author(c.CreatedBy)
+	tag := p.tags(c)
+	head := fmt.Sprintf("[%s] %s%s", internal.ShortID(c.ID), commitTag, loc)
+	line := p.text(head) + p.links.render(c.Body, p.text, p.link) + p.text(suffix+tag+fixedIn(c))
+	if p.html || c.ParentID.Valid {
+		return line
+	}
+	// Thread roots are colored like status: green when resolved, yellow while open
+	if c.ResolvedAt.Valid {
+		return p.out.Green(line)
+	}
+	return p.out.Yellow(line)
+}
+
+// tags returns the bracketed status tags shown after a comment: resolution, severity,
+// an open question and whether it predates an amend.
+func (p threadPrinter) tags(c db.Comment) string {
 	tag := resolvedTag(c)
-	out.Printf("%s[%s] %s%s%s%s\n", indent, internal.ShortID(c.ID), commitTag, c.Body, suffix, tag)
+	if c.Severity.Valid {
+		tag += " [" + c.Severity.String + "]"
+	}
+	if c.NeedsResponse {
+		tag += " [needs response]"
+	}
+	if p.preAmend[c.ID.String()] {
+		tag += " [pre-amend]"
+	}
+	if c.Assignee.Valid {
+		tag += " →@" + c.Assignee.String
+	}
+	return tag
+}
+
+func (p threadPrinter) text(s string) string {
+	if p.html {
+		return html.EscapeString(s)
+	}
+	return s
+}
+
+func (p threadPrinter) link(label, href string) string {
+	if p.html {
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(href), html.EscapeString(label))
+	}
+	return fmt.Sprintf("[%s](%s)", label, href)
+}
+
+// lineLocation returns an "L10-25: " prefix for comments with a line range, or "".
+func lineLocation(c db.Comment) string {
+	if lr := internal.FormatLineRange(c.StartLine, c.EndLine); lr != "" {
+		return "L" + lr + ": "
+	}
+	return ""
+}
+
+// fileLocation returns a "path:10-25: " prefix for file comments, or "". A comment on
+// several files lists them all: "a.go, b.go: ".
+func fileLocation(c db.Comment, files commentFiles) string {
+	if !c.File.Valid {
+		return ""
+	}
+	if lr := internal.FormatLineRange(c.StartLine, c.EndLine); lr != "" {
+		return files.label(c) + ":" + lr + ": "
+	}
+	return files.label(c) + ": "
+}
+
+// commentFiles maps the ID of each comment on several files to all of them, the first
+// being the file stored on the comment itself. Comments on one file are not in it.
+type commentFiles map[string][]string
+
+// loadCommentFiles reads the files of the given comments that are on several files.
+func loadCommentFiles(ctx context.Context, q *db.Queries, comments []db.Comment) (commentFiles, error) {
+	rows, err := q.ListCommentFiles(ctx)
+	if err != nil {
+		return nil, ergo.Wrap(err, "failed to list comment files")
+	}
+	more := map[string][]string{}
+	for _, r := range rows {
+		more[r.CommentID.String()] = append(more[r.CommentID.String()], r.File)
+	}
+	files := commentFiles{}
+	for _, c := range comments {
+		if m, ok := more[c.ID.String()]; ok && c.File.Valid {
+			files[c.ID.String()] = append([]string{c.File.String}, m...)
+		}
+	}
+	return files, nil
+}
+
+// of returns the files a comment is on, or nil for a comment on no file.
+func (f commentFiles) of(c db.Comment) []string {
+	if files, ok := f[c.ID.String()]; ok {
+		return files
+	}
+	if c.File.Valid {
+		return []string{c.File.String}
+	}
+	return nil
+}
+
+// label returns the comment's file, or its files separated by commas.
+func (f commentFiles) label(c db.Comment) string {
+	return strings.Join(f.of(c), ", ")
 }
 
 // resolvedTag returns a " [resolved ...]" suffix for root comments, or "" for replies/unresolved.
@@ -323,13 +1282,61 @@ func resolvedTag(c db.Comment) string {
 	return tag + "]"
 }
 
+// fixedIn returns a " → fixed in <hash>" suffix for comments added with --fixup, or "".
+func fixedIn(c db.Comment) string {
+	if !c.Fixup.Valid {
+		return ""
+	}
+	return " → fixed in " + internal.ShortSHA(c.Fixup.String)
+}
+
+// crossCommitTag returns a "(sha) " prefix for comments on a commit other than the
+// section's, and "(a↔b) " for comments that span two commits, wherever they are shown.
+// Review summaries belong to no commit and are never tagged; comments on the base are
+// tagged "(base) " outside the Base section, their replies not at all.
 func crossCommitTag(c db.Comment, sectionCommit string) string {
-	if c.Commit != sectionCommit {
-		return "(" + internal.ShortSHA(c.Commit) + ") "
+	if !c.Commit.Valid {
+		return ""
+	}
+	if c.OnBase {
+		if sectionCommit == baseSection || c.ParentID.Valid {
+			return "" // replies sit under their tagged root
+		}
+		return "(base) "
+	}
+	if c.AlsoCommit.Valid && c.AlsoCommit.String != c.Commit.String {
+		return "(" + internal.ShortSHA(c.Commit.String) + "↔" + internal.ShortSHA(c.AlsoCommit.String) + ") "
+	}
+	if c.Commit.String != sectionCommit {
+		return "(" + internal.ShortSHA(c.Commit.String) + ") "
 	}
 	return ""
 }
 
+// author returns the " @name" suffix for a comment's author, as " @name(role)" when the
+// reviewer has a role.
+func (p threadPrinter) author(name string) string {
+	if role := p.roles[name]; role != "" && name != "" {
+		return authorSuffix(name) + "(" + role + ")"
+	}
+	return authorSuffix(name)
+}
+
+// loadReviewerRoles maps each reviewer that has a role to it.
+func loadReviewerRoles(ctx context.Context, q *db.Queries) (map[string]string, error) {
+	reviewers, err := q.ListReviewers(ctx)
+	if err != nil {
+		return nil, ergo.Wrap(err, "failed to list reviewers")
+	}
+	roles := map[string]string{}
+	for _, r := range reviewers {
+		if r.Role.Valid {
+			roles[r.Name] = r.Role.String
+		}
+	}
+	return roles, nil
+}
+
 func authorSuffix(author string) string {
 	if author == "" {
 		return ""
@@ -1,9 +1,13 @@
 package commands
 
 import (
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/output"
 	"github.com/google/uuid"
 	"github.com/guregu/null/v6"
 )
@@ -14,7 +18,7 @@ func TestFilterComments_NoFilter(t *testing.T) {
 		newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "def", "c2", "", null.String{}, null.Int{}, null.Int{}),
 	}
 	idMap := buildIDMap(comments)
-	got := filterComments(comments, nil, idMap, "", false, "", "")
+	got := filterComments(comments, nil, idMap, commentFilter{})
 	if len(got) != 2 {
 		t.Errorf("expected 2 comments, got %d", len(got))
 	}
@@ -29,7 +33,7 @@ func TestFilterComments_ByCommitPrefix(t *testing.T) {
 	}
 	commits := []db.Commit{{Sha: "abc123"}, {Sha: "def456"}}
 	idMap := buildIDMap(comments)
-	got := filterComments(comments, commits, idMap, "abc", false, "", "")
+	got := filterComments(comments, commits, idMap, commentFilter{commit: "abc"})
 	if len(got) != 1 {
 		t.Fatalf("expected 1 comment, got %d", len(got))
 	}
@@ -43,10 +47,10 @@ func TestFilterComments_ByUnresolved(t *testing.T) {
 	id2 := uuid.Must(uuid.NewV7())
 	comments := []db.Comment{
 		newComment(id1, uuid.NullUUID{}, "abc", "open", "", null.String{}, null.Int{}, null.Int{}),
-		{ID: id2, Commit: "abc", Body: "resolved", ResolvedAt: null.StringFrom("2024-01-01T00:00:00Z")},
+		{ID: id2, Commit: null.StringFrom("abc"), Body: "resolved", ResolvedAt: null.StringFrom("2024-01-01T00:00:00Z")},
 	}
 	idMap := buildIDMap(comments)
-	got := filterComments(comments, nil, idMap, "", true, "", "")
+	got := filterComments(comments, nil, idMap, commentFilter{unresolved: true})
 	if len(got) != 1 {
 		t.Fatalf("expected 1 comment, got %d", len(got))
 	}
@@ -63,7 +67,7 @@ func TestFilterComments_ByCreator(t *testing.T) {
 		newComment(id2, uuid.NullUUID{}, "abc", "by bob", "bob", null.String{}, null.Int{}, null.Int{}),
 	}
 	idMap := buildIDMap(comments)
-	got := filterComments(comments, nil, idMap, "", false, "alice", "")
+	got := filterComments(comments, nil, idMap, commentFilter{creator: "alice"})
 	if len(got) != 1 {
 		t.Fatalf("expected 1 comment, got %d", len(got))
 	}
@@ -72,6 +76,61 @@ func TestFilterComments_ByCreator(t *testing.T) {
 	}
 }
 
+func TestFilterComments_ByAuthorIncludesReplies(t *testing.T) {
+	rootID := uuid.Must(uuid.NewV7())
+	replyID := uuid.Must(uuid.NewV7())
+	otherID := uuid.Must(uuid.NewV7())
+	comments := []db.Comment{
+		newComment(rootID, uuid.NullUUID{}, "abc", "by alice", "alice", null.String{}, null.Int{}, null.Int{}),
+		newComment(replyID, uuid.NullUUID{UUID: rootID, Valid: true}, "abc", "bob replies", "bob", null.String{}, null.Int{}, null.Int{}),
+		newComment(otherID, uuid.NullUUID{}, "abc", "bob starts", "bob", null.String{}, null.Int{}, null.Int{}),
+	}
+	idMap := buildIDMap(comments)
+	got := filterComments(comments, nil, idMap, commentFilter{by: "bob"})
+	if len(got) != 2 {
+		t.Fatalf("expected 2 comments, got %d", len(got))
+	}
+	if got[0].Body != "bob replies" || got[1].Body != "bob starts" {
+		t.Errorf("got bodies %q, %q", got[0].Body, got[1].Body)
+	}
+}
+
+func TestFilterComments_ByAuthorWithinMatchingThreads(t *testing.T) {
+	rootID := uuid.Must(uuid.NewV7())
+	replyID := uuid.Must(uuid.NewV7())
+	otherID := uuid.Must(uuid.NewV7())
+	comments := []db.Comment{
+		newComment(rootID, uuid.NullUUID{}, "abc", "alice on main.go", "alice", null.StringFrom("main.go"), null.Int{}, null.Int{}),
+		newComment(replyID, uuid.NullUUID{UUID: rootID, Valid: true}, "abc", "bob on main.go", "bob", null.StringFrom("main.go"), null.Int{}, null.Int{}),
+		newComment(otherID, uuid.NullUUID{}, "abc", "bob elsewhere", "bob", null.StringFrom("other.go"), null.Int{}, null.Int{}),
+	}
+	idMap := buildIDMap(comments)
+	got := filterComments(comments, nil, idMap, commentFilter{by: "bob", file: "main.go"})
+	if len(got) != 1 || got[0].Body != "bob on main.go" {
+		t.Fatalf("expected only bob's reply on main.go, got %v", got)
+	}
+}
+
+func TestDetachOrphans(t *testing.T) {
+	rootID := uuid.Must(uuid.NewV7())
+	replyID := uuid.Must(uuid.NewV7())
+	nestedID := uuid.Must(uuid.NewV7())
+	comments := []db.Comment{
+		newComment(replyID, uuid.NullUUID{UUID: rootID, Valid: true}, "abc", "parent hidden", "bob", null.String{}, null.Int{}, null.Int{}),
+		newComment(nestedID, uuid.NullUUID{UUID: replyID, Valid: true}, "abc", "parent shown", "bob", null.String{}, null.Int{}, null.Int{}),
+	}
+	got := detachOrphans(comments)
+	if got[0].ParentID.Valid {
+		t.Error("reply whose parent is filtered out should become standalone")
+	}
+	if !got[1].ParentID.Valid {
+		t.Error("reply whose parent is shown should stay nested")
+	}
+	if !comments[0].ParentID.Valid {
+		t.Error("input should not be modified")
+	}
+}
+
 func TestFilterComments_ByFile(t *testing.T) {
 	id1 := uuid.Must(uuid.NewV7())
 	id2 := uuid.Must(uuid.NewV7())
@@ -80,7 +139,7 @@ func TestFilterComments_ByFile(t *testing.T) {
 		newComment(id2, uuid.NullUUID{}, "abc", "general", "", null.String{}, null.Int{}, null.Int{}),
 	}
 	idMap := buildIDMap(comments)
-	got := filterComments(comments, nil, idMap, "", false, "", "main.go")
+	got := filterComments(comments, nil, idMap, commentFilter{file: "main.go"})
 	if len(got) != 1 {
 		t.Fatalf("expected 1 comment, got %d", len(got))
 	}
@@ -99,7 +158,7 @@ func TestFilterComments_IncludesDescendantsOfMatchingRoot(t *testing.T) {
 		newComment(otherID, uuid.NullUUID{}, "abc", "other root", "charlie", null.String{}, null.Int{}, null.Int{}),
 	}
 	idMap := buildIDMap(comments)
-	got := filterComments(comments, nil, idMap, "", false, "alice", "")
+	got := filterComments(comments, nil, idMap, commentFilter{creator: "alice"})
 	if len(got) != 2 {
 		t.Fatalf("expected 2 (root + reply), got %d", len(got))
 	}
@@ -112,7 +171,7 @@ func TestFilterComments_NoMatchingCommit(t *testing.T) {
 	}
 	commits := []db.Commit{{Sha: "abc"}}
 	idMap := buildIDMap(comments)
-	got := filterComments(comments, commits, idMap, "zzz", false, "", "")
+	got := filterComments(comments, commits, idMap, commentFilter{commit: "zzz"})
 	if got != nil {
 		t.Errorf("expected nil, got %d comments", len(got))
 	}
@@ -128,7 +187,7 @@ func TestFilterComments_CombinedFilters(t *testing.T) {
 		newComment(id3, uuid.NullUUID{}, "abc", "wrong file", "alice", null.StringFrom("other.go"), null.Int{}, null.Int{}),
 	}
 	idMap := buildIDMap(comments)
-	got := filterComments(comments, nil, idMap, "", false, "alice", "main.go")
+	got := filterComments(comments, nil, idMap, commentFilter{creator: "alice", file: "main.go"})
 	if len(got) != 1 {
 		t.Fatalf("expected 1 comment, got %d", len(got))
 	}
@@ -137,6 +196,44 @@ func TestFilterComments_CombinedFilters(t *testing.T) {
 	}
 }
 
+func TestFilterComments_ByNeedsResponse(t *testing.T) {
+	root1 := uuid.Must(uuid.NewV7())
+	root2 := uuid.Must(uuid.NewV7())
+	reply := uuid.Must(uuid.NewV7())
+	comments := []db.Comment{
+		{ID: root1, Commit: null.StringFrom("abc"), Body: "note"},
+		{ID: reply, ParentID: uuid.NullUUID{UUID: root1, Valid: true}, Commit: null.StringFrom("abc"), Body: "why?", NeedsResponse: true},
+		{ID: root2, Commit: null.StringFrom("abc"), Body: "another note"},
+	}
+	idMap := buildIDMap(comments)
+	got := filterComments(comments, nil, idMap, commentFilter{needsResponse: true})
+	if len(got) != 2 {
+		t.Fatalf("expected question thread (2 comments), got %d", len(got))
+	}
+	if got[0].Body != "note" || got[1].Body != "why?" {
+		t.Errorf("got %q, %q", got[0].Body, got[1].Body)
+	}
+}
+
+func TestFilterComments_ReviewedOnly(t *testing.T) {
+	root := uuid.Must(uuid.NewV7())
+	comments := []db.Comment{
+		{ID: root, Commit: null.StringFrom("abc"), Body: "reviewed"},
+		{ID: uuid.Must(uuid.NewV7()), ParentID: uuid.NullUUID{UUID: root, Valid: true}, Commit: null.StringFrom("abc"), Body: "reply"},
+		{ID: uuid.Must(uuid.NewV7()), Commit: null.StringFrom("def"), Body: "ahead"},
+		{ID: uuid.Must(uuid.NewV7()), Body: "summary"},
+	}
+	commits := []db.Commit{{Sha: "abc", Position: 0}, {Sha: "def", Position: 1}}
+	idMap := buildIDMap(comments)
+	got := filterComments(comments, commits, idMap, commentFilter{reviewedOnly: true, reviewedUpTo: 0})
+	if len(got) != 2 || got[0].Body != "reviewed" || got[1].Body != "reply" {
+		t.Errorf("expected the thread on the first commit, got %v", got)
+	}
+	if got := filterComments(comments, commits, idMap, commentFilter{reviewedOnly: true, reviewedUpTo: -1}); len(got) != 0 {
+		t.Errorf("expected nothing before the first commit, got %v", got)
+	}
+}
+
 func TestDescendants_BuildsTree(t *testing.T) {
 	root := uuid.Must(uuid.NewV7())
 	child1 := uuid.Must(uuid.NewV7())
@@ -174,3 +271,94 @@ func TestFindRoot_WalksUpChain(t *testing.T) {
 		t.Errorf("expected root %s, got %s", root, found.ID)
 	}
 }
+
+func TestSortSection_NewestFirst(t *testing.T) {
+	older := newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "abc", "older", "", null.String{}, null.Int{}, null.Int{})
+	newer := newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "abc", "newer", "", null.String{}, null.Int{}, null.Int{})
+	aOld := newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "abc", "a old", "", null.StringFrom("a.go"), null.IntFrom(1), null.IntFrom(1))
+	bNew := newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "abc", "b new", "", null.StringFrom("b.go"), null.IntFrom(1), null.IntFrom(1))
+
+	general := []db.Comment{older, newer}
+	files := []fileComments{{file: "a.go", comments: []db.Comment{aOld}}, {file: "b.go", comments: []db.Comment{bNew}}}
+	sortSection(general, files, "-created")
+
+	if general[0].Body != "newer" {
+		t.Errorf("general[0] = %q, want newer", general[0].Body)
+	}
+	if files[0].file != "b.go" {
+		t.Errorf("files[0] = %q, want b.go (newest comment first)", files[0].file)
+	}
+}
+
+func TestSortSection_ByFileThenLine(t *testing.T) {
+	b := newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "abc", "b", "", null.StringFrom("b.go"), null.IntFrom(1), null.IntFrom(1))
+	a20 := newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "abc", "a20", "", null.StringFrom("a.go"), null.IntFrom(20), null.IntFrom(20))
+	a5 := newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{}, "abc", "a5", "", null.StringFrom("a.go"), null.IntFrom(5), null.IntFrom(5))
+
+	files := []fileComments{{file: "b.go", comments: []db.Comment{b}}, {file: "a.go", comments: []db.Comment{a20, a5}}}
+	sortSection(nil, files, "file")
+
+	if files[0].file != "a.go" || files[0].comments[0].Body != "a5" {
+		t.Errorf("got %q first with %q, want a.go with a5", files[0].file, files[0].comments[0].Body)
+	}
+}
+
+func TestFormatComment_ColorsRootsByResolution(t *testing.T) {
+	rootID := uuid.Must(uuid.NewV7())
+	resolvedID := uuid.Must(uuid.NewV7())
+	replyID := uuid.Must(uuid.NewV7())
+	open := newComment(rootID, uuid.NullUUID{}, "abc", "open", "", null.String{}, null.Int{}, null.Int{})
+	resolved := newComment(resolvedID, uuid.NullUUID{}, "abc", "done", "", null.String{}, null.Int{}, null.Int{})
+	resolved.ResolvedAt = null.StringFrom("2024-01-01T00:00:00Z")
+	reply := newComment(replyID, uuid.NullUUID{UUID: rootID, Valid: true}, "abc", "reply", "", null.String{}, null.Int{}, null.Int{})
+
+	p := threadPrinter{out: &output.Output{Color: true}}
+	if got := p.formatComment(open, "abc", ""); !strings.HasPrefix(got, "\033[0;33m") {
+		t.Errorf("open root should be yellow, got %q", got)
+	}
+	if got := p.formatComment(resolved, "abc", ""); !strings.HasPrefix(got, "\033[0;32m") {
+		t.Errorf("resolved root should be green, got %q", got)
+	}
+	if got := p.formatComment(reply, "abc", ""); strings.Contains(got, "\033[") {
+		t.Errorf("reply should not be colored, got %q", got)
+	}
+
+	p.out.Color = false
+	if got := p.formatComment(open, "abc", ""); strings.Contains(got, "\033[") {
+		t.Errorf("no color expected without Color, got %q", got)
+	}
+}
+
+func TestPageThreads(t *testing.T) {
+	var comments []db.Comment
+	var roots []uuid.UUID
+	for i := range 5 {
+		id := uuid.Must(uuid.NewV7())
+		roots = append(roots, id)
+		comments = append(comments, newComment(id, uuid.NullUUID{}, "abc", fmt.Sprintf("root %d", i), "", null.String{}, null.Int{}, null.Int{}))
+	}
+	// A reply belongs to its root's page and is not counted as a thread
+	comments = append(comments, newComment(uuid.Must(uuid.NewV7()), uuid.NullUUID{UUID: roots[2], Valid: true}, "abc", "reply", "", null.String{}, null.Int{}, null.Int{}))
+	// Pages follow ID order, not input order
+	slices.Reverse(comments)
+
+	got, pg := pageThreads(comments, 1, 2)
+	if pg.String() != "Showing 2-3 of 5 threads." {
+		t.Errorf("footer = %q", pg)
+	}
+	var bodies []string
+	for _, c := range got {
+		bodies = append(bodies, c.Body)
+	}
+	slices.Sort(bodies)
+	if strings.Join(bodies, ",") != "reply,root 1,root 2" {
+		t.Errorf("page = %v, want root 1, root 2 and the reply", bodies)
+	}
+
+	if got, pg := pageThreads(comments, 4, 10); len(got) != 1 || pg.String() != "Showing 5-5 of 5 threads." {
+		t.Errorf("last page = %d comments, %q", len(got), pg)
+	}
+	if got, pg := pageThreads(comments, 9, 2); len(got) != 0 || pg.String() != "No threads on this page (5 in total)." {
+		t.Errorf("past the end = %d comments, %q", len(got), pg)
+	}
+}
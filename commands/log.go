@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/newmo-oss/ergo"
+)
+
+type LogCmd struct {
+	Creator string `help:"Show only what this author did: comments written and threads resolved or reopened." name:"creator"`
+	Since   string `help:"Show only activity from this date (YYYY-MM-DD) or time (RFC 3339) on." placeholder:"DATE"`
+	Until   string `help:"Show only activity up to the end of this date (YYYY-MM-DD), or up to this time (RFC 3339)." placeholder:"DATE"`
+}
+
+// logEvent is one entry of the feed: a comment made, or a change to one from its history.
+type logEvent struct {
+	at      time.Time
+	by      string
+	action  string
+	comment db.Comment
+}
+
+// Run prints who did what when across the whole review, oldest first, one line per event.
+func (c *LogCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	since, err := parseLogDate("--since", c.Since, false)
+	if err != nil {
+		return err
+	}
+	until, err := parseLogDate("--until", c.Until, true)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	q := repo.Queries()
+
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	comments, err := q.ListAllComments(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list comments")
+	}
+	changes, err := q.ListAllCommentHistory(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list comment history")
+	}
+	files, err := loadCommentFiles(ctx, q, comments)
+	if err != nil {
+		return err
+	}
+
+	// UUIDv7 IDs sort chronologically, and history is kept in order, so a stable sort by
+	// time keeps events within the same second in the order they happened
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID.String() < comments[j].ID.String() })
+	byID := buildIDMap(comments)
+	var events []logEvent
+	for _, cm := range comments {
+		action := "commented"
+		if cm.ParentID.Valid {
+			action = "replied"
+		}
+		events = append(events, logEvent{at: parseTimestamp(cm.CreatedAt), by: cm.CreatedBy, action: action, comment: cm})
+	}
+	for _, ch := range changes {
+		if cm, ok := byID[ch.CommentID.String()]; ok {
+			events = append(events, logEvent{at: parseTimestamp(ch.ChangedAt), by: ch.ChangedBy, action: ch.Action, comment: cm})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+
+	shown := 0
+	for _, e := range events {
+		if c.Creator != "" && e.by != c.Creator {
+			continue
+		}
+		if (!since.IsZero() && e.at.Before(since)) || (!until.IsZero() && e.at.After(until)) {
+			continue
+		}
+		where := "summary"
+		if e.comment.Commit.Valid {
+			where = fmt.Sprintf("%d/%d %s", findCommitPosition(commits, e.comment.Commit.String)+1, len(commits), internal.ShortSHA(e.comment.Commit.String))
+		}
+		out.Printf("%s  %-10s [%s] %s %s%s%s\n", e.at.Format(time.RFC3339), e.action, internal.ShortID(e.comment.ID),
+			where, fileLocation(e.comment, files), truncateBody(e.comment.Body, threadBodyWidth), authorSuffix(e.by))
+		shown++
+	}
+	if shown == 0 {
+		out.Info("No activity to show.")
+	}
+	return nil
+}
+
+// parseTimestamp parses a stored RFC 3339 timestamp; one that does not parse sorts first.
+func parseTimestamp(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+// parseLogDate parses a --since or --until value, either an RFC 3339 time or a date. A
+// date stands for its start, or with endOfDay for its last second, in local time.
+func parseLogDate(flag, s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, ergo.New(fmt.Sprintf("%s wants a date (YYYY-MM-DD) or an RFC 3339 time", flag), slog.String("value", s))
+	}
+	if endOfDay {
+		d = d.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return d, nil
+}
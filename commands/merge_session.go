@@ -0,0 +1,225 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/guregu/null/v6"
+	"github.com/newmo-oss/ergo"
+)
+
+type MergeSessionCmd struct {
+	Path string `arg:"" type:"existingfile" help:"Path to another review database to merge into the current review."`
+}
+
+// mergeResult summarizes a merge. Conflicts are human-readable descriptions of
+// comments that were skipped or reconciled.
+type mergeResult struct {
+	comments  int
+	reviewers int
+	conflicts []string
+}
+
+func (c *MergeSessionCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	src, err := repository.Open(c.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	sq := src.Queries()
+	srcReviewers, err := sq.ListReviewers(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list reviewers of the merged review", slog.String("path", c.Path))
+	}
+	srcComments, err := sq.ListAllComments(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list comments of the merged review", slog.String("path", c.Path))
+	}
+	srcFiles, err := loadCommentFiles(ctx, sq, srcComments)
+	if err != nil {
+		return ergo.Wrap(err, "failed to read the merged review", slog.String("path", c.Path))
+	}
+
+	var result mergeResult
+	if err := repo.WithTx(ctx, func(q *db.Queries) error {
+		result, err = mergeSession(ctx, q, srcReviewers, srcComments, srcFiles)
+		return err
+	}); err != nil {
+		return ergo.Wrap(err, "failed to merge review")
+	}
+
+	for _, conflict := range result.conflicts {
+		out.Warn(conflict)
+	}
+	out.Ok(fmt.Sprintf("Merged %d %s and %d %s",
+		result.comments, internal.Pluralize(result.comments, "comment", "comments"),
+		result.reviewers, internal.Pluralize(result.reviewers, "reviewer", "reviewers")))
+
+	return nil
+}
+
+// mergeSession imports reviewers and comments into the current review.
+// Comments are de-duplicated by ID and inserted parents first so threads stay intact.
+// Comments on commits outside the current review, or whose parent is missing, are skipped.
+// For comments present in both reviews, the most recent resolution wins: only resolving
+// is timestamped, so a resolved thread takes precedence over an unresolved one.
+func mergeSession(ctx context.Context, q *db.Queries, reviewers []db.Reviewer, comments []db.Comment, files commentFiles) (mergeResult, error) {
+	var result mergeResult
+
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return result, ergo.Wrap(err, "failed to list commits")
+	}
+	known := make(map[string]bool, len(commits))
+	for _, cm := range commits {
+		known[cm.Sha] = true
+	}
+
+	for _, r := range reviewers {
+		_, err := q.GetReviewer(ctx, r.Name)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return result, ergo.Wrap(err, "failed to get reviewer", slog.String("name", r.Name))
+		}
+		current := r.CurrentSha
+		if current.Valid && !known[current.String] {
+			current = null.String{}
+		}
+		if err := q.InsertReviewer(ctx, db.InsertReviewerParams{Name: r.Name, CurrentSha: current, Role: r.Role}); err != nil {
+			return result, ergo.Wrap(err, "failed to insert reviewer", slog.String("name", r.Name))
+		}
+		result.reviewers++
+	}
+
+	// Walk threads root-first so every parent is inserted before its replies.
+	childrenMap := buildChildrenMap(comments)
+	var queue []db.Comment
+	for _, cm := range comments {
+		if !cm.ParentID.Valid {
+			queue = append(queue, cm)
+		}
+	}
+	visited := map[string]bool{}
+	for len(queue) > 0 {
+		cm := queue[0]
+		queue = queue[1:]
+		id := cm.ID.String()
+		visited[id] = true
+
+		if cm.Commit.Valid && !known[cm.Commit.String] {
+			result.conflicts = append(result.conflicts, fmt.Sprintf(
+				"[%s] skipped: commit %s is not part of this review", internal.ShortID(cm.ID), internal.ShortSHA(cm.Commit.String)))
+			markSkipped(childrenMap, id, visited)
+			continue
+		}
+		if cm.AlsoCommit.Valid && !known[cm.AlsoCommit.String] {
+			result.conflicts = append(result.conflicts, fmt.Sprintf(
+				"[%s] dropped its reference to commit %s, which is not part of this review", internal.ShortID(cm.ID), internal.ShortSHA(cm.AlsoCommit.String)))
+			cm.AlsoCommit = null.String{}
+		}
+
+		existing, err := q.GetComment(ctx, cm.ID)
+		switch {
+		case err == nil:
+			if conflict, err := reconcileComment(ctx, q, existing, cm); err != nil {
+				return result, err
+			} else if conflict != "" {
+				result.conflicts = append(result.conflicts, conflict)
+			}
+		case errors.Is(err, sql.ErrNoRows):
+			if err := q.InsertComment(ctx, db.InsertCommentParams{
+				ID:            cm.ID,
+				ParentID:      cm.ParentID,
+				Commit:        cm.Commit,
+				File:          cm.File,
+				StartLine:     cm.StartLine,
+				EndLine:       cm.EndLine,
+				Body:          cm.Body,
+				ResolvedAt:    cm.ResolvedAt,
+				ResolvedBy:    cm.ResolvedBy,
+				CreatedAt:     cm.CreatedAt,
+				CreatedBy:     cm.CreatedBy,
+				NeedsResponse: cm.NeedsResponse,
+				Tree:          cm.Tree,
+				Severity:      cm.Severity,
+				AlsoCommit:    cm.AlsoCommit,
+				Fixup:         cm.Fixup,
+				Assignee:      cm.Assignee,
+				OnBase:        cm.OnBase,
+			}); err != nil {
+				return result, ergo.Wrap(err, "failed to insert comment", slog.String("comment_id", id))
+			}
+			if f := files[id]; len(f) > 1 {
+				if err := insertMoreFiles(ctx, q, cm.ID, f[1:]); err != nil {
+					return result, err
+				}
+			}
+			result.comments++
+		default:
+			return result, ergo.Wrap(err, "failed to get comment", slog.String("comment_id", id))
+		}
+
+		queue = append(queue, childrenMap[id]...)
+	}
+
+	for _, cm := range comments {
+		if !visited[cm.ID.String()] {
+			result.conflicts = append(result.conflicts, fmt.Sprintf(
+				"[%s] skipped: parent comment is missing", internal.ShortID(cm.ID)))
+		}
+	}
+
+	return result, nil
+}
+
+// reconcileComment merges the resolution state of a comment present in both reviews.
+// It returns a description of the conflict, or "" when both sides agree.
+func reconcileComment(ctx context.Context, q *db.Queries, existing, incoming db.Comment) (string, error) {
+	var notes []string
+	if existing.Body != incoming.Body {
+		notes = append(notes, "body differs, kept current")
+	}
+
+	switch {
+	case existing.ResolvedAt == incoming.ResolvedAt:
+	case incoming.ResolvedAt.Valid &&
+		(!existing.ResolvedAt.Valid || incoming.ResolvedAt.String > existing.ResolvedAt.String):
+		if err := resolveThread(ctx, q, existing, incoming.ResolvedBy.String, incoming.ResolvedAt.String); err != nil {
+			return "", err
+		}
+		notes = append(notes, "resolution differs, took the merged one")
+	default:
+		notes = append(notes, "resolution differs, kept current")
+	}
+
+	if len(notes) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("[%s] %s", internal.ShortID(existing.ID), strings.Join(notes, "; ")), nil
+}
+
+// markSkipped marks every descendant of id as visited so skipped threads are reported once.
+func markSkipped(childrenMap map[string][]db.Comment, id string, visited map[string]bool) {
+	for _, child := range childrenMap[id] {
+		visited[child.ID.String()] = true
+		markSkipped(childrenMap, child.ID.String(), visited)
+	}
+}
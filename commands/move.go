@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/guregu/null/v6"
+	"github.com/newmo-oss/ergo"
+)
+
+type MoveCmd struct {
+	ID       string `arg:"" help:"ID (or prefix) of the thread to move." completion:"ids"`
+	Commit   string `arg:"" help:"Hash (or prefix) of the reviewed commit to move it to." completion:"commits"`
+	RootOnly bool   `name:"root-only" help:"Move only the root comment, leaving replies on the original commit."`
+	Force    bool   `help:"Move a file comment even if the target commit does not change the file."`
+}
+
+func (c *MoveCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	q := repo.Queries()
+
+	root, err := findComment(ctx, q, c.ID)
+	if err != nil {
+		return err
+	}
+	if root.ParentID.Valid {
+		return ergo.New("only root comments can be moved; replies follow their thread", slog.String("comment_id", c.ID))
+	}
+	if !root.Commit.Valid {
+		return ergo.New(fmt.Sprintf("[%s] is a review summary, which is not on any commit", internal.ShortID(root.ID)))
+	}
+	if root.OnBase {
+		return ergo.New(fmt.Sprintf("[%s] is on the base, and base comments stay on the first commit", internal.ShortID(root.ID)))
+	}
+
+	target, err := findCommit(ctx, q, c.Commit)
+	if err != nil {
+		return err
+	}
+	if target.Sha == root.Commit.String {
+		return ergo.New(fmt.Sprintf("[%s] is already on commit %s", internal.ShortID(root.ID), internal.ShortSHA(target.Sha)))
+	}
+	if root.File.Valid && !c.Force {
+		if err := requireChangedFile(ctx, g, q, target.Sha, root.File.String); err != nil {
+			return err
+		}
+	}
+
+	// Record the target's current tree so the moved comments are not flagged as pre-amend
+	session, err := q.GetSession(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to get session")
+	}
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	var tree null.String
+	if t, ok := currentTrees(g, session, commits)[target.Sha]; ok {
+		tree = null.StringFrom(t)
+	}
+
+	moved := []db.Comment{root}
+	if !c.RootOnly {
+		all, err := q.ListAllComments(ctx)
+		if err != nil {
+			return ergo.Wrap(err, "failed to list comments")
+		}
+		// Cross-commit replies already live elsewhere on purpose; keep them there
+		for _, d := range descendants(buildChildrenMap(all), root.ID) {
+			if d.Commit == root.Commit {
+				moved = append(moved, d)
+			}
+		}
+	}
+
+	if err := repo.WithTx(ctx, func(q *db.Queries) error {
+		for _, cm := range moved {
+			if err := q.UpdateCommentCommit(ctx, db.UpdateCommentCommitParams{
+				Commit: null.StringFrom(target.Sha),
+				Tree:   tree,
+				ID:     cm.ID,
+			}); err != nil {
+				return ergo.Wrap(err, "failed to move comment", slog.String("comment_id", cm.ID.String()))
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Moved [%s] to %s %s", internal.ShortID(root.ID), internal.ShortSHA(target.Sha), target.Message)
+	if n := len(moved) - 1; n > 0 {
+		msg += fmt.Sprintf(" (with %d %s)", n, internal.Pluralize(n, "reply", "replies"))
+	}
+	out.Ok(msg)
+
+	return nil
+}
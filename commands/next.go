@@ -9,7 +9,10 @@ import (
 	"github.com/newmo-oss/ergo"
 )
 
-type NextCmd struct{}
+type NextCmd struct {
+	SkipCommented bool `name:"skip-commented" help:"Skip commits that already have comments, moving to the next uncommented one."`
+	AutoFinish    bool `name:"auto-finish" help:"Finish the review (as plain finish) when there is no commit left, instead of suggesting it. From a reviewer worktree it is only suggested."`
+}
 
 func (c *NextCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
 	if err := requireActive(repo); err != nil {
@@ -43,12 +46,41 @@ func (c *NextCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outpu
 		nextIdx = pos + 1
 	}
 
+	if c.SkipCommented && nextIdx < int64(total) {
+		comments, err := q.ListAllComments(ctx)
+		if err != nil {
+			return ergo.Wrap(err, "failed to list comments")
+		}
+		// Count the same way status does: every comment, replies included, on its commit
+		commentCount := map[string]int{}
+		for _, cm := range comments {
+			commentCount[cm.Commit.String]++
+		}
+		for nextIdx < int64(total) && commentCount[commits[nextIdx].Sha] > 0 {
+			nextIdx++
+		}
+		if nextIdx >= int64(total) {
+			out.Notef("\n")
+			out.Ok("All remaining commits have comments.")
+			out.Notef("\n")
+			out.Notef("  git review next      Step through them one by one\n")
+			out.Notef("  git review finish    Complete the review\n")
+			return nil
+		}
+	}
+
 	if nextIdx >= int64(total) {
-		out.Printf("\n")
+		out.Notef("\n")
 		out.Ok("All commits reviewed.")
-		out.Printf("\n")
-		out.Printf("  git review finish    Complete the review\n")
-		out.Printf("  git review list      View all comments\n")
+		if c.AutoFinish && g.Reviewer == "" {
+			return (&FinishCmd{}).Run(g, repo, out)
+		}
+		if c.AutoFinish {
+			out.Info("Not finishing from a reviewer worktree; run finish from the main worktree.")
+		}
+		out.Notef("\n")
+		out.Notef("  git review finish    Complete the review\n")
+		out.Notef("  git review list      View all comments\n")
 		return nil
 	}
 
@@ -58,11 +90,11 @@ func (c *NextCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outpu
 	}
 
 	oneline, _ := g.Oneline(target.Sha)
-	stat, _ := g.DiffStagedStat()
-	out.Printf("\n")
-	out.Printf("  %s [%d/%d] %s\n", out.Bold("→"), nextIdx+1, total, oneline)
+	stat := positionStat(g, repo, target)
+	out.Notef("\n")
+	out.Notef("  %s [%d/%d] %s\n", out.Bold("→"), nextIdx+1, total, oneline)
 	if stat != "" {
-		out.Printf("\n%s\n", stat)
+		out.Notef("\n%s\n", stat)
 	}
 
 	return nil
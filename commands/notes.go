@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/newmo-oss/ergo"
+)
+
+type NotesCmd struct {
+	Commit string `arg:"" optional:"" help:"Show the note on this commit only."`
+	Base   string `help:"Base ref of the branch (auto-detected like start if omitted)." placeholder:"REF"`
+	Ref    string `help:"Notes ref to read instead of the default (as git notes --ref)." placeholder:"REF"`
+}
+
+// Run shows the notes finished reviews left on the branch's commits, oldest first. It
+// needs no active review, so past feedback can be revisited after finish.
+func (c *NotesCmd) Run(g *git.Git, out *output.Output) error {
+	noted, err := g.NotedCommits(c.Ref)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list notes", slog.String("ref", c.Ref))
+	}
+
+	if c.Commit != "" {
+		sha, err := g.Run("rev-parse", "--verify", c.Commit+"^{commit}")
+		if err != nil {
+			return ergo.WithCode(
+				ergo.New("invalid ref", slog.String("ref", c.Commit)),
+				internal.ErrCodeInvalidRef)
+		}
+		if !noted[sha] {
+			return ergo.New(fmt.Sprintf("no review notes on %s", internal.ShortSHA(sha)))
+		}
+		return printNote(g, out, c.Ref, sha, "")
+	}
+
+	var base string
+	if c.Base != "" {
+		if base, err = g.Run("rev-parse", c.Base); err != nil {
+			return ergo.WithCode(
+				ergo.New("invalid ref", slog.String("ref", c.Base)),
+				internal.ErrCodeInvalidRef)
+		}
+	} else if _, base = detectBase(g, loadConfig(g).BaseBranches, "HEAD"); base == "" {
+		return ergo.WithCode(
+			ergo.New("Cannot detect base branch. Specify: git review notes --base <ref>, or configure: git review config baseBranches <branch>..."),
+			internal.ErrCodeInvalidRef)
+	}
+	commits, err := g.RevList(base + "..HEAD")
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits", slog.String("base", base))
+	}
+
+	shown := 0
+	for i, sha := range commits {
+		if !noted[sha] {
+			continue
+		}
+		if shown > 0 {
+			out.Printf("\n")
+		}
+		if err := printNote(g, out, c.Ref, sha, fmt.Sprintf("%d/%d ", i+1, len(commits))); err != nil {
+			return err
+		}
+		shown++
+	}
+	if shown == 0 {
+		out.Info(fmt.Sprintf("No review notes on the %d %s since %s.",
+			len(commits), internal.Pluralize(len(commits), "commit", "commits"), internal.ShortSHA(base)))
+	}
+	return nil
+}
+
+// printNote prints a commit's heading, as list does, followed by its note.
+func printNote(g *git.Git, out *output.Output, ref, sha, position string) error {
+	note, err := g.NotesShow(ref, sha)
+	if err != nil {
+		return ergo.Wrap(err, "failed to read note", slog.String("sha", sha))
+	}
+	subject, _ := g.Subject(sha)
+	out.Printf("## Commit %s%s: %s\n\n%s\n", position, internal.ShortSHA(sha), subject, note)
+	return nil
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/newmo-oss/ergo"
+)
+
+type OpenCmd struct {
+	ID   string `arg:"" optional:"" help:"ID (or prefix) of a file comment to open at." completion:"ids"`
+	File string `short:"f" help:"File to open when no comment ID is given." completion:"files"`
+	Line string `short:"l" help:"Line (or range; its start is used) to open --file at."`
+}
+
+// Run opens $VISUAL or $EDITOR on a file in this worktree, whose checkout holds the
+// reviewed commit's version of it, positioned with the common "+N file" convention.
+func (c *OpenCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	q := repo.Queries()
+
+	reviewer, err := q.GetReviewer(ctx, g.Reviewer)
+	if err != nil {
+		return ergo.Wrap(err, "failed to get reviewer")
+	}
+
+	var file, commit string
+	var line int64
+	if c.ID != "" {
+		if c.File != "" || c.Line != "" {
+			return ergo.New("give either a comment ID or --file/--line, not both")
+		}
+		cm, err := findComment(ctx, q, c.ID)
+		if err != nil {
+			return err
+		}
+		if !cm.File.Valid {
+			return ergo.New(fmt.Sprintf("comment [%s] is not on a file", internal.ShortID(cm.ID)))
+		}
+		file, commit, line = cm.File.String, cm.Commit.String, cm.StartLine.Int64
+	} else {
+		if c.File == "" {
+			return ergo.New("specify a comment ID, or a file with --file")
+		}
+		start, _, err := parseLineRange(c.Line)
+		if err != nil {
+			return err
+		}
+		file, commit, line = c.File, reviewer.CurrentSha.String, start.Int64
+	}
+
+	// The worktree shows the reviewer's current commit; another commit's version may differ
+	if commit != "" && reviewer.CurrentSha.Valid && commit != reviewer.CurrentSha.String {
+		out.Warn(fmt.Sprintf("the comment is on commit %s but this worktree shows %s; run 'git review jump %s' to see that version",
+			internal.ShortSHA(commit), internal.ShortSHA(reviewer.CurrentSha.String), internal.ShortSHA(commit)))
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		return ergo.New("No editor set. Set $EDITOR (or $VISUAL), e.g. export EDITOR=vim")
+	}
+
+	top, err := g.TopLevel()
+	if err != nil {
+		return ergo.Wrap(err, "failed to find the worktree root")
+	}
+	path := filepath.Join(top, file)
+	args := []string{path}
+	if line > 0 {
+		args = []string{fmt.Sprintf("+%d", line), path}
+	}
+	cmd := exec.Command("sh", append([]string{"-c", editor + ` "$@"`, editor}, args...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return ergo.Wrap(err, "editor failed", slog.String("editor", editor))
+	}
+	return nil
+}
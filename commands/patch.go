@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/newmo-oss/ergo"
+)
+
+type PatchCmd struct {
+	Unresolved bool `help:"Include only unresolved threads."`
+}
+
+// Run prints the reviewed commits as a diff annotated with the review: each commit's
+// general comments in a header, and each file comment as "# review:" lines right below
+// the line it is on. The result is a review artifact to read, not a patch to apply.
+func (c *PatchCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	q := repo.Queries()
+
+	session, err := q.GetSession(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to get session")
+	}
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	all, err := q.ListAllComments(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list comments")
+	}
+
+	comments := filterComments(all, commits, buildIDMap(all), commentFilter{unresolved: c.Unresolved})
+	childrenMap := buildChildrenMap(comments)
+
+	for _, cm := range commits {
+		var general []db.Comment
+		byFile := map[string][]db.Comment{}
+		for _, cc := range comments {
+			switch {
+			case cc.ParentID.Valid || cc.Commit.String != cm.Sha:
+			case cc.File.Valid:
+				byFile[cc.File.String] = append(byFile[cc.File.String], cc)
+			default:
+				general = append(general, cc)
+			}
+		}
+		if len(general) == 0 && len(byFile) == 0 {
+			continue
+		}
+
+		out.Printf("# Commit %d/%d %s %s\n", cm.Position+1, len(commits), internal.ShortSHA(cm.Sha), cm.Message)
+		for _, root := range general {
+			printReviewLines(out, root, childrenMap, "")
+		}
+		out.Printf("\n")
+
+		parent := diffParent(g, session, commits, cm.Sha)
+		for _, file := range slices.Sorted(maps.Keys(byFile)) {
+			diff, err := g.FileDiff(parent, cm.Sha, file)
+			if err != nil {
+				return ergo.Wrap(err, "failed to read diff", slog.String("file", file))
+			}
+			annotateDiff(out, diff, byFile[file], childrenMap)
+			out.Printf("\n")
+		}
+	}
+	return nil
+}
+
+var patchHunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)`)
+
+// annotateDiff prints a file's diff with each root comment's thread inserted after the
+// new-side line its range ends on. File-level comments follow the diff header, and
+// comments on lines the diff does not show come after the diff.
+func annotateDiff(out *output.Output, diff string, roots []db.Comment, childrenMap map[string][]db.Comment) {
+	atLine := map[int64][]db.Comment{}
+	var fileLevel []db.Comment
+	for _, root := range roots {
+		if !root.StartLine.Valid {
+			fileLevel = append(fileLevel, root)
+			continue
+		}
+		end := root.EndLine.ValueOr(root.StartLine.Int64)
+		atLine[end] = append(atLine[end], root)
+	}
+
+	inHunks := false
+	var newLine int64
+	for _, line := range strings.Split(diff, "\n") {
+		if m := patchHunkHeader.FindStringSubmatch(line); m != nil {
+			if !inHunks {
+				for _, root := range fileLevel {
+					printReviewLines(out, root, childrenMap, "")
+				}
+				inHunks = true
+			}
+			out.Printf("%s\n", line)
+			newLine, _ = strconv.ParseInt(m[1], 10, 64)
+			continue
+		}
+		out.Printf("%s\n", line)
+		if !inHunks || strings.HasPrefix(line, "-") || strings.HasPrefix(line, `\`) {
+			continue
+		}
+		for _, root := range atLine[newLine] {
+			printReviewLines(out, root, childrenMap, "")
+		}
+		delete(atLine, newLine)
+		newLine++
+	}
+
+	if !inHunks {
+		for _, root := range fileLevel {
+			printReviewLines(out, root, childrenMap, "")
+		}
+	}
+	for _, l := range slices.Sorted(maps.Keys(atLine)) {
+		for _, root := range atLine[l] {
+			printReviewLines(out, root, childrenMap, fmt.Sprintf("(L%s, outside the diff) ", internal.FormatLineRange(root.StartLine, root.EndLine)))
+		}
+	}
+}
+
+// printReviewLines prints a thread as "# review:" comment lines, replies indented
+// under their parent. Continuation lines of a multi-line body keep the indentation.
+func printReviewLines(out *output.Output, root db.Comment, childrenMap map[string][]db.Comment, prefix string) {
+	var walk func(c db.Comment, depth int)
+	walk = func(c db.Comment, depth int) {
+		indent := strings.Repeat("  ", depth)
+		head := fmt.Sprintf("# review: %s[%s]%s ", indent, internal.ShortID(c.ID), authorSuffix(c.CreatedBy))
+		if depth == 0 {
+			head += prefix
+		}
+		body := strings.Split(c.Body, "\n")
+		out.Printf("%s%s%s\n", head, body[0], resolvedTag(c))
+		for _, more := range body[1:] {
+			out.Printf("# review: %s  %s\n", indent, more)
+		}
+		for _, child := range childrenMap[c.ID.String()] {
+			walk(child, depth+1)
+		}
+	}
+	walk(root, 0)
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/newmo-oss/ergo"
+)
+
+type ReorderCmd struct {
+	Commits []string `arg:"" help:"Every reviewed commit hash (or prefix), in the order to review them." completion:"commits"`
+}
+
+// Run changes the order next and list walk the commits in. The git history is untouched:
+// each commit is still shown as the diff against its real parent.
+func (c *ReorderCmd) Run(repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	q := repo.Queries()
+
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	if len(c.Commits) != len(commits) {
+		return ergo.New(fmt.Sprintf("reorder needs all %d reviewed commits, got %d", len(commits), len(c.Commits)))
+	}
+
+	order := make([]db.Commit, 0, len(c.Commits))
+	seen := map[string]bool{}
+	for _, prefix := range c.Commits {
+		cm, err := findCommit(ctx, q, prefix)
+		if err != nil {
+			return err
+		}
+		if seen[cm.Sha] {
+			return ergo.New(fmt.Sprintf("commit %s is listed more than once", internal.ShortSHA(cm.Sha)), slog.String("sha", cm.Sha))
+		}
+		seen[cm.Sha] = true
+		order = append(order, cm)
+	}
+
+	if err := repo.WithTx(ctx, func(q *db.Queries) error {
+		// Positions are unique, so park every commit on a negative one before assigning the new order
+		for i, cm := range order {
+			if err := q.UpdateCommitPosition(ctx, db.UpdateCommitPositionParams{Position: -int64(i) - 1, Sha: cm.Sha}); err != nil {
+				return ergo.Wrap(err, "failed to reorder commits", slog.String("sha", cm.Sha))
+			}
+		}
+		for i, cm := range order {
+			if err := q.UpdateCommitPosition(ctx, db.UpdateCommitPositionParams{Position: int64(i), Sha: cm.Sha}); err != nil {
+				return ergo.Wrap(err, "failed to reorder commits", slog.String("sha", cm.Sha))
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	out.Ok("Review order updated:")
+	for i, cm := range order {
+		out.Notef("  %d. %s %s\n", i+1, internal.ShortSHA(cm.Sha), cm.Message)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/newmo-oss/ergo"
+)
+
+type RepliesCmd struct {
+	ID string `arg:"" help:"ID (or prefix) of the comment whose replies to show." completion:"ids"`
+}
+
+// Run prints every reply below a comment, oldest first, without the comments above it,
+// to follow one sub-discussion of a long thread.
+func (c *RepliesCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	q := repo.Queries()
+
+	links, err := loadIssueLinker(g)
+	if err != nil {
+		return err
+	}
+	comment, err := findComment(ctx, q, c.ID)
+	if err != nil {
+		return err
+	}
+
+	session, err := q.GetSession(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to get session")
+	}
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	allComments, err := q.ListAllComments(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to load comments")
+	}
+	roles, err := loadReviewerRoles(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	p := threadPrinter{
+		out:         out,
+		childrenMap: buildChildrenMap(allComments),
+		preAmend:    preAmendIDs(allComments, currentTrees(g, session, commits)),
+		links:       links,
+		roles:       roles,
+	}
+	replies := descendants(p.childrenMap, comment.ID)
+	if len(replies) == 0 {
+		out.Printf("No replies to [%s]\n", internal.ShortID(comment.ID))
+		return nil
+	}
+	for _, r := range replies {
+		p.printCommentLine(r, comment.Commit.String, "")
+	}
+	return nil
+}
@@ -1,10 +1,17 @@
 package commands
 
 import (
+	"bufio"
+	"cmp"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/FujishigeTemma/git-review/internal"
@@ -12,13 +19,19 @@ import (
 	"github.com/FujishigeTemma/git-review/internal/git"
 	"github.com/FujishigeTemma/git-review/internal/output"
 	"github.com/FujishigeTemma/git-review/internal/repository"
-	"github.com/guregu/null/v6"
 	"github.com/newmo-oss/ergo"
+	"golang.org/x/term"
 )
 
 type ResolveCmd struct {
-	ID   string `arg:"" help:"ID (or prefix) of the thread to resolve."`
-	Name string `short:"a" help:"Who resolved it (default: worktree name)."`
+	IDs         []string `arg:"" optional:"" name:"id" help:"IDs (or prefixes) of the threads to resolve." completion:"ids" json:"ids"`
+	Name        string   `short:"a" help:"Who resolved it (default: $GIT_REVIEW_AUTHOR, else worktree name)."`
+	Interactive bool     `short:"i" help:"Walk unresolved threads, choosing to resolve, skip, or reply to each."`
+	Creator     string   `help:"Resolve every open thread started by this author."`
+	Commit      string   `help:"Resolve every open thread on this commit (hash prefix); combines with --creator." completion:"commits"`
+
+	// ID is the single "id" taken over --serve-stdio, as before several could be given.
+	ID string `kong:"-" json:"id"`
 }
 
 func (c *ResolveCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
@@ -27,36 +40,337 @@ func (c *ResolveCmd) Run(g *git.Git, repo *repository.Repository, out *output.Ou
 	}
 
 	ctx := context.Background()
-	q := repo.Queries()
 
+	if c.ID != "" {
+		c.IDs = append(c.IDs, c.ID)
+	}
 	name := c.Name
 	if name == "" {
-		name = g.Reviewer
+		name = defaultAuthor(g)
 	}
 
-	comment, err := q.FindCommentByPrefix(ctx, sql.NullString{String: c.ID, Valid: true})
-	if err != nil {
-		return ergo.New("comment not found", slog.String("comment_id", c.ID))
+	if c.Interactive {
+		if len(c.IDs) > 0 || c.Creator != "" || c.Commit != "" {
+			return ergo.New("--interactive cannot be combined with a comment ID, --creator or --commit")
+		}
+		return resolveInteractive(ctx, g, repo, out, name)
 	}
+	if c.Creator != "" || c.Commit != "" {
+		if len(c.IDs) > 0 {
+			return ergo.New("a comment ID cannot be combined with --creator or --commit", slog.String("comment_id", c.IDs[0]))
+		}
+		return c.resolveBatch(repo, out, g.Reviewer, name)
+	}
+	if len(c.IDs) == 0 {
+		return ergo.New("specify a comment ID, --creator, --commit, or --interactive")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var resolved []db.Comment
+	var failed int
+	if err := repo.WithTx(ctx, func(q *db.Queries) error {
+		var err error
+		resolved, failed, err = findComments(ctx, q, out, c.IDs, func(comment db.Comment) error {
+			if comment.ParentID.Valid {
+				return ergo.New("only root comments can be resolved", slog.String("comment_id", comment.ID.String()))
+			}
+			if comment.ResolvedAt.Valid {
+				return ergo.New("thread is already resolved")
+			}
+			return nil
+		})
+		if err != nil || len(resolved) == 0 {
+			return err
+		}
 
-	if comment.ParentID.Valid {
-		return ergo.New("only root comments can be resolved", slog.String("comment_id", c.ID))
+		rec, err := snapshotThreads(ctx, q, commentIDs(resolved)...)
+		if err != nil {
+			return err
+		}
+		for _, comment := range resolved {
+			if err := resolveThread(ctx, q, comment, name, now); err != nil {
+				return err
+			}
+		}
+		return logAction(ctx, q, g.Reviewer, name, "resolve", commentsSummary("resolve", resolved, "thread", "threads"), rec)
+	}); err != nil {
+		return err
 	}
 
-	if comment.ResolvedAt.Valid {
-		return ergo.New("thread is already resolved")
+	for _, comment := range resolved {
+		if out.Porcelain {
+			out.Record("resolved", comment.ID.String())
+		} else {
+			out.Ok(fmt.Sprintf("Resolved [%s]", internal.ShortID(comment.ID)))
+		}
 	}
+	if failed > 0 {
+		return batchFailed(failed, len(c.IDs), "threads could not be resolved")
+	}
+	return nil
+}
 
+// resolveBatch resolves every open root thread matching --creator and --commit
+// in a single transaction, logged for undo under the worktree's reviewer.
+func (c *ResolveCmd) resolveBatch(repo *repository.Repository, out *output.Output, reviewer, name string) error {
+	ctx := context.Background()
 	now := time.Now().UTC().Format(time.RFC3339)
-	if err := q.ResolveComment(ctx, db.ResolveCommentParams{
-		ResolvedAt: null.StringFrom(now),
-		ResolvedBy: null.StringFrom(name),
-		ID:         comment.ID,
+
+	var resolved []db.Comment
+	if err := repo.WithTx(ctx, func(q *db.Queries) error {
+		var commitSHA string
+		if c.Commit != "" {
+			target, err := findCommit(ctx, q, c.Commit)
+			if err != nil {
+				return err
+			}
+			commitSHA = target.Sha
+		}
+
+		roots, err := q.ListUnresolvedRoots(ctx)
+		if err != nil {
+			return ergo.Wrap(err, "failed to load comments")
+		}
+
+		var targets []db.Comment
+		for _, cm := range roots {
+			if c.Creator != "" && cm.CreatedBy != c.Creator {
+				continue
+			}
+			if commitSHA != "" && cm.Commit.String != commitSHA {
+				continue
+			}
+			targets = append(targets, cm)
+		}
+		if len(targets) == 0 {
+			return nil
+		}
+
+		rec, err := snapshotThreads(ctx, q, commentIDs(targets)...)
+		if err != nil {
+			return err
+		}
+		for _, cm := range targets {
+			if err := resolveThread(ctx, q, cm, name, now); err != nil {
+				return err
+			}
+		}
+		resolved = targets
+		return logAction(ctx, q, reviewer, name, "resolve", commentsSummary("resolve", targets, "thread", "threads"), rec)
 	}); err != nil {
-		return ergo.Wrap(err, "failed to resolve comment")
+		return err
 	}
 
-	out.Ok(fmt.Sprintf("Resolved [%s]", internal.ShortID(comment.ID)))
+	if out.Porcelain {
+		for _, cm := range resolved {
+			out.Record("resolved", cm.ID.String())
+		}
+		return nil
+	}
+	n := len(resolved)
+	out.Ok(fmt.Sprintf("Resolved %d %s", n, internal.Pluralize(n, "thread", "threads")))
 
 	return nil
 }
+
+// resolveInteractive walks unresolved threads in review order and prompts for each one:
+// resolve, skip, reply (via the git editor), or quit. Without a terminal it reads one
+// choice per line from stdin, so a walk can be scripted.
+func resolveInteractive(ctx context.Context, g *git.Git, repo *repository.Repository, out *output.Output, name string) error {
+	q := repo.Queries()
+
+	session, err := q.GetSession(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to get session")
+	}
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	comments, err := q.ListAllComments(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list comments")
+	}
+
+	var roots []db.Comment
+	for _, cm := range comments {
+		if !cm.ParentID.Valid && !cm.ResolvedAt.Valid {
+			roots = append(roots, cm)
+		}
+	}
+	if len(roots) == 0 {
+		out.Info("No unresolved threads.")
+		return nil
+	}
+	slices.SortFunc(roots, func(a, b db.Comment) int {
+		if c := cmp.Compare(findCommitPosition(commits, a.Commit.String), findCommitPosition(commits, b.Commit.String)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID.String(), b.ID.String())
+	})
+
+	p := threadPrinter{
+		out:         out,
+		childrenMap: buildChildrenMap(comments),
+		preAmend:    preAmendIDs(comments, currentTrees(g, session, commits)),
+	}
+	in := bufio.NewReader(out.Stdin)
+
+	// The tally ends a walk that stopped normally; after an error, the error is the last word
+	resolved := 0
+	tally := func() {
+		out.Printf("\n")
+		out.Ok(fmt.Sprintf("Resolved %d of %d %s", resolved, len(roots), internal.Pluralize(len(roots), "thread", "threads")))
+	}
+
+	for i, root := range roots {
+		if root.Commit.Valid {
+			pos := findCommitPosition(commits, root.Commit.String)
+			out.Printf("\n%s (%d/%d) commit %d/%d %s\n", out.Bold("──"), i+1, len(roots), pos+1, len(commits), internal.ShortSHA(root.Commit.String))
+		} else {
+			out.Printf("\n%s (%d/%d) review summary\n", out.Bold("──"), i+1, len(roots))
+		}
+	prompt:
+		for {
+			if root.File.Valid {
+				out.Printf("%s\n", root.File.String)
+				p.printFileThreadFlat(root, root.Commit.String)
+			} else {
+				p.printThreadFlat(root, root.Commit.String)
+			}
+			out.Printf("%s ", out.Bold("[r]esolve, [s]kip, re[p]ly, [q]uit?"))
+
+			key, err := readKey(out, in)
+			if err != nil {
+				tally() // EOF ends the walk
+				return nil
+			}
+			out.Printf("%c\n", key)
+
+			switch key {
+			case 'r':
+				// Others may have changed the thread while the prompt was waiting
+				var moved string
+				if err := whileLocked(g, out, func() error {
+					return repo.WithTx(ctx, func(q *db.Queries) error {
+						current, err := q.GetComment(ctx, root.ID)
+						if errors.Is(err, sql.ErrNoRows) {
+							moved = "was deleted"
+							return nil
+						}
+						if err != nil {
+							return ergo.Wrap(err, "failed to get comment", slog.String("comment_id", root.ID.String()))
+						}
+						if current.ResolvedAt.Valid {
+							moved = "was resolved by " + current.ResolvedBy.String
+							return nil
+						}
+						rec, err := snapshotThreads(ctx, q, root.ID)
+						if err != nil {
+							return err
+						}
+						if err := resolveThread(ctx, q, current, name, time.Now().UTC().Format(time.RFC3339)); err != nil {
+							return err
+						}
+						return logAction(ctx, q, g.Reviewer, name, "resolve", fmt.Sprintf("resolve of [%s]", internal.ShortID(root.ID)), rec)
+					})
+				}); err != nil {
+					return err
+				}
+				if moved != "" {
+					out.Warn(fmt.Sprintf("[%s] %s in the meantime", internal.ShortID(root.ID), moved))
+					break prompt
+				}
+				resolved++
+				out.Ok(fmt.Sprintf("Resolved [%s]", internal.ShortID(root.ID)))
+				break prompt
+			case 's', '\r', '\n':
+				break prompt
+			case 'p':
+				body, err := editReply(g)
+				if err != nil {
+					return err
+				}
+				if body == "" {
+					out.Warn("empty reply, nothing added")
+					continue
+				}
+				params := replyParams(root, body, name)
+				if err := whileLocked(g, out, func() error {
+					return saveComment(ctx, g, repo, params, nil, nil)
+				}); err != nil {
+					return err
+				}
+				comments, err = q.ListAllComments(ctx)
+				if err != nil {
+					return ergo.Wrap(err, "failed to list comments")
+				}
+				p.childrenMap = buildChildrenMap(comments)
+				out.Ok(fmt.Sprintf("[%s] %s", internal.ShortID(params.ID), body))
+			case 'q', 3: // 3 = Ctrl-C in raw mode
+				tally()
+				return nil
+			default:
+				out.Warn("unknown choice")
+			}
+		}
+	}
+	tally()
+	return nil
+}
+
+// readKey reads a single keystroke without waiting for Enter when stdin is a terminal,
+// and otherwise the first byte of the next line.
+func readKey(out *output.Output, in *bufio.Reader) (byte, error) {
+	if f, ok := out.Stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return 0, ergo.Wrap(err, "failed to read from terminal")
+		}
+		defer term.Restore(int(f.Fd()), state)
+		return in.ReadByte()
+	}
+	line, err := in.ReadString('\n')
+	if line == "" {
+		return 0, err
+	}
+	return line[0], nil
+}
+
+// editReply opens the git editor on a temporary file and returns the reply text.
+// Lines starting with '#' are dropped, as in commit messages.
+func editReply(g *git.Git) (string, error) {
+	editor, err := g.Editor()
+	if err != nil {
+		return "", ergo.Wrap(err, "failed to determine editor")
+	}
+
+	f, err := os.CreateTemp("", "git-review-reply-*.md")
+	if err != nil {
+		return "", ergo.Wrap(err, "failed to create reply file")
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("\n# Write your reply above. Lines starting with '#' are ignored; an empty reply is discarded.\n")
+	f.Close()
+	if err != nil {
+		return "", ergo.Wrap(err, "failed to write reply file")
+	}
+
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", ergo.Wrap(err, "editor failed", slog.String("editor", editor))
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", ergo.Wrap(err, "failed to read reply file")
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"encoding/json"
+
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/output"
+)
+
+// SARIF 2.1.0 output, the subset code-scanning viewers need.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+const sarifRuleID = "review-comment"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool                     sarifTool                 `json:"tool"`
+	Invocations              []sarifInvocation         `json:"invocations"`
+	VersionControlProvenance []sarifVersionControlInfo `json:"versionControlProvenance,omitempty"`
+	Results                  []sarifResult             `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Properties sarifProperties `json:"properties"`
+}
+
+type sarifVersionControlInfo struct {
+	RevisionID string `json:"revisionId"`
+	Branch     string `json:"branch"`
+}
+
+type sarifResult struct {
+	RuleID              string             `json:"ruleId"`
+	Level               string             `json:"level"`
+	Message             sarifMessage       `json:"message"`
+	Locations           []sarifLocation    `json:"locations"`
+	PartialFingerprints map[string]string  `json:"partialFingerprints"`
+	Suppressions        []sarifSuppression `json:"suppressions,omitempty"`
+	Properties          sarifProperties    `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int64 `json:"startLine"`
+	EndLine   int64 `json:"endLine,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// sarifProperties carries review context that SARIF has no field for.
+type sarifProperties struct {
+	CommentID string       `json:"commentId"`
+	Commit    string       `json:"commit,omitempty"` // empty for review summaries
+	Author    string       `json:"author,omitempty"`
+	Replies   []sarifReply `json:"replies,omitempty"`
+}
+
+type sarifReply struct {
+	Author string `json:"author,omitempty"`
+	Body   string `json:"body"`
+}
+
+// printSARIF writes the filtered threads as a SARIF log. File comments become results
+// located at their file and line range; general comments have no location and are
+// reported as notifications of the run instead. Resolved threads are marked suppressed.
+func printSARIF(out *output.Output, session db.Session, commits []db.Commit, comments []db.Comment, childrenMap map[string][]db.Comment) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "git-review",
+			InformationURI: "https://github.com/FujishigeTemma/git-review",
+			Rules:          []sarifRule{{ID: sarifRuleID, ShortDescription: sarifMessage{Text: "Review comment"}}},
+		}},
+		Invocations: []sarifInvocation{{ExecutionSuccessful: true}},
+		Results:     []sarifResult{},
+	}
+	if len(commits) > 0 {
+		run.VersionControlProvenance = []sarifVersionControlInfo{{
+			RevisionID: commits[len(commits)-1].Sha,
+			Branch:     session.Branch,
+		}}
+	}
+
+	for _, c := range comments {
+		if c.ParentID.Valid {
+			continue
+		}
+		props := sarifProperties{CommentID: c.ID.String(), Commit: c.Commit.String, Author: c.CreatedBy}
+		for _, r := range descendants(childrenMap, c.ID) {
+			props.Replies = append(props.Replies, sarifReply{Author: r.CreatedBy, Body: r.Body})
+		}
+
+		if !c.File.Valid {
+			run.Invocations[0].ToolExecutionNotifications = append(run.Invocations[0].ToolExecutionNotifications,
+				sarifNotification{Level: "note", Message: sarifMessage{Text: c.Body}, Properties: props})
+			continue
+		}
+
+		loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: c.File.String}}
+		if c.StartLine.Valid {
+			loc.Region = &sarifRegion{StartLine: c.StartLine.Int64}
+			if c.EndLine.Valid && c.EndLine.Int64 != c.StartLine.Int64 {
+				loc.Region.EndLine = c.EndLine.Int64
+			}
+		}
+		result := sarifResult{
+			RuleID:              sarifRuleID,
+			Level:               sarifLevel(c.Severity.String),
+			Message:             sarifMessage{Text: c.Body},
+			Locations:           []sarifLocation{{PhysicalLocation: loc}},
+			PartialFingerprints: map[string]string{"gitReviewCommentId": c.ID.String()},
+			Properties:          props,
+		}
+		if c.ResolvedAt.Valid {
+			justification := "resolved"
+			if c.ResolvedBy.Valid {
+				justification += " by " + c.ResolvedBy.String
+			}
+			result.Suppressions = []sarifSuppression{{Kind: "external", Justification: justification}}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	enc := json.NewEncoder(out.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// sarifLevel maps a thread severity to a SARIF result level; threads without one are warnings.
+func sarifLevel(severity string) string {
+	switch severity {
+	case "nit":
+		return "note"
+	case "blocker":
+		return "error"
+	default:
+		return "warning"
+	}
+}
@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/alecthomas/kong"
+	"github.com/newmo-oss/ergo"
+)
+
+// Standard JSON-RPC 2.0 error codes, plus the server-defined code used for command failures.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcCommandFailed  = -32000
+)
+
+// serveCommand is the Run signature shared by the commands exposed over --serve-stdio.
+type serveCommand interface {
+	Run(g *git.Git, repo *repository.Repository, out *output.Output) error
+}
+
+// serveMethods maps JSON-RPC method names to commands. Params are decoded into the
+// command struct by field name, e.g. {"message": "...", "file": "a.go", "replyTo": "..."};
+// fields they leave out keep the flag's default.
+var serveMethods = map[string]func() serveCommand{
+	"add":     func() serveCommand { return &AddCmd{} },
+	"list":    func() serveCommand { return &ListCmd{} },
+	"next":    func() serveCommand { return &NextCmd{} },
+	"resolve": func() serveCommand { return &ResolveCmd{} },
+	"state":   func() serveCommand { return &StateCmd{} },
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// commandResult is the result of every method except state, whose result is the state JSON itself.
+type commandResult struct {
+	Output string `json:"output"`
+}
+
+// ServeStdio answers JSON-RPC 2.0 requests read from in, writing one response per line to w,
+// until in reaches EOF. The review database stays open across requests; it is reopened
+// when a review is started or finished by another process in the meantime.
+func ServeStdio(g *git.Git, dbPath string, in io.Reader, w io.Writer) error {
+	s := &server{g: g, dbPath: dbPath}
+	defer s.closeRepo()
+
+	dec := json.NewDecoder(in)
+	enc := json.NewEncoder(w)
+	for {
+		var req rpcRequest
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			// The stream cannot be resynchronized after malformed JSON.
+			_ = enc.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+				Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+			return ergo.Wrap(err, "failed to read JSON-RPC request")
+		}
+
+		resp := s.handle(req)
+		if req.ID == nil {
+			continue // notification: no response
+		}
+		resp.JSONRPC, resp.ID = "2.0", req.ID
+		if err := enc.Encode(resp); err != nil {
+			return ergo.Wrap(err, "failed to write JSON-RPC response")
+		}
+	}
+}
+
+type server struct {
+	g      *git.Git
+	dbPath string
+	repo   *repository.Repository
+	opened os.FileInfo // the file repo was opened from
+}
+
+func (s *server) handle(req rpcRequest) rpcResponse {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcResponse{Error: &rpcError{Code: rpcInvalidRequest, Message: "expected a JSON-RPC 2.0 request with a method"}}
+	}
+	newCmd, ok := serveMethods[req.Method]
+	if !ok {
+		return rpcResponse{Error: &rpcError{Code: rpcMethodNotFound, Message: "unknown method: " + req.Method}}
+	}
+
+	cmd := newCmd()
+	if err := applyDefaults(cmd); err != nil {
+		return rpcResponse{Error: &rpcError{Code: rpcInvalidParams, Message: err.Error()}}
+	}
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		if err := json.Unmarshal(req.Params, cmd); err != nil {
+			return rpcResponse{Error: &rpcError{Code: rpcInvalidParams, Message: err.Error()}}
+		}
+	}
+
+	repo := s.openRepo()
+	if repo == nil {
+		if _, isState := cmd.(*StateCmd); !isState {
+			return rpcResponse{Error: &rpcError{Code: rpcCommandFailed, Message: "No review in progress. Start with: git review"}}
+		}
+	}
+
+	var buf bytes.Buffer
+	out := &output.Output{Stdin: strings.NewReader(""), Stdout: &buf, Stderr: os.Stderr}
+	if !ReadOnlyCommands[req.Method] {
+		unlock, err := LockReview(s.dbPath, out)
+		if err != nil {
+			return rpcResponse{Error: &rpcError{Code: rpcCommandFailed, Message: internal.UserMessage(err)}}
+		}
+		defer unlock()
+	}
+	// Diffs cached by an earlier request may predate an amend or rebase
+	s.g.ResetCache()
+	if err := cmd.Run(s.g, repo, out); err != nil {
+		return rpcResponse{Error: &rpcError{Code: rpcCommandFailed, Message: internal.UserMessage(err)}}
+	}
+
+	if _, isState := cmd.(*StateCmd); isState {
+		return rpcResponse{Result: json.RawMessage(bytes.TrimSpace(buf.Bytes()))}
+	}
+	return rpcResponse{Result: commandResult{Output: buf.String()}}
+}
+
+// applyDefaults sets the fields of cmd that have a default: tag, as parsing the command
+// line would, so that params need only carry what differs from it.
+func applyDefaults(cmd serveCommand) error {
+	parser, err := kong.New(cmd)
+	if err != nil {
+		return ergo.Wrap(err, "failed to build the command")
+	}
+	ctx, err := kong.Trace(parser, nil)
+	if err != nil {
+		return ergo.Wrap(err, "failed to build the command")
+	}
+	return ctx.ApplyDefaults()
+}
+
+// openRepo returns the open review database, opening it if a review has started since the
+// last request and dropping it if the review has ended. A review ended and started again
+// in between replaces the file, so the database is reopened when the file is not the one
+// that was opened. It returns nil when no review exists.
+func (s *server) openRepo() *repository.Repository {
+	info, err := os.Stat(s.dbPath)
+	if err != nil {
+		s.closeRepo()
+		return nil
+	}
+	if s.repo != nil && !os.SameFile(s.opened, info) {
+		s.closeRepo()
+	}
+	if s.repo == nil {
+		repo, err := repository.Open(s.dbPath)
+		if err != nil {
+			return nil
+		}
+		s.repo, s.opened = repo, info
+	}
+	return s.repo
+}
+
+func (s *server) closeRepo() {
+	if s.repo != nil {
+		s.repo.Close()
+		s.repo, s.opened = nil, nil
+	}
+}
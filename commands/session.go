@@ -2,15 +2,20 @@ package commands
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/FujishigeTemma/git-review/internal"
 	"github.com/FujishigeTemma/git-review/internal/db"
 	"github.com/FujishigeTemma/git-review/internal/git"
 	"github.com/FujishigeTemma/git-review/internal/output"
 	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/google/uuid"
 	"github.com/guregu/null/v6"
 	"github.com/newmo-oss/ergo"
 )
@@ -26,6 +31,55 @@ func requireMainWorktree(g *git.Git) error {
 	return nil
 }
 
+// authorEnv names the environment variable add and resolve attribute actions to when -a
+// is not given, so a CI job or agent can act as someone without passing -a every time.
+const authorEnv = "GIT_REVIEW_AUTHOR"
+
+// defaultAuthor returns who add and resolve act as without -a: $GIT_REVIEW_AUTHOR when
+// set, else the worktree's reviewer name. Positions and undo stay with the worktree.
+func defaultAuthor(g *git.Git) string {
+	if name := os.Getenv(authorEnv); name != "" {
+		return name
+	}
+	return g.Reviewer
+}
+
+// ReadOnlyCommands never change the review, so they run without taking the review lock.
+var ReadOnlyCommands = map[string]bool{
+	"list": true, "threads": true, "replies": true, "patch": true, "status": true, "stats": true,
+	"open": true, "history": true, "log": true, "state": true, "notes": true, "config": true, "whoami": true,
+	"__complete": true,
+}
+
+// ReviewDBPath returns the path of the review database, shared by every worktree.
+func ReviewDBPath(g *git.Git) string {
+	return filepath.Join(g.CommonDir, "review", "review.db")
+}
+
+// LockReview takes the review lock for a command that changes the review in the DB at
+// dbPath, waiting for any other such command to finish. Without a review directory there
+// is nothing to protect yet, and no lock is taken.
+func LockReview(dbPath string, out *output.Output) (func(), error) {
+	dir := filepath.Dir(dbPath)
+	if _, err := os.Stat(dir); err != nil {
+		return func() {}, nil
+	}
+	return repository.Lock(dir, func() {
+		out.Warn("another git-review command is changing the review; waiting for it to finish")
+	})
+}
+
+// whileLocked runs fn holding the review lock, for a command that does not hold it
+// throughout because it waits on the reviewer in between.
+func whileLocked(g *git.Git, out *output.Output, fn func() error) error {
+	unlock, err := LockReview(ReviewDBPath(g), out)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
+
 // requireActive checks that a review session exists.
 func requireActive(repo *repository.Repository) error {
 	count, err := repo.Queries().SessionExists(context.Background())
@@ -41,46 +95,108 @@ func requireActive(repo *repository.Repository) error {
 }
 
 // jumpTo performs the checkout-parent + read-tree-target dance and updates the reviewer position.
+// A review started with --annotate-only skips the dance and only moves the position.
 func jumpTo(g *git.Git, repo *repository.Repository, reviewerName string, target db.Commit) error {
 	ctx := context.Background()
 	q := repo.Queries()
 
-	// Determine parent: if position==0, use session.base_ref; else commits[position-1]
-	var parentRef string
-	if target.Position == 0 {
-		session, err := q.GetSession(ctx)
-		if err != nil {
-			return ergo.Wrap(err, "failed to get session")
+	session, err := q.GetSession(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to get session")
+	}
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	parentRef := diffParent(g, session, commits, target.Sha)
+
+	// A rebase or amend during the review leaves the stored SHAs dangling;
+	// report that clearly instead of letting read-tree fail with a raw git error.
+	for _, sha := range []string{parentRef, target.Sha} {
+		if !g.CommitExists(sha) {
+			return ergo.WithCode(
+				ergo.New("A reviewed commit no longer exists: the branch history changed during the review.\n  Run 'git review abort' and start a new review.",
+					slog.String("sha", sha)),
+				internal.ErrCodeStaleCommit)
 		}
-		parentRef = session.BaseRef
-	} else {
-		parent, err := q.GetCommitByPosition(ctx, target.Position-1)
-		if err != nil {
-			return ergo.Wrap(err, "failed to get parent commit")
+	}
+
+	// The index holds the previously viewed commit, which a plain checkout refuses to
+	// overwrite unless the parent is unchanged; it is review state, so discard it.
+	if !session.AnnotateOnly {
+		if err := g.CheckoutForce(parentRef); err != nil {
+			return ergo.Wrap(err, "failed to checkout parent")
+		}
+		if err := g.ReadTreeReset(target.Sha); err != nil {
+			return ergo.Wrap(err, "failed to read-tree target")
 		}
-		parentRef = parent.Sha
 	}
 
-	if err := g.Checkout(parentRef); err != nil {
-		return ergo.Wrap(err, "failed to checkout parent")
+	// Credit the commit being left with the time spent on it, and start the clock on the target
+	now := time.Now().UTC()
+	return repo.WithTx(ctx, func(q *db.Queries) error {
+		reviewer, err := q.GetReviewer(ctx, reviewerName)
+		if err != nil {
+			return ergo.Wrap(err, "failed to get reviewer")
+		}
+		if err := recordTimeSpent(ctx, q, reviewer, now); err != nil {
+			return err
+		}
+		if err := q.UpdateReviewerCurrent(ctx, db.UpdateReviewerCurrentParams{
+			CurrentSha: null.StringFrom(target.Sha),
+			EnteredAt:  null.StringFrom(now.Format(time.RFC3339)),
+			Name:       reviewerName,
+		}); err != nil {
+			return ergo.Wrap(err, "failed to update reviewer position")
+		}
+		return nil
+	})
+}
+
+// positionStat returns the diffstat of the commit jumpTo moved to: the staged changes, or
+// the commit's own diff when the review leaves the worktree alone.
+func positionStat(g *git.Git, repo *repository.Repository, target db.Commit) string {
+	ctx := context.Background()
+	q := repo.Queries()
+	session, err := q.GetSession(ctx)
+	if err != nil {
+		return ""
 	}
-	if err := g.ReadTreeReset(target.Sha); err != nil {
-		return ergo.Wrap(err, "failed to read-tree target")
+	if !session.AnnotateOnly {
+		stat, _ := g.DiffStagedStat()
+		return stat
 	}
-
-	if err := q.UpdateReviewerCurrent(ctx, db.UpdateReviewerCurrentParams{
-		CurrentSha: null.StringFrom(target.Sha),
-		Name:       reviewerName,
-	}); err != nil {
-		return ergo.Wrap(err, "failed to update reviewer position")
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ""
 	}
+	stat, _ := g.DiffStat(diffParent(g, session, commits, target.Sha), target.Sha)
+	return stat
+}
 
-	return nil
+// diffParent returns the commit jumpTo checks out to show sha as staged changes: its first
+// parent, so a merge shows what it brought into the branch. If git cannot tell, it is
+// sha's predecessor in history, not in review order, which 'git review reorder' may have
+// changed; the first commit diffs against the base.
+func diffParent(g *git.Git, session db.Session, commits []db.Commit, sha string) string {
+	if parents, err := g.Parents(sha); err == nil && len(parents) > 0 {
+		return parents[0]
+	}
+	history := historyOrder(g, session, commits)
+	for i := 1; i < len(history); i++ {
+		if history[i].Sha == sha {
+			return history[i-1].Sha
+		}
+	}
+	return session.BaseRef
 }
 
 // cleanupReview removes worktrees, checks out the original branch, closes the DB,
-// and removes the review directory. Shared by finish and abort.
-func cleanupReview(g *git.Git, repo *repository.Repository, out *output.Output, session db.Session) {
+// and removes the review directory. Shared by finish and abort. If the branch was
+// deleted or renamed during the review, the commit it pointed to at start is checked
+// out instead. Changes stashed by start --autostash are then restored. It returns a
+// description of where HEAD ended up.
+func cleanupReview(g *git.Git, repo *repository.Repository, out *output.Output, session db.Session) string {
 	ctx := context.Background()
 	q := repo.Queries()
 
@@ -102,8 +218,38 @@ func cleanupReview(g *git.Git, repo *repository.Repository, out *output.Output,
 		}
 	}
 
-	if err := g.CheckoutForce(session.Branch); err != nil {
-		out.Warn(fmt.Sprintf("failed to checkout %s: %v", session.Branch, err))
+	// start --branch reviewed another branch than the one to return to; with
+	// --annotate-only the worktree never left it
+	branch := session.ReturnBranch.ValueOr(session.Branch)
+	backOn := branch
+	if !session.AnnotateOnly {
+		if err := g.CheckoutForce(branch); err != nil {
+			problem := fmt.Sprintf("failed to checkout %s: %v", branch, err)
+			if !g.RefExists("refs/heads/" + branch) {
+				problem = fmt.Sprintf("branch %s no longer exists (deleted or renamed during the review)", branch)
+			}
+			if err := g.CheckoutDetached(session.HeadSha); err != nil {
+				out.Warn(fmt.Sprintf("%s, and checking out its original commit %s failed: %v",
+					problem, internal.ShortSHA(session.HeadSha), err))
+				backOn = ""
+			} else {
+				out.Warn(fmt.Sprintf("%s; checked out its original commit %s instead (detached HEAD)",
+					problem, internal.ShortSHA(session.HeadSha)))
+				backOn = internal.ShortSHA(session.HeadSha) + " (detached)"
+			}
+		}
+	}
+
+	if session.StashSha.Valid {
+		if backOn == "" {
+			out.Warn(fmt.Sprintf("not restoring your stashed changes; apply them with: git stash apply %s",
+				internal.ShortSHA(session.StashSha.String)))
+		} else if err := g.StashPop(session.StashSha.String); err != nil {
+			out.Warn(fmt.Sprintf("failed to restore your stashed changes (%v); they are kept in 'git stash list' as %s",
+				err, internal.ShortSHA(session.StashSha.String)))
+		} else {
+			out.Info("Restored the local changes stashed at start.")
+		}
 	}
 
 	repo.Close()
@@ -111,6 +257,221 @@ func cleanupReview(g *git.Git, repo *repository.Repository, out *output.Output,
 	if err := os.RemoveAll(reviewDir); err != nil {
 		out.Warn(fmt.Sprintf("failed to clean up review directory: %v", err))
 	}
+	return backOn
+}
+
+// branchCounterparts maps each reviewed commit SHA to its counterpart on the session branch
+// as it is now, so comments made before an amend or rebase can be detected. Counterparts
+// are matched by position when the commit count is unchanged, otherwise by subject when
+// that subject is unique on both sides; commits without a counterpart map to themselves.
+func branchCounterparts(g *git.Git, session db.Session, commits []db.Commit) map[string]string {
+	current := branchCommits(g, session, commits)
+	samePositions := len(current) == len(commits)
+
+	counterparts := make(map[string]string, len(commits))
+	if samePositions {
+		for i, cm := range historyOrder(g, session, commits) {
+			counterparts[cm.Sha] = current[i].SHA
+		}
+		return counterparts
+	}
+
+	reviewedSubjects := map[string]int{}
+	for _, cm := range commits {
+		reviewedSubjects[cm.Message]++
+	}
+	bySubject := map[string]string{}
+	currentSubjects := map[string]int{}
+	for _, entry := range current {
+		bySubject[entry.Subject] = entry.SHA
+		currentSubjects[entry.Subject]++
+	}
+	for _, cm := range commits {
+		counterparts[cm.Sha] = cm.Sha
+		if reviewedSubjects[cm.Message] == 1 && currentSubjects[cm.Message] == 1 {
+			counterparts[cm.Sha] = bySubject[cm.Message]
+		}
+	}
+	return counterparts
+}
+
+// branchCommits lists the session branch as start would now, oldest first: merges are
+// left out unless the review has some, and commits that were on the branch at start but
+// not reviewed, such as those skipped by --single, are dropped.
+func branchCommits(g *git.Git, session db.Session, commits []db.Commit) []git.LogEntry {
+	shas := make([]string, len(commits))
+	reviewed := make(map[string]bool, len(commits))
+	for i, cm := range commits {
+		shas[i] = cm.Sha
+		reviewed[cm.Sha] = true
+	}
+	var options []string
+	if len(shas) > 0 {
+		if merges, err := g.Merges(shas...); err == nil && len(merges) == 0 {
+			options = append(options, "--no-merges")
+		}
+	}
+
+	current, err := g.Log(session.BaseRef+"..refs/heads/"+session.Branch, options...)
+	if err != nil || !session.BranchSha.Valid {
+		return current
+	}
+	atStart, err := g.RevList(session.BaseRef+".."+session.BranchSha.String, options...)
+	if err != nil {
+		return current
+	}
+	skipped := map[string]bool{}
+	for _, sha := range atStart {
+		if !reviewed[sha] {
+			skipped[sha] = true
+		}
+	}
+	kept := current[:0]
+	for _, entry := range current {
+		if !skipped[entry.SHA] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// historyOrder returns the reviewed commits in git history order, oldest first. This is the
+// review order unless 'git review reorder' changed it; if git cannot tell, the review order
+// is kept.
+func historyOrder(g *git.Git, session db.Session, commits []db.Commit) []db.Commit {
+	shas := make([]string, len(commits))
+	bySHA := make(map[string]db.Commit, len(commits))
+	for i, cm := range commits {
+		shas[i] = cm.Sha
+		bySHA[cm.Sha] = cm
+	}
+	if len(shas) == 0 {
+		return commits
+	}
+	all, err := g.RevListTopo(session.BaseRef, shas...)
+	if err != nil {
+		return commits
+	}
+	ordered := make([]db.Commit, 0, len(commits))
+	for _, sha := range all {
+		if cm, ok := bySHA[sha]; ok {
+			ordered = append(ordered, cm)
+		}
+	}
+	if len(ordered) != len(commits) {
+		return commits
+	}
+	return ordered
+}
+
+// currentTrees maps each reviewed commit SHA to the tree of its branch counterpart.
+func currentTrees(g *git.Git, session db.Session, commits []db.Commit) map[string]string {
+	trees := make(map[string]string, len(commits))
+	for sha, counterpart := range branchCounterparts(g, session, commits) {
+		if tree, err := g.TreeSHA(counterpart); err == nil {
+			trees[sha] = tree
+		}
+	}
+	return trees
+}
+
+// preAmendIDs returns the IDs of comments recorded against a tree that no longer matches
+// the current version of their commit.
+func preAmendIDs(comments []db.Comment, trees map[string]string) map[string]bool {
+	ids := map[string]bool{}
+	for _, c := range comments {
+		tree, ok := trees[c.Commit.String]
+		if ok && c.Tree.Valid && c.Tree.String != tree {
+			ids[c.ID.String()] = true
+		}
+	}
+	return ids
+}
+
+// findComment resolves a comment ID prefix to exactly one comment. A prefix shared by several
+// comments is an error listing the shortest prefixes that tell them apart.
+func findComment(ctx context.Context, q *db.Queries, prefix string) (db.Comment, error) {
+	matches, err := q.FindCommentsByPrefix(ctx, sql.NullString{String: prefix, Valid: true})
+	if err != nil {
+		return db.Comment{}, ergo.Wrap(err, "failed to find comment", slog.String("comment_id", prefix))
+	}
+	switch len(matches) {
+	case 0:
+		return db.Comment{}, ergo.New("comment not found", slog.String("comment_id", prefix))
+	case 1:
+		return matches[0], nil
+	}
+	ids := make([]string, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ID.String()
+	}
+	return db.Comment{}, ergo.New(fmt.Sprintf("ambiguous comment ID prefix %q matches %d comments, be more specific: %s",
+		prefix, len(matches), strings.Join(internal.UniquePrefixes(ids, 8), ", ")),
+		slog.String("comment_id", prefix))
+}
+
+// findComments resolves the IDs given to a command that acts on several comments at once,
+// keeping those check accepts. With a single ID its error is returned as is; with several,
+// each ID that is not found or fails check is reported as a warning and counted in failed,
+// so the others can still be acted on. An ID repeated, or naming the same comment, is
+// taken once.
+func findComments(ctx context.Context, q *db.Queries, out *output.Output, ids []string, check func(db.Comment) error) (found []db.Comment, failed int, err error) {
+	seen := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		c, err := findComment(ctx, q, id)
+		if err == nil {
+			err = check(c)
+		}
+		if err != nil {
+			if len(ids) == 1 {
+				return nil, 0, err
+			}
+			out.Warn(fmt.Sprintf("%s: %s", id, internal.UserMessage(err)))
+			failed++
+			continue
+		}
+		if !seen[c.ID] {
+			seen[c.ID] = true
+			found = append(found, c)
+		}
+	}
+	return found, failed, nil
+}
+
+// batchFailed is the error of a command on several IDs when some of them failed, after
+// the rest were acted on and each failure was reported.
+func batchFailed(failed, total int, what string) error {
+	return ergo.New(fmt.Sprintf("%d of %d %s", failed, total, what))
+}
+
+// commentsSummary names the comments an action touched for the action log: a single one
+// by ID, otherwise how many there were.
+func commentsSummary(action string, comments []db.Comment, noun, plural string) string {
+	if len(comments) == 1 {
+		return fmt.Sprintf("%s of [%s]", action, internal.ShortID(comments[0].ID))
+	}
+	return fmt.Sprintf("%s of %d %s", action, len(comments), internal.Pluralize(len(comments), noun, plural))
+}
+
+// findCommit resolves a SHA prefix to exactly one reviewed commit, like findComment.
+func findCommit(ctx context.Context, q *db.Queries, prefix string) (db.Commit, error) {
+	matches, err := q.FindCommitsBySHAPrefix(ctx, sql.NullString{String: prefix, Valid: true})
+	if err != nil {
+		return db.Commit{}, ergo.Wrap(err, "failed to find commit", slog.String("hash", prefix))
+	}
+	switch len(matches) {
+	case 0:
+		return db.Commit{}, ergo.New("commit not found", slog.String("hash", prefix))
+	case 1:
+		return matches[0], nil
+	}
+	shas := make([]string, len(matches))
+	for i, m := range matches {
+		shas[i] = m.Sha
+	}
+	return db.Commit{}, ergo.New(fmt.Sprintf("ambiguous commit prefix %q matches %d commits, be more specific: %s",
+		prefix, len(matches), strings.Join(internal.UniquePrefixes(shas, 7), ", ")),
+		slog.String("hash", prefix))
 }
 
 // findCommitPosition returns the position of a commit with the given SHA, or -1 if not found.
@@ -0,0 +1,27 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/newmo-oss/ergo"
+)
+
+// severities lists the thread severities from least to most serious.
+var severities = []string{"nit", "minor", "major", "blocker"}
+
+// validateSeverity rejects names outside severities. The empty string means no severity.
+func validateSeverity(flag, s string) error {
+	if s == "" || slices.Contains(severities, s) {
+		return nil
+	}
+	return ergo.New(fmt.Sprintf("invalid %s %q: expected one of %s", flag, s, strings.Join(severities, ", ")))
+}
+
+// atOrBelow reports whether severity s is no more serious than limit.
+// Threads without a severity are never at or below a limit.
+func atOrBelow(s, limit string) bool {
+	i := slices.Index(severities, s)
+	return i >= 0 && i <= slices.Index(severities, limit)
+}
@@ -2,9 +2,13 @@ package commands
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/FujishigeTemma/git-review/internal"
@@ -12,17 +16,41 @@ import (
 	"github.com/FujishigeTemma/git-review/internal/git"
 	"github.com/FujishigeTemma/git-review/internal/output"
 	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/guregu/null/v6"
 	"github.com/newmo-oss/ergo"
 )
 
 type StartCmd struct {
-	Base string `arg:"" optional:"" help:"Base ref to review from (auto-detects if omitted)."`
-	Name string `short:"a" help:"Reviewer role name."`
+	Base      string `arg:"" optional:"" help:"Base ref to review from (auto-detects if omitted)."`
+	Name      string `short:"a" help:"Reviewer role name (default: review.defaultReviewer)."`
+	Role      string `help:"What this reviewer reviews for, e.g. security or perf; shown as @name(role)."`
+	Single    string `help:"Review only this commit (diffed against its parent) instead of base..HEAD."`
+	Branch    string `help:"Review this local branch (base..BRANCH) without switching to it for good; the current branch is checked out again on finish or abort." placeholder:"BRANCH"`
+	Autostash bool   `help:"Stash uncommitted changes before starting and restore them on finish or abort."`
+	Strict    bool   `help:"Refuse to start when the base ref is not an ancestor of HEAD, instead of warning."`
+	SinceTag  bool   `name:"since-tag" help:"Use the most recent tag reachable from HEAD, other than one on HEAD itself, as the base, for reviewing everything since the last release; falls back to base branch detection without tags."`
+
+	AnnotateOnly bool `name:"annotate-only" aliases:"no-read-tree" help:"Only track the position: next and jump never check out or stage commits, so the worktree is left as it is and diffs are read elsewhere."`
+
+	IncludeMerges bool `name:"include-merges" help:"Also review merge commits, each diffed against its first parent. By default they are skipped."`
 }
 
 func (c *StartCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
 	ctx := context.Background()
 
+	if c.Single != "" && c.Base != "" {
+		return ergo.New("--single cannot be combined with a base ref")
+	}
+	if c.Single != "" && c.Branch != "" {
+		return ergo.New("--single cannot be combined with --branch")
+	}
+	if c.SinceTag && (c.Base != "" || c.Single != "") {
+		return ergo.New("--since-tag cannot be combined with a base ref or --single")
+	}
+	if c.AnnotateOnly && c.Autostash {
+		return ergo.New("--annotate-only leaves local changes alone; --autostash is not needed")
+	}
+
 	// Check if a session already exists
 	count, err := repo.Queries().SessionExists(ctx)
 	if err != nil {
@@ -32,12 +60,24 @@ func (c *StartCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outp
 		if c.Name != "" {
 			return c.joinExistingSession(g, repo, out)
 		}
-		if c.Base != "" {
+		if c.Role != "" {
+			return ergo.New("--role needs -a <name> to join the review in progress")
+		}
+		if c.Base != "" || c.Single != "" || c.Branch != "" || c.SinceTag || c.AnnotateOnly {
 			return ergo.WithCode(
 				ergo.New("Review already in progress. Finish or abort first."),
 				internal.ErrCodeReviewActive)
 		}
-		return showStatus(g, repo, out)
+		if err := showStatus(g, repo, out); err != nil {
+			return err
+		}
+		return printResumeHint(g, repo, out)
+	}
+
+	// The configured reviewer stands in for -a when starting from the main worktree
+	cfg := loadConfig(g)
+	if c.Name == "" && g.Reviewer == "" {
+		c.Name = cfg.DefaultReviewer
 	}
 
 	currentBranch, err := g.CurrentBranch()
@@ -47,64 +87,79 @@ func (c *StartCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outp
 			internal.ErrCodeDetachedHead)
 	}
 
-	// Detect base
-	var base string
-	if c.Base != "" {
-		base, err = g.Run("rev-parse", c.Base)
-		if err != nil {
-			return ergo.WithCode(
-				ergo.New("invalid ref", slog.String("ref", c.Base)),
-				internal.ErrCodeInvalidRef)
-		}
-	} else {
-		for _, ref := range []string{"main", "master", "develop"} {
-			if g.RefExists(ref) {
-				base, err = g.MergeBase(ref, "HEAD")
-				if err != nil {
-					continue
-				}
-				oneline, _ := g.Oneline(base)
-				out.Info(fmt.Sprintf("Base: %s (%s)", ref, oneline))
-				break
-			}
-		}
-		if base == "" {
+	// With --branch the review is of another branch, and this one is where cleanup returns
+	reviewBranch, tip := currentBranch, "HEAD"
+	var returnBranch null.String
+	if c.Branch != "" && c.Branch != currentBranch {
+		if !g.RefExists("refs/heads/" + c.Branch) {
 			return ergo.WithCode(
-				ergo.New("Cannot detect base branch. Specify: git review <base-ref>"),
+				ergo.New(fmt.Sprintf("no local branch %s; create one first, e.g. git branch %s origin/%s", c.Branch, c.Branch, c.Branch)),
 				internal.ErrCodeInvalidRef)
 		}
+		reviewBranch, tip = c.Branch, "refs/heads/"+c.Branch
+		returnBranch = null.StringFrom(currentBranch)
 	}
 
-	commits, err := g.RevList(base + "..HEAD")
-	if err != nil || len(commits) == 0 {
-		return ergo.WithCode(
-			ergo.New("No commits to review between base and HEAD."),
-			internal.ErrCodeNoCommits)
+	var base string
+	var commits []string
+	if c.Single != "" {
+		base, commits, err = singleCommitRange(g, c.Single)
+	} else {
+		base, commits, err = c.branchRange(g, out, cfg.BaseBranches, tip)
+	}
+	if err != nil {
+		return err
 	}
 
 	nCommits := len(commits)
 
+	headSHA, err := g.Run("rev-parse", "HEAD")
+	if err != nil {
+		return ergo.Wrap(err, "failed to resolve HEAD")
+	}
+	// finish tells commits added to the branch since from those already there, which
+	// --single leaves out of the review
+	branchSHA, err := g.Run("rev-parse", tip)
+	if err != nil {
+		return ergo.Wrap(err, "failed to resolve the review branch", slog.String("branch", reviewBranch))
+	}
+
 	reviewerName := c.Name
 	if reviewerName == "" {
 		reviewerName = g.Reviewer
 	}
 
+	// Without -a the review checks commits out in this tree, which would clobber local changes
+	var stashSHA null.String
+	if c.Name == "" && !c.AnnotateOnly {
+		if stashSHA, err = protectLocalChanges(g, out, c.Autostash); err != nil {
+			return err
+		}
+	}
+
 	// Insert session, commits, and reviewer in a transaction
 	if err := repo.WithTx(ctx, func(q *db.Queries) error {
 		if err := q.InsertSession(ctx, db.InsertSessionParams{
-			BaseRef:   base,
-			Branch:    currentBranch,
-			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+			BaseRef:      base,
+			Branch:       reviewBranch,
+			CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+			HeadSha:      headSHA,
+			StashSha:     stashSHA,
+			ReturnBranch: returnBranch,
+			AnnotateOnly: c.AnnotateOnly,
+			BranchSha:    null.StringFrom(branchSHA),
 		}); err != nil {
 			return ergo.Wrap(err, "failed to insert session")
 		}
 
 		for i, sha := range commits {
 			msg, _ := g.Subject(sha)
+			author, err := g.Author(sha)
 			if err := q.InsertCommit(ctx, db.InsertCommitParams{
 				Sha:      sha,
 				Message:  msg,
 				Position: int64(i),
+				Author:   null.NewString(author, err == nil),
 			}); err != nil {
 				return ergo.Wrap(err, "failed to insert commit",
 					slog.String("sha", sha))
@@ -113,12 +168,14 @@ func (c *StartCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outp
 
 		if err := q.InsertReviewer(ctx, db.InsertReviewerParams{
 			Name: reviewerName,
+			Role: null.NewString(c.Role, c.Role != ""),
 		}); err != nil {
 			return ergo.Wrap(err, "failed to insert reviewer",
 				slog.String("name", reviewerName))
 		}
 		return nil
 	}); err != nil {
+		restoreAutostash(g, out, stashSHA)
 		return ergo.Wrap(err, "failed to initialize review")
 	}
 
@@ -126,8 +183,8 @@ func (c *StartCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outp
 	jumpGit := g
 	if c.Name != "" {
 		worktreePath := filepath.Join(g.CommonDir, "review", "worktrees", c.Name)
-		if err := g.WorktreeAdd(worktreePath); err != nil {
-			return ergo.Wrap(err, "failed to create worktree")
+		if err := ensureWorktree(g, worktreePath); err != nil {
+			return err
 		}
 		jumpGit = g.ForWorktree(c.Name, worktreePath)
 	}
@@ -142,46 +199,269 @@ func (c *StartCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outp
 	}
 
 	oneline, _ := g.Oneline(commits[0])
-	out.Printf("\n")
+	out.Notef("\n")
 	out.Ok(fmt.Sprintf("══ Review Started: %d commit(s) ══", nCommits))
-	out.Printf("\n")
-	out.Printf("  %s [1/%d] %s\n", out.Bold("→"), nCommits, oneline)
-	out.Printf("\n")
-	out.Printf("  Staged changes are ready for review.\n")
-	out.Printf("\n")
-	out.Printf("    git review add 'message'                Add comment\n")
-	out.Printf("    git review add -f file -l N 'message'   Add comment on file:line\n")
-	out.Printf("    git review next                         Next commit\n")
+	out.Notef("\n")
+	out.Notef("  %s [1/%d] %s\n", out.Bold("→"), nCommits, oneline)
+	out.Notef("\n")
+	if c.AnnotateOnly {
+		out.Notef("  Annotate-only: the worktree is left as it is; read the diff where you like.\n")
+	} else {
+		out.Notef("  Staged changes are ready for review.\n")
+	}
+	out.Notef("\n")
+	out.Notef("    git review add 'message'                Add comment\n")
+	out.Notef("    git review add -f file -l N 'message'   Add comment on file:line\n")
+	out.Notef("    git review next                         Next commit\n")
 
 	return nil
 }
 
+// branchRange resolves the base (explicit, or auto-detected from the first existing
+// candidate branch) and the base..tip commits to review, tip being HEAD unless --branch.
+func (c *StartCmd) branchRange(g *git.Git, out *output.Output, candidates []string, tip string) (string, []string, error) {
+	var base string
+	var err error
+	if c.Base != "" {
+		base, err = g.Run("rev-parse", c.Base)
+		if err != nil {
+			return "", nil, ergo.WithCode(
+				ergo.New("invalid ref", slog.String("ref", c.Base)),
+				internal.ErrCodeInvalidRef)
+		}
+		if err := c.checkBaseAncestry(g, out, base, tip); err != nil {
+			return "", nil, err
+		}
+	} else {
+		var ref string
+		if c.SinceTag {
+			ref, base = detectTagBase(g, tip)
+		}
+		if base == "" {
+			ref, base = detectBase(g, candidates, tip)
+		}
+		if base == "" {
+			return "", nil, ergo.WithCode(
+				ergo.New("Cannot detect base branch. Specify: git review <base-ref>, or configure: git review config baseBranches <branch>..."),
+				internal.ErrCodeInvalidRef)
+		}
+		oneline, _ := g.Oneline(base)
+		out.Info(fmt.Sprintf("Base: %s (%s)", ref, oneline))
+	}
+
+	// A merge has no single diff to show, so unless asked for, merges are left out
+	var options []string
+	if !c.IncludeMerges {
+		options = append(options, "--no-merges")
+		if merges, err := g.RevList(base+".."+tip, "--merges"); err == nil && len(merges) > 0 {
+			out.Info(fmt.Sprintf("Skipping %d merge %s; pass --include-merges to review %s against the first parent.",
+				len(merges), internal.Pluralize(len(merges), "commit", "commits"), internal.Pluralize(len(merges), "it", "them")))
+		}
+	}
+	commits, err := g.RevList(base+".."+tip, options...)
+	if err != nil || len(commits) == 0 {
+		return "", nil, ergo.WithCode(
+			ergo.New("No commits to review between base and HEAD."),
+			internal.ErrCodeNoCommits)
+	}
+	return base, commits, nil
+}
+
+// detectBase returns the first existing candidate branch and its merge base with tip,
+// or empty strings when none of them shares history with tip.
+func detectBase(g *git.Git, candidates []string, tip string) (ref, base string) {
+	for _, ref := range candidates {
+		if !g.RefExists(ref) {
+			continue
+		}
+		if base, err := g.MergeBase(ref, tip); err == nil {
+			return ref, base
+		}
+	}
+	return "", ""
+}
+
+// detectTagBase returns the most recent tag reachable from tip's parent and its commit, or
+// empty strings when there is none. A tag on tip itself, as when a release was just cut,
+// would leave nothing to review, so the tag before it is used.
+func detectTagBase(g *git.Git, tip string) (tag, base string) {
+	tag, err := g.Run("describe", "--tags", "--abbrev=0", tip+"^")
+	if err != nil {
+		return "", ""
+	}
+	if base, err = g.MergeBase(tag, tip); err != nil {
+		return "", ""
+	}
+	return tag, base
+}
+
+// protectLocalChanges refuses to start over uncommitted changes, or with --autostash
+// stashes them and returns the stash to record in the session for cleanup to restore.
+func protectLocalChanges(g *git.Git, out *output.Output, autostash bool) (null.String, error) {
+	clean, err := g.IsClean()
+	if err != nil {
+		return null.String{}, ergo.Wrap(err, "failed to check working tree")
+	}
+	if clean {
+		return null.String{}, nil
+	}
+	if !autostash {
+		return null.String{}, ergo.WithCode(
+			ergo.New("You have uncommitted changes, which the review would overwrite.\n  Commit or stash them, or rerun with --autostash to restore them when the review ends."),
+			internal.ErrCodeDirtyWorkDir)
+	}
+	sha, err := g.StashPush("git-review autostash")
+	if err != nil {
+		return null.String{}, ergo.Wrap(err, "failed to stash local changes")
+	}
+	out.Info(fmt.Sprintf("Stashed local changes (%s); they are restored on finish or abort.", internal.ShortSHA(sha)))
+	return null.StringFrom(sha), nil
+}
+
+// restoreAutostash gives back the changes protectLocalChanges stashed when the review
+// could not be saved, since no session would record the stash to restore it later.
+func restoreAutostash(g *git.Git, out *output.Output, sha null.String) {
+	if !sha.Valid {
+		return
+	}
+	if err := g.StashPop(sha.String); err != nil {
+		out.Warn(fmt.Sprintf("Your local changes are still stashed (%s); restore them with: git stash pop", internal.ShortSHA(sha.String)))
+	}
+}
+
+// checkBaseAncestry warns, or with --strict fails, when an explicit base is not an
+// ancestor of tip. The commit list would then include commits that are not part of the
+// branch since it forked, and a base with no shared history puts all of tip under review.
+func (c *StartCmd) checkBaseAncestry(g *git.Git, out *output.Output, base, tip string) error {
+	if g.IsAncestor(base, tip) {
+		return nil
+	}
+	name := strings.TrimPrefix(tip, "refs/heads/")
+	var msg string
+	if mb, err := g.MergeBase(base, tip); err == nil {
+		msg = fmt.Sprintf("%s is not an ancestor of %s, so the commits under review would include some that are not part of the branch since it forked.\n  Did you mean the merge-base? git review %s",
+			c.Base, name, internal.ShortSHA(mb))
+	} else {
+		msg = fmt.Sprintf("%s shares no history with %s, so every commit reachable from %s would be reviewed.", c.Base, name, name)
+	}
+	if c.Strict {
+		return ergo.WithCode(ergo.New(msg, slog.String("ref", c.Base)), internal.ErrCodeInvalidRef)
+	}
+	out.Warn(msg)
+	return nil
+}
+
+// singleCommitRange resolves one commit to review on its own, using its parent as the base
+// so that jumpTo diffs it against sha^.
+func singleCommitRange(g *git.Git, ref string) (string, []string, error) {
+	sha, err := g.Run("rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		return "", nil, ergo.WithCode(
+			ergo.New("invalid ref", slog.String("ref", ref)),
+			internal.ErrCodeInvalidRef)
+	}
+	parent, err := g.Run("rev-parse", "--verify", sha+"^")
+	if err != nil {
+		return "", nil, ergo.WithCode(
+			ergo.New("Cannot review a root commit with --single: it has no parent.", slog.String("sha", sha)),
+			internal.ErrCodeInvalidRef)
+	}
+	return parent, []string{sha}, nil
+}
+
+// printResumeHint tells a reviewer returning to a running review where they left off
+// and the command that continues from there.
+func printResumeHint(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	ctx := context.Background()
+	q := repo.Queries()
+
+	reviewer, err := q.GetReviewer(ctx, g.Reviewer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // not a reviewer in this review, e.g. the main worktree after start -a
+	}
+	if err != nil {
+		return ergo.Wrap(err, "failed to get reviewer")
+	}
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	total := len(commits)
+
+	pos := int64(-1)
+	if reviewer.CurrentSha.Valid {
+		pos = findCommitPosition(commits, reviewer.CurrentSha.String)
+	}
+	if pos < 0 {
+		out.Info("You have not started on a commit yet.")
+		out.Notef("\n")
+		out.Notef("  git review next      Start with commit 1/%d\n", total)
+		return nil
+	}
+
+	out.Info(fmt.Sprintf("You are on commit %d/%d: %s", pos+1, total, commits[pos].Message))
+	out.Notef("\n")
+	if session, err := q.GetSession(ctx); err == nil && !session.AnnotateOnly {
+		out.Notef("  git review jump %-4d Restore its changes in the worktree\n", pos+1)
+	}
+	if next := pos + 1; next < int64(total) {
+		out.Notef("  git review next      Continue with %d/%d: %s\n", next+1, total, commits[next].Message)
+	} else {
+		out.Notef("  git review finish    Complete the review (this is the last commit)\n")
+	}
+	return nil
+}
+
 // joinExistingSession adds a new reviewer to an existing session and creates a worktree.
+// Rejoining as an existing reviewer (e.g. after a crash) reuses the worktree and
+// returns to the recorded position instead of failing.
 func (c *StartCmd) joinExistingSession(g *git.Git, repo *repository.Repository, out *output.Output) error {
 	ctx := context.Background()
 	q := repo.Queries()
 
-	// Insert the new reviewer
-	if err := q.InsertReviewer(ctx, db.InsertReviewerParams{
-		Name: c.Name,
-	}); err != nil {
-		return ergo.Wrap(err, "failed to add reviewer")
+	reviewer, err := q.GetReviewer(ctx, c.Name)
+	rejoin := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ergo.Wrap(err, "failed to get reviewer", slog.String("name", c.Name))
+	}
+
+	if !rejoin {
+		if err := q.InsertReviewer(ctx, db.InsertReviewerParams{
+			Name: c.Name,
+			Role: null.NewString(c.Role, c.Role != ""),
+		}); err != nil {
+			return ergo.Wrap(err, "failed to add reviewer")
+		}
+	} else if c.Role != "" && c.Role != reviewer.Role.String {
+		// Rejoining with another --role changes it; without one the role is kept
+		if err := q.UpdateReviewerRole(ctx, db.UpdateReviewerRoleParams{
+			Role: null.StringFrom(c.Role),
+			Name: c.Name,
+		}); err != nil {
+			return ergo.Wrap(err, "failed to update reviewer role", slog.String("name", c.Name))
+		}
 	}
 
-	// Create worktree
 	worktreePath := filepath.Join(g.CommonDir, "review", "worktrees", c.Name)
-	if err := g.WorktreeAdd(worktreePath); err != nil {
-		return ergo.Wrap(err, "failed to create worktree")
+	if err := ensureWorktree(g, worktreePath); err != nil {
+		return err
 	}
 	jumpGit := g.ForWorktree(c.Name, worktreePath)
 
-	// Jump to the first commit
-	firstCommit, err := q.GetCommitByPosition(ctx, 0)
+	// Jump to the recorded position when rejoining, otherwise to the first commit
+	target, err := q.GetCommitByPosition(ctx, 0)
 	if err != nil {
 		return ergo.Wrap(err, "failed to get first commit")
 	}
-	if err := jumpTo(jumpGit, repo, c.Name, firstCommit); err != nil {
-		return ergo.Wrap(err, "failed to jump to first commit")
+	if rejoin && reviewer.CurrentSha.Valid {
+		target, err = q.GetCommitBySHA(ctx, reviewer.CurrentSha.String)
+		if err != nil {
+			return ergo.Wrap(err, "failed to get recorded commit",
+				slog.String("sha", reviewer.CurrentSha.String))
+		}
+	}
+	if err := jumpTo(jumpGit, repo, c.Name, target); err != nil {
+		return ergo.Wrap(err, "failed to jump to commit")
 	}
 
 	commits, err := q.ListCommits(ctx)
@@ -189,13 +469,45 @@ func (c *StartCmd) joinExistingSession(g *git.Git, repo *repository.Repository,
 		return ergo.Wrap(err, "failed to list commits")
 	}
 
-	oneline, _ := g.Oneline(firstCommit.Sha)
-	out.Printf("\n")
-	out.Ok(fmt.Sprintf("══ Joined Review as %s: %d commit(s) ══", c.Name, len(commits)))
-	out.Printf("\n")
-	out.Printf("  %s [1/%d] %s\n", out.Bold("→"), len(commits), oneline)
-	out.Printf("\n")
-	out.Printf("  Worktree: %s\n", worktreePath)
+	verb := "Joined"
+	if rejoin {
+		verb = "Rejoined"
+	}
+	oneline, _ := g.Oneline(target.Sha)
+	out.Notef("\n")
+	out.Ok(fmt.Sprintf("══ %s Review as %s: %d commit(s) ══", verb, c.Name, len(commits)))
+	out.Notef("\n")
+	out.Notef("  %s [%d/%d] %s\n", out.Bold("→"), target.Position+1, len(commits), oneline)
+	out.Notef("\n")
+	out.Notef("  Worktree: %s\n", worktreePath)
+
+	return nil
+}
 
+// ensureWorktree creates the reviewer worktree at path, reusing it when it is already valid.
+// A stale registration is pruned, and a leftover directory removed, before recreating; a
+// directory git still registers as a worktree is never removed, since it may hold work.
+func ensureWorktree(g *git.Git, path string) error {
+	if g.IsWorktree(path) {
+		return nil
+	}
+	if err := g.WorktreePrune(); err != nil {
+		return ergo.Wrap(err, "failed to prune worktrees")
+	}
+	registered, err := g.IsRegisteredWorktree(path)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list worktrees")
+	}
+	if registered {
+		return ergo.New(fmt.Sprintf("%s is a registered worktree that git cannot use; repair it with git worktree repair, or remove it with git worktree remove", path),
+			slog.String("path", path))
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return ergo.Wrap(err, "failed to remove invalid worktree",
+			slog.String("path", path))
+	}
+	if err := g.WorktreeAdd(path); err != nil {
+		return ergo.Wrap(err, "failed to create worktree")
+	}
 	return nil
 }
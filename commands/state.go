@@ -4,37 +4,51 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 
 	"github.com/FujishigeTemma/git-review/internal/db"
 	"github.com/FujishigeTemma/git-review/internal/git"
 	"github.com/FujishigeTemma/git-review/internal/output"
 	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/google/uuid"
 	"github.com/guregu/null/v6"
 	"github.com/newmo-oss/ergo"
 )
 
-type StateCmd struct{}
+type StateCmd struct {
+	WithDiff bool `name:"with-diff" help:"Include the unified diff of this worktree's current commit as \"diff\"."`
+}
 
 type stateOutput struct {
-	BaseRef  string         `json:"baseRef"`
-	Branch   string         `json:"branch"`
-	Commits  []string       `json:"commits"`
-	Current  null.Int       `json:"current"`
-	Comments []stateComment `json:"comments"`
+	BaseRef     string            `json:"baseRef"`
+	BaseOneline string            `json:"baseOneline"`
+	Branch      string            `json:"branch"`
+	Commits     []string          `json:"commits"`
+	Authors     map[string]string `json:"commitAuthors"` // "Name <email>" by SHA, for commits recorded with one
+	Current     null.Int          `json:"current"`
+	Comments    []stateComment    `json:"comments"`
+	Diff        string            `json:"diff,omitempty"` // current commit's changes, with --with-diff
 }
 
 type stateComment struct {
-	ID         string      `json:"id"`
-	ParentID   null.String `json:"parentId"`
-	Commit     string      `json:"commit"`
-	File       null.String `json:"file"`
-	StartLine  null.Int    `json:"startLine"`
-	EndLine    null.Int    `json:"endLine"`
-	Body       string      `json:"body"`
-	ResolvedAt null.String `json:"resolvedAt"`
-	ResolvedBy null.String `json:"resolvedBy"`
-	CreatedAt  string      `json:"createdAt"`
-	CreatedBy  string      `json:"createdBy"`
+	ID            string      `json:"id"`
+	ParentID      null.String `json:"parentId"`
+	Commit        null.String `json:"commit"` // null for review summaries
+	File          null.String `json:"file"`
+	Files         []string    `json:"files,omitempty"` // every file of a comment on several files; file is the first
+	StartLine     null.Int    `json:"startLine"`
+	EndLine       null.Int    `json:"endLine"`
+	Body          string      `json:"body"`
+	ResolvedAt    null.String `json:"resolvedAt"`
+	ResolvedBy    null.String `json:"resolvedBy"`
+	CreatedAt     string      `json:"createdAt"`
+	CreatedBy     string      `json:"createdBy"`
+	NeedsResponse bool        `json:"needsResponse"`
+	Severity      null.String `json:"severity"`
+	AlsoCommit    null.String `json:"alsoCommit"`
+	Fixup         null.String `json:"fixup"`
+	Assignee      null.String `json:"assignee"`
+	OnBase        bool        `json:"onBase"`
 }
 
 func (c *StateCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
@@ -64,8 +78,12 @@ func (c *StateCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outp
 	}
 
 	commitSHAs := make([]string, len(commits))
+	authors := make(map[string]string, len(commits))
 	for i, c := range commits {
 		commitSHAs[i] = c.Sha
+		if c.Author.Valid {
+			authors[c.Sha] = c.Author.String
+		}
 	}
 
 	// Determine current position from worktree reviewer
@@ -82,17 +100,36 @@ func (c *StateCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outp
 		return ergo.Wrap(err, "failed to list comments")
 	}
 
+	files, err := loadCommentFiles(ctx, q, comments)
+	if err != nil {
+		return err
+	}
+
 	stateComments := make([]stateComment, len(comments))
 	for i, c := range comments {
 		stateComments[i] = toStateComment(c)
+		stateComments[i].Files = files[c.ID.String()]
 	}
 
+	baseOneline, _ := g.Oneline(session.BaseRef)
+
 	s := stateOutput{
-		BaseRef:  session.BaseRef,
-		Branch:   session.Branch,
-		Commits:  commitSHAs,
-		Current:  current,
-		Comments: stateComments,
+		BaseRef:     session.BaseRef,
+		BaseOneline: baseOneline,
+		Branch:      session.Branch,
+		Commits:     commitSHAs,
+		Authors:     authors,
+		Current:     current,
+		Comments:    stateComments,
+	}
+
+	if c.WithDiff && current.Valid {
+		sha := commits[current.Int64].Sha
+		diff, err := g.Diff(diffParent(g, session, commits, sha), sha)
+		if err != nil {
+			return ergo.Wrap(err, "failed to read diff", slog.String("commit", sha))
+		}
+		s.Diff = diff
 	}
 
 	enc := json.NewEncoder(out.Stdout)
@@ -102,19 +139,60 @@ func (c *StateCmd) Run(g *git.Git, repo *repository.Repository, out *output.Outp
 
 func toStateComment(c db.Comment) stateComment {
 	sc := stateComment{
-		ID:         c.ID.String(),
-		Commit:     c.Commit,
-		File:       c.File,
-		StartLine:  c.StartLine,
-		EndLine:    c.EndLine,
-		Body:       c.Body,
-		ResolvedAt: c.ResolvedAt,
-		ResolvedBy: c.ResolvedBy,
-		CreatedAt:  c.CreatedAt,
-		CreatedBy:  c.CreatedBy,
+		ID:            c.ID.String(),
+		Commit:        c.Commit,
+		File:          c.File,
+		StartLine:     c.StartLine,
+		EndLine:       c.EndLine,
+		Body:          c.Body,
+		ResolvedAt:    c.ResolvedAt,
+		ResolvedBy:    c.ResolvedBy,
+		CreatedAt:     c.CreatedAt,
+		CreatedBy:     c.CreatedBy,
+		NeedsResponse: c.NeedsResponse,
+		Severity:      c.Severity,
+		AlsoCommit:    c.AlsoCommit,
+		Fixup:         c.Fixup,
+		Assignee:      c.Assignee,
+		OnBase:        c.OnBase,
 	}
 	if c.ParentID.Valid {
 		sc.ParentID = null.StringFrom(c.ParentID.UUID.String())
 	}
 	return sc
 }
+
+// fromStateComment is the inverse of toStateComment, for restoring an exported review.
+// The tree a comment was made against is not exported, so pre-amend tracking starts over.
+func fromStateComment(sc stateComment) (db.InsertCommentParams, error) {
+	id, err := uuid.Parse(sc.ID)
+	if err != nil {
+		return db.InsertCommentParams{}, ergo.Wrap(err, "invalid comment ID", slog.String("comment_id", sc.ID))
+	}
+	params := db.InsertCommentParams{
+		ID:            id,
+		Commit:        sc.Commit,
+		File:          sc.File,
+		StartLine:     sc.StartLine,
+		EndLine:       sc.EndLine,
+		Body:          sc.Body,
+		ResolvedAt:    sc.ResolvedAt,
+		ResolvedBy:    sc.ResolvedBy,
+		CreatedAt:     sc.CreatedAt,
+		CreatedBy:     sc.CreatedBy,
+		NeedsResponse: sc.NeedsResponse,
+		Severity:      sc.Severity,
+		AlsoCommit:    sc.AlsoCommit,
+		Fixup:         sc.Fixup,
+		Assignee:      sc.Assignee,
+		OnBase:        sc.OnBase,
+	}
+	if sc.ParentID.Valid {
+		parent, err := uuid.Parse(sc.ParentID.String)
+		if err != nil {
+			return db.InsertCommentParams{}, ergo.Wrap(err, "invalid parent ID", slog.String("comment_id", sc.ID))
+		}
+		params.ParentID = uuid.NullUUID{UUID: parent, Valid: true}
+	}
+	return params, nil
+}
@@ -0,0 +1,231 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/guregu/null/v6"
+	"github.com/newmo-oss/ergo"
+)
+
+type StatsCmd struct {
+	JSON bool `name:"json" help:"Output stats as JSON."`
+}
+
+type reviewStats struct {
+	Comments        int           `json:"comments"`
+	Threads         int           `json:"threads"`
+	ResolvedThreads int           `json:"resolvedThreads"`
+	OpenThreads     int           `json:"openThreads"`
+	ResolvedRatio   float64       `json:"resolvedRatio"`
+	ByCommit        []commitCount `json:"byCommit"`
+	ByReviewer      []nameCount   `json:"byReviewer"`
+	ByFile          []nameCount   `json:"byFile"`
+	BusiestFile     null.String   `json:"busiestFile"`
+
+	TimeSpentSeconds int64         `json:"timeSpentSeconds"`
+	TimeByReviewer   []nameSeconds `json:"timeByReviewer"`
+}
+
+type commitCount struct {
+	Sha              string `json:"sha"`
+	Message          string `json:"message"`
+	Position         int64  `json:"position"`
+	Count            int    `json:"count"`
+	TimeSpentSeconds int64  `json:"timeSpentSeconds"`
+}
+
+type nameSeconds struct {
+	Name    string `json:"name"`
+	Seconds int64  `json:"seconds"`
+}
+
+type nameCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (c *StatsCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	q := repo.Queries()
+
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+
+	reviewers, err := q.ListReviewers(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list reviewers")
+	}
+
+	comments, err := q.ListAllComments(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list comments")
+	}
+
+	spent, err := loadTimeSpent(ctx, q, reviewers, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	s := computeStats(commits, reviewers, comments)
+	s.addTimeSpent(spent)
+
+	if c.JSON || out.JSON { // the global --json shares this flag's name
+		enc := json.NewEncoder(out.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+
+	printStats(out, s)
+	return nil
+}
+
+// computeStats aggregates comment counts overall and per commit, reviewer and file.
+// Commits keep review order; reviewers and files are sorted by count, busiest first.
+// Reviewers without comments are included so that idle reviewers are visible.
+func computeStats(commits []db.Commit, reviewers []db.Reviewer, comments []db.Comment) reviewStats {
+	s := reviewStats{
+		Comments:   len(comments),
+		ByCommit:   []commitCount{},
+		ByReviewer: []nameCount{},
+		ByFile:     []nameCount{},
+	}
+
+	perCommit := map[string]int{}
+	perReviewer := map[string]int{}
+	perFile := map[string]int{}
+	for _, r := range reviewers {
+		perReviewer[r.Name] = 0
+	}
+	for _, c := range comments {
+		perCommit[c.Commit.String]++
+		perReviewer[c.CreatedBy]++
+		if c.File.Valid {
+			perFile[c.File.String]++
+		}
+		if !c.ParentID.Valid {
+			s.Threads++
+			if c.ResolvedAt.Valid {
+				s.ResolvedThreads++
+			}
+		}
+	}
+	s.OpenThreads = s.Threads - s.ResolvedThreads
+	if s.Threads > 0 {
+		s.ResolvedRatio = float64(s.ResolvedThreads) / float64(s.Threads)
+	}
+
+	for _, cm := range commits {
+		s.ByCommit = append(s.ByCommit, commitCount{
+			Sha:      cm.Sha,
+			Message:  cm.Message,
+			Position: cm.Position,
+			Count:    perCommit[cm.Sha],
+		})
+	}
+	s.ByReviewer = sortedCounts(perReviewer)
+	s.ByFile = sortedCounts(perFile)
+	if len(s.ByFile) > 0 {
+		s.BusiestFile = null.StringFrom(s.ByFile[0].Name)
+	}
+
+	return s
+}
+
+// addTimeSpent fills in the time reviewers dwelled on each commit, longest first per reviewer.
+func (s *reviewStats) addTimeSpent(spent timeSpent) {
+	s.TimeSpentSeconds = spent.total
+	for i := range s.ByCommit {
+		s.ByCommit[i].TimeSpentSeconds = spent.byCommit[s.ByCommit[i].Sha]
+	}
+	s.TimeByReviewer = []nameSeconds{}
+	for name, secs := range spent.byReviewer {
+		s.TimeByReviewer = append(s.TimeByReviewer, nameSeconds{Name: name, Seconds: secs})
+	}
+	slices.SortFunc(s.TimeByReviewer, func(a, b nameSeconds) int {
+		return cmp.Or(cmp.Compare(b.Seconds, a.Seconds), cmp.Compare(a.Name, b.Name))
+	})
+}
+
+// sortedCounts orders counts descending, breaking ties by name for stable output.
+func sortedCounts(m map[string]int) []nameCount {
+	counts := make([]nameCount, 0, len(m))
+	for name, n := range m {
+		counts = append(counts, nameCount{Name: name, Count: n})
+	}
+	slices.SortFunc(counts, func(a, b nameCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return counts
+}
+
+func printStats(out *output.Output, s reviewStats) {
+	out.Printf("\n")
+	out.Printf("%s\n", out.Bold("Review Stats"))
+	out.Printf("\n")
+	out.Printf("  Comments: %d\n", s.Comments)
+	out.Printf("  Threads:  %d (%d resolved, %d open, %.0f%% resolved)\n",
+		s.Threads, s.ResolvedThreads, s.OpenThreads, s.ResolvedRatio*100)
+
+	if s.TimeSpentSeconds >= 60 {
+		out.Printf("  Time:     %s\n", formatDuration(s.TimeSpentSeconds))
+	}
+
+	out.Printf("\n  %s\n", out.Bold("By commit"))
+	for _, cm := range s.ByCommit {
+		spent := ""
+		if cm.TimeSpentSeconds >= 60 {
+			spent = "  (" + formatDuration(cm.TimeSpentSeconds) + ")"
+		}
+		out.Printf("    %d. %s %s  %d%s\n", cm.Position+1, internal.ShortSHA(cm.Sha), cm.Message, cm.Count, spent)
+	}
+
+	if len(s.ByReviewer) > 0 {
+		out.Printf("\n  %s\n", out.Bold("By reviewer"))
+		for _, r := range s.ByReviewer {
+			name := r.Name
+			if name == "" {
+				name = "(default)"
+			}
+			out.Printf("    %s  %d\n", name, r.Count)
+		}
+	}
+
+	if s.TimeSpentSeconds >= 60 {
+		out.Printf("\n  %s\n", out.Bold("Time by reviewer"))
+		for _, r := range s.TimeByReviewer {
+			name := r.Name
+			if name == "" {
+				name = "(default)"
+			}
+			out.Printf("    %s  %s\n", name, formatDuration(r.Seconds))
+		}
+	}
+
+	if len(s.ByFile) > 0 {
+		out.Printf("\n  %s\n", out.Bold("By file"))
+		for _, f := range s.ByFile {
+			out.Printf("    %s  %d\n", f.Name, f.Count)
+		}
+		out.Printf("\n  Busiest file: %s (%d %s)\n", s.BusiestFile.String, s.ByFile[0].Count,
+			internal.Pluralize(s.ByFile[0].Count, "comment", "comments"))
+	}
+	out.Printf("\n")
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/google/uuid"
+	"github.com/guregu/null/v6"
+)
+
+func TestComputeStats(t *testing.T) {
+	rootA := uuid.Must(uuid.NewV7())
+	rootB := uuid.Must(uuid.NewV7())
+	reply := uuid.Must(uuid.NewV7())
+
+	resolved := newComment(rootA, uuid.NullUUID{}, "c1", "fix this", "alice", null.StringFrom("app.js"), null.IntFrom(1), null.IntFrom(1))
+	resolved.ResolvedAt = null.StringFrom("2025-01-01T00:00:00Z")
+	comments := []db.Comment{
+		resolved,
+		newComment(reply, uuid.NullUUID{UUID: rootA, Valid: true}, "c1", "done", "bob", null.StringFrom("app.js"), null.Int{}, null.Int{}),
+		newComment(rootB, uuid.NullUUID{}, "c2", "general", "alice", null.String{}, null.Int{}, null.Int{}),
+	}
+	commits := []db.Commit{
+		{Sha: "c1", Message: "first", Position: 0},
+		{Sha: "c2", Message: "second", Position: 1},
+		{Sha: "c3", Message: "third", Position: 2},
+	}
+	reviewers := []db.Reviewer{{Name: "alice"}, {Name: "carol"}}
+
+	s := computeStats(commits, reviewers, comments)
+
+	if s.Comments != 3 || s.Threads != 2 || s.ResolvedThreads != 1 || s.OpenThreads != 1 {
+		t.Errorf("totals = %d comments, %d threads, %d resolved, %d open; want 3, 2, 1, 1",
+			s.Comments, s.Threads, s.ResolvedThreads, s.OpenThreads)
+	}
+	if s.ResolvedRatio != 0.5 {
+		t.Errorf("resolved ratio = %v, want 0.5", s.ResolvedRatio)
+	}
+
+	wantCommits := []int{2, 1, 0}
+	for i, cc := range s.ByCommit {
+		if cc.Count != wantCommits[i] {
+			t.Errorf("commit %s count = %d, want %d", cc.Sha, cc.Count, wantCommits[i])
+		}
+	}
+
+	wantReviewers := []nameCount{{"alice", 2}, {"bob", 1}, {"carol", 0}}
+	if len(s.ByReviewer) != len(wantReviewers) {
+		t.Fatalf("got %d reviewers, want %d", len(s.ByReviewer), len(wantReviewers))
+	}
+	for i, want := range wantReviewers {
+		if s.ByReviewer[i] != want {
+			t.Errorf("reviewer[%d] = %+v, want %+v", i, s.ByReviewer[i], want)
+		}
+	}
+
+	if !s.BusiestFile.Valid || s.BusiestFile.String != "app.js" {
+		t.Errorf("busiest file = %v, want app.js", s.BusiestFile)
+	}
+}
+
+func TestComputeStats_NoComments(t *testing.T) {
+	s := computeStats(nil, nil, nil)
+	if s.ResolvedRatio != 0 || s.BusiestFile.Valid {
+		t.Errorf("expected zero stats, got %+v", s)
+	}
+}
+
+func TestAddTimeSpent_CountsOpenCommitUpToNow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	commits := []db.Commit{{Sha: "c1", Position: 0}, {Sha: "c2", Position: 1}}
+	reviewers := []db.Reviewer{
+		// alice moved on from c1 earlier and has been on c2 for 10 minutes
+		{Name: "alice", CurrentSha: null.StringFrom("c2"), EnteredAt: null.StringFrom("2025-01-01T11:50:00Z")},
+		// bob has not started
+		{Name: "bob"},
+	}
+
+	spent := timeSpent{byCommit: map[string]int64{}, byReviewer: map[string]int64{}}
+	spent.add("alice", "c1", 300)
+	for _, r := range reviewers {
+		spent.add(r.Name, r.CurrentSha.String, openSeconds(r, now))
+	}
+
+	s := computeStats(commits, reviewers, nil)
+	s.addTimeSpent(spent)
+
+	if s.TimeSpentSeconds != 900 {
+		t.Errorf("total = %d, want 900", s.TimeSpentSeconds)
+	}
+	if s.ByCommit[0].TimeSpentSeconds != 300 || s.ByCommit[1].TimeSpentSeconds != 600 {
+		t.Errorf("by commit = %d, %d; want 300, 600", s.ByCommit[0].TimeSpentSeconds, s.ByCommit[1].TimeSpentSeconds)
+	}
+	if len(s.TimeByReviewer) != 1 || s.TimeByReviewer[0] != (nameSeconds{"alice", 900}) {
+		t.Errorf("by reviewer = %+v, want alice 900", s.TimeByReviewer)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		secs int64
+		want string
+	}{
+		{59, "0m"},
+		{60, "1m"},
+		{3599, "59m"},
+		{3600, "1h00m"},
+		{3900, "1h05m"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.secs); got != tt.want {
+			t.Errorf("formatDuration(%d) = %q, want %q", tt.secs, got, tt.want)
+		}
+	}
+}
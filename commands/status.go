@@ -1,37 +1,166 @@
 package commands
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
 
 	"github.com/FujishigeTemma/git-review/internal"
 	"github.com/FujishigeTemma/git-review/internal/git"
 	"github.com/FujishigeTemma/git-review/internal/output"
 	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/guregu/null/v6"
 	"github.com/newmo-oss/ergo"
 )
 
-type StatusCmd struct{}
+type StatusCmd struct {
+	FailOnUnresolved bool `name:"fail-on-unresolved" help:"Exit with status 2 if any thread is unresolved (for CI gating)."`
+	Quiet            bool `short:"q" help:"Print nothing; only set the exit status."`
+	JSON             bool `name:"json" help:"Output progress as JSON: reviewer positions, per-commit comment counts, thread totals."`
+	Watch            bool `name:"watch" help:"Keep the progress on screen, redrawing it whenever the review changes, until interrupted."`
+	Compact          bool `name:"compact" help:"Print one terse line for a shell prompt, e.g. \"review 2/3 (1!)\" for 1 unresolved thread; nothing when no review is in progress."`
+}
+
+// statusWatchInterval is how often status --watch checks the review for changes.
+const statusWatchInterval = time.Second
 
 func (c *StatusCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if c.Compact {
+		if c.JSON || out.JSON || c.Watch {
+			return ergo.New("--compact cannot be combined with --json or --watch")
+		}
+		if repo == nil {
+			return nil
+		}
+		if active, err := repo.Queries().SessionExists(context.Background()); err != nil || active == 0 {
+			return nil
+		}
+	}
 	if err := requireActive(repo); err != nil {
 		return err
 	}
-	return showStatus(g, repo, out)
+	// The global --quiet and --json share these flags' names, so honor whichever caught them
+	c.Quiet = c.Quiet || out.Quiet
+	c.JSON = c.JSON || out.JSON
+	if c.Watch {
+		if c.JSON || c.Quiet || c.FailOnUnresolved {
+			return ergo.New("--watch cannot be combined with --json, --quiet or --fail-on-unresolved")
+		}
+		return watchStatus(g, repo, out)
+	}
+	if !c.Quiet {
+		if c.JSON {
+			st, err := loadStatus(g, repo, out)
+			if err != nil {
+				return err
+			}
+			enc := json.NewEncoder(out.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(st); err != nil {
+				return err
+			}
+		} else if c.Compact {
+			if err := showCompactStatus(g, repo, out); err != nil {
+				return err
+			}
+		} else if err := showStatus(g, repo, out); err != nil {
+			return err
+		}
+	}
+	if !c.FailOnUnresolved {
+		return nil
+	}
+
+	comments, err := repo.Queries().ListAllComments(context.Background())
+	if err != nil {
+		return ergo.Wrap(err, "failed to list comments")
+	}
+	unresolved := 0
+	for _, cm := range comments {
+		if !cm.ParentID.Valid && !cm.ResolvedAt.Valid {
+			unresolved++
+		}
+	}
+	if unresolved == 0 {
+		return nil
+	}
+	err = ergo.WithCode(
+		ergo.New(fmt.Sprintf("%d unresolved %s", unresolved, internal.Pluralize(unresolved, "thread", "threads"))),
+		internal.ErrCodeUnresolved)
+	if c.Quiet {
+		return internal.Silent(err)
+	}
+	return err
 }
 
-func showStatus(g *git.Git, repo *repository.Repository, out *output.Output) error {
+// showCompactStatus prints "review <position>/<total>", followed by "(N!)" while N threads
+// are unresolved, with "-" as the position before this worktree's reviewer reaches a commit.
+func showCompactStatus(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	st, err := loadStatus(g, repo, out)
+	if err != nil {
+		return err
+	}
+	pos := "-"
+	if st.CurrentPosition.Valid {
+		pos = fmt.Sprint(st.CurrentPosition.Int64 + 1)
+	}
+	line := fmt.Sprintf("review %s/%d", pos, st.TotalCommits)
+	if st.OpenThreads > 0 {
+		out.Printf("%s\n", out.Yellow(fmt.Sprintf("%s (%d!)", line, st.OpenThreads)))
+		return nil
+	}
+	out.Printf("%s\n", out.Green(line))
+	return nil
+}
+
+// reviewStatus is the progress view shared by the human and --json output of status.
+type reviewStatus struct {
+	Branch          string           `json:"branch"`
+	BaseRef         string           `json:"baseRef"`
+	Reviewers       []reviewerStatus `json:"reviewers"`
+	CurrentPosition null.Int         `json:"currentPosition"` // this worktree's reviewer
+	TotalCommits    int              `json:"totalCommits"`
+	Commits         []commitStatus   `json:"commits"`
+	Comments        int              `json:"comments"`
+	ResolvedThreads int              `json:"resolvedThreads"`
+	OpenThreads     int              `json:"openThreads"`
+	AnnotateOnly    bool             `json:"annotateOnly"` // started with --annotate-only
+}
+
+type reviewerStatus struct {
+	Name     string      `json:"name"`
+	Role     null.String `json:"role"`     // set with start --role
+	Position null.Int    `json:"position"` // null until the reviewer reaches a commit
+}
+
+type commitStatus struct {
+	Sha      string      `json:"sha"`
+	Message  string      `json:"message"`
+	Author   null.String `json:"author"` // "Name <email>"; null in reviews started before it was recorded
+	Position int64       `json:"position"`
+	Comments int         `json:"comments"`
+	PreAmend int         `json:"preAmend"`
+
+	TimeSpentSeconds int64 `json:"timeSpentSeconds"` // all reviewers, up to now
+}
+
+func loadStatus(g *git.Git, repo *repository.Repository, out *output.Output) (reviewStatus, error) {
 	ctx := context.Background()
 	q := repo.Queries()
 
 	session, err := q.GetSession(ctx)
 	if err != nil {
-		return ergo.Wrap(err, "failed to get session")
+		return reviewStatus{}, ergo.Wrap(err, "failed to get session")
 	}
 
 	commits, err := q.ListCommits(ctx)
 	if err != nil {
-		return ergo.Wrap(err, "failed to list commits")
+		return reviewStatus{}, ergo.Wrap(err, "failed to list commits")
 	}
 
 	comments, err := q.ListAllComments(ctx)
@@ -41,53 +170,173 @@ func showStatus(g *git.Git, repo *repository.Repository, out *output.Output) err
 
 	reviewers, err := q.ListReviewers(ctx)
 	if err != nil {
-		return ergo.Wrap(err, "failed to list reviewers")
+		return reviewStatus{}, ergo.Wrap(err, "failed to list reviewers")
+	}
+
+	st := reviewStatus{
+		Branch:       session.Branch,
+		BaseRef:      session.BaseRef,
+		AnnotateOnly: session.AnnotateOnly,
+		Reviewers:    []reviewerStatus{},
+		TotalCommits: len(commits),
+		Commits:      make([]commitStatus, 0, len(commits)),
+		Comments:     len(comments),
 	}
 
+	for _, r := range reviewers {
+		rs := reviewerStatus{Name: r.Name, Role: r.Role}
+		if r.CurrentSha.Valid {
+			if p := findCommitPosition(commits, r.CurrentSha.String); p >= 0 {
+				rs.Position = null.IntFrom(p)
+			}
+		}
+		if r.Name == g.Reviewer {
+			st.CurrentPosition = rs.Position
+		}
+		st.Reviewers = append(st.Reviewers, rs)
+	}
+
+	// Build maps of commit SHA -> comment count and pre-amend comment count
+	preAmend := preAmendIDs(comments, currentTrees(g, session, commits))
+	commentCount := map[string]int{}
+	preAmendCount := map[string]int{}
+	for _, c := range comments {
+		commentCount[c.Commit.String]++
+		if preAmend[c.ID.String()] {
+			preAmendCount[c.Commit.String]++
+		}
+		if !c.ParentID.Valid {
+			if c.ResolvedAt.Valid {
+				st.ResolvedThreads++
+			} else {
+				st.OpenThreads++
+			}
+		}
+	}
+	spent, err := loadTimeSpent(ctx, q, reviewers, time.Now().UTC())
+	if err != nil {
+		return reviewStatus{}, err
+	}
+	for _, cm := range commits {
+		st.Commits = append(st.Commits, commitStatus{
+			Sha:              cm.Sha,
+			Message:          cm.Message,
+			Author:           cm.Author,
+			Position:         cm.Position,
+			Comments:         commentCount[cm.Sha],
+			PreAmend:         preAmendCount[cm.Sha],
+			TimeSpentSeconds: spent.byCommit[cm.Sha],
+		})
+	}
+
+	return st, nil
+}
+
+// showStatus prints the review progress; start shows it when a review is already running.
+func showStatus(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	st, err := loadStatus(g, repo, out)
+	if err != nil {
+		return err
+	}
+	printStatus(g, out, st)
+	return nil
+}
+
+// watchStatus redraws the progress every statusWatchInterval when it has changed, clearing
+// the screen between frames on a terminal, until Ctrl-C. The review database is shared by
+// all worktrees, so other reviewers' moves and comments show up as they happen.
+func watchStatus(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(statusWatchInterval)
+	defer ticker.Stop()
+
+	var last string
+	for {
+		var buf bytes.Buffer
+		frame := *out
+		frame.Stdout = &buf
+		if err := showStatus(g, repo, &frame); err != nil {
+			return err
+		}
+		if buf.String() != last {
+			if out.Terminal {
+				out.Printf("\033[H\033[2J")
+			}
+			out.Printf("%s", buf.String())
+			last = buf.String()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func printStatus(g *git.Git, out *output.Output, st reviewStatus) {
 	out.Printf("\n")
-	out.Printf("%s  %s\n", out.Bold("Review Progress"), session.Branch)
+	out.Printf("%s  %s\n", out.Bold("Review Progress"), st.Branch)
+	if oneline, err := g.Oneline(st.BaseRef); err == nil {
+		out.Printf("Base: %s\n", oneline)
+	}
+	if st.AnnotateOnly {
+		out.Printf("Annotate-only: commits are not checked out\n")
+	}
 	out.Printf("\n")
 
-	// Show per-reviewer progress if multiple reviewers
-	if len(reviewers) > 1 {
-		for _, r := range reviewers {
+	// Show per-reviewer progress if multiple reviewers, or who the one reviewer is if they have a role
+	if len(st.Reviewers) > 1 || (len(st.Reviewers) == 1 && st.Reviewers[0].Role.Valid) {
+		for _, r := range st.Reviewers {
 			name := r.Name
 			if name == "" {
 				name = "(default)"
 			}
+			if r.Role.Valid {
+				name += "(" + r.Role.String + ")"
+			}
 			pos := "not started"
-			if r.CurrentSha.Valid {
-				if p := findCommitPosition(commits, r.CurrentSha.String); p >= 0 {
-					pos = fmt.Sprintf("%d/%d", p+1, len(commits))
-				}
+			if r.Position.Valid {
+				pos = fmt.Sprintf("%d/%d", r.Position.Int64+1, st.TotalCommits)
 			}
 			out.Printf("  Reviewer %s: %s\n", name, pos)
 		}
 		out.Printf("\n")
 	}
 
-	// Build a map of commit SHA -> comment count
-	commentCount := map[string]int{}
-	for _, c := range comments {
-		commentCount[c.Commit]++
-	}
-
 	// Determine current reviewer position for display
-	var currentPos int64 = -1
-	for _, r := range reviewers {
-		if r.Name == g.Reviewer && r.CurrentSha.Valid {
-			currentPos = findCommitPosition(commits, r.CurrentSha.String)
-			break
+	currentPos := st.CurrentPosition.ValueOr(-1)
+
+	// Name the authors only when there is more than one, e.g. on a shared branch
+	authors := map[string]bool{}
+	for _, cm := range st.Commits {
+		if cm.Author.Valid {
+			authors[cm.Author.String] = true
 		}
 	}
 
-	for _, cm := range commits {
+	for _, cm := range st.Commits {
 		oneline, _ := g.Oneline(cm.Sha)
+		if len(authors) > 1 && cm.Author.Valid {
+			name, _, _ := strings.Cut(cm.Author.String, " <")
+			oneline += " by " + name
+		}
 
+		var notes []string
+		if n := cm.Comments; n > 0 {
+			notes = append(notes, fmt.Sprintf("%d %s", n, internal.Pluralize(n, "comment", "comments")))
+			if cm.PreAmend > 0 {
+				notes = append(notes, fmt.Sprintf("%d pre-amend", cm.PreAmend))
+			}
+		}
+		if cm.TimeSpentSeconds >= 60 {
+			notes = append(notes, formatDuration(cm.TimeSpentSeconds))
+		}
 		badge := ""
-		n := commentCount[cm.Sha]
-		if n > 0 {
-			badge = fmt.Sprintf(" (%d %s)", n, internal.Pluralize(n, "comment", "comments"))
+		if len(notes) > 0 {
+			badge = " (" + strings.Join(notes, ", ") + ")"
 		}
 
 		line := fmt.Sprintf("%d. %s%s", cm.Position+1, oneline, badge)
@@ -101,6 +350,4 @@ func showStatus(g *git.Git, repo *repository.Repository, out *output.Output) err
 		}
 	}
 	out.Printf("\n")
-
-	return nil
 }
@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"text/template"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/newmo-oss/ergo"
+)
+
+// summaryData is what a finish --template sees. Comments are in review order, each
+// thread's root followed by its replies.
+type summaryData struct {
+	Branch       string
+	BaseRef      string
+	CommitCount  int
+	CommentCount int
+	Threads      int
+	Unresolved   int
+	Resolved     int
+	Commits      []summaryCommit
+	Comments     []summaryComment
+}
+
+type summaryCommit struct {
+	Sha      string
+	ShortSha string
+	Message  string
+	Position int // 1-based
+	Comments int
+}
+
+type summaryComment struct {
+	ID         string
+	ShortID    string
+	Commit     string // short SHA; empty for review summaries
+	File       string // files separated by commas for a comment on several files
+	Lines      string // "10" or "10-25"; empty without a line
+	Body       string
+	Author     string
+	Reply      bool
+	Resolved   bool
+	ResolvedBy string
+}
+
+// loadSummaryTemplate parses the template file given to finish --template.
+func loadSummaryTemplate(path string) (*template.Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ergo.Wrap(err, "failed to read template", slog.String("path", path))
+	}
+	tmpl, err := template.New(path).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, ergo.Wrap(err, "failed to parse template", slog.String("path", path))
+	}
+	return tmpl, nil
+}
+
+// renderSummary executes tmpl on the review's commits and comments.
+func renderSummary(tmpl *template.Template, session db.Session, commits []db.Commit, comments []db.Comment, files commentFiles) (string, error) {
+	data := summaryData{
+		Branch:       session.Branch,
+		BaseRef:      session.BaseRef,
+		CommitCount:  len(commits),
+		CommentCount: len(comments),
+	}
+	perCommit := map[string]int{}
+	for _, c := range comments {
+		perCommit[c.Commit.String]++
+		if c.ParentID.Valid {
+			continue
+		}
+		data.Threads++
+		if c.ResolvedAt.Valid {
+			data.Resolved++
+		}
+	}
+	data.Unresolved = data.Threads - data.Resolved
+	for _, cm := range commits {
+		data.Commits = append(data.Commits, summaryCommit{
+			Sha:      cm.Sha,
+			ShortSha: internal.ShortSHA(cm.Sha),
+			Message:  cm.Message,
+			Position: int(cm.Position) + 1,
+			Comments: perCommit[cm.Sha],
+		})
+	}
+
+	childrenMap := buildChildrenMap(comments)
+	add := func(c db.Comment) {
+		sc := summaryComment{
+			ID:         c.ID.String(),
+			ShortID:    internal.ShortID(c.ID),
+			File:       files.label(c),
+			Lines:      internal.FormatLineRange(c.StartLine, c.EndLine),
+			Body:       c.Body,
+			Author:     c.CreatedBy,
+			Reply:      c.ParentID.Valid,
+			Resolved:   c.ResolvedAt.Valid,
+			ResolvedBy: c.ResolvedBy.String,
+		}
+		if c.Commit.Valid {
+			sc.Commit = internal.ShortSHA(c.Commit.String)
+		}
+		data.Comments = append(data.Comments, sc)
+	}
+	for _, sha := range append([]string{""}, commitSHAs(commits)...) {
+		general, byFile := groupCommitComments(comments, files, sha)
+		for _, fe := range byFile {
+			general = append(general, fe.comments...)
+		}
+		for _, root := range general {
+			add(root)
+			for _, r := range descendants(childrenMap, root.ID) {
+				add(r)
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", ergo.Wrap(err, "failed to render template")
+	}
+	return b.String(), nil
+}
+
+func commitSHAs(commits []db.Commit) []string {
+	shas := make([]string, len(commits))
+	for i, cm := range commits {
+		shas[i] = cm.Sha
+	}
+	return shas
+}
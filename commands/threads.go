@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/newmo-oss/ergo"
+)
+
+// threadBodyWidth is how many characters of a root comment threads shows.
+const threadBodyWidth = 60
+
+type ThreadsCmd struct {
+	Unresolved bool `help:"Show only unresolved threads."`
+}
+
+// Run prints one line per thread: its root's ID, status, commit, location, the start of
+// its body and how many replies it has, most recently active thread first.
+func (c *ThreadsCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	q := repo.Queries()
+
+	commits, err := q.ListCommits(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list commits")
+	}
+	all, err := q.ListAllComments(ctx)
+	if err != nil {
+		return ergo.Wrap(err, "failed to list comments")
+	}
+
+	files, err := loadCommentFiles(ctx, q, all)
+	if err != nil {
+		return err
+	}
+
+	childrenMap := buildChildrenMap(all)
+	var roots []db.Comment
+	for _, cm := range filterComments(all, commits, buildIDMap(all), commentFilter{unresolved: c.Unresolved}) {
+		if !cm.ParentID.Valid {
+			roots = append(roots, cm)
+		}
+	}
+	if len(roots) == 0 {
+		out.Printf("No threads\n")
+		return nil
+	}
+
+	activity := make(map[string]threadActivity, len(roots))
+	for _, root := range roots {
+		activity[root.ID.String()] = lastActivity(root, childrenMap)
+	}
+	slices.SortStableFunc(roots, func(a, b db.Comment) int {
+		x, y := activity[a.ID.String()], activity[b.ID.String()]
+		return cmp.Or(cmp.Compare(y.at, x.at), cmp.Compare(y.id, x.id))
+	})
+
+	for _, root := range roots {
+		status := "open"
+		if root.ResolvedAt.Valid {
+			status = "resolved"
+		}
+		where := "summary"
+		if root.Commit.Valid {
+			where = fmt.Sprintf("%d/%d %s", findCommitPosition(commits, root.Commit.String)+1, len(commits), internal.ShortSHA(root.Commit.String))
+		}
+		replies := countReplies(root, childrenMap)
+		out.Printf("[%s] %-8s %s %s%s (%d %s)\n", internal.ShortID(root.ID), status, where,
+			fileLocation(root, files), truncateBody(root.Body, threadBodyWidth), replies, internal.Pluralize(replies, "reply", "replies"))
+	}
+	return nil
+}
+
+// threadActivity is when a thread last changed: the latest RFC 3339 timestamp among its
+// comments' creation and the root's resolution, and its newest comment ID, which for
+// UUIDv7 orders comments made within the same second.
+type threadActivity struct {
+	at, id string
+}
+
+func lastActivity(c db.Comment, childrenMap map[string][]db.Comment) threadActivity {
+	latest := threadActivity{at: max(c.CreatedAt, c.ResolvedAt.String), id: c.ID.String()}
+	for _, child := range childrenMap[c.ID.String()] {
+		a := lastActivity(child, childrenMap)
+		latest = threadActivity{at: max(latest.at, a.at), id: max(latest.id, a.id)}
+	}
+	return latest
+}
+
+// countReplies counts every comment below c, including replies to replies.
+func countReplies(c db.Comment, childrenMap map[string][]db.Comment) int {
+	n := 0
+	for _, child := range childrenMap[c.ID.String()] {
+		n += 1 + countReplies(child, childrenMap)
+	}
+	return n
+}
+
+// truncateBody returns the first line of body, cut to width characters with an ellipsis.
+func truncateBody(body string, width int) string {
+	line, _, more := strings.Cut(body, "\n")
+	if r := []rune(line); len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	if more {
+		return line + " …"
+	}
+	return line
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/newmo-oss/ergo"
+)
+
+// timeSpent is how long reviewers have dwelled on each commit, in seconds.
+type timeSpent struct {
+	byCommit   map[string]int64
+	byReviewer map[string]int64
+	total      int64
+}
+
+// recordTimeSpent credits the reviewer's current commit with the time since they
+// arrived on it. Callers then move the reviewer on, which restarts the clock.
+func recordTimeSpent(ctx context.Context, q *db.Queries, r db.Reviewer, now time.Time) error {
+	secs := openSeconds(r, now)
+	if secs == 0 {
+		return nil
+	}
+	if err := q.AddTimeSpent(ctx, db.AddTimeSpentParams{
+		Reviewer: r.Name,
+		Sha:      r.CurrentSha.String,
+		Seconds:  secs,
+	}); err != nil {
+		return ergo.Wrap(err, "failed to record time spent", slog.String("name", r.Name))
+	}
+	return nil
+}
+
+// loadTimeSpent sums the recorded time with the time each reviewer has been on their
+// current commit so far, which has no end until they move on or the review finishes.
+func loadTimeSpent(ctx context.Context, q *db.Queries, reviewers []db.Reviewer, now time.Time) (timeSpent, error) {
+	ts := timeSpent{byCommit: map[string]int64{}, byReviewer: map[string]int64{}}
+	rows, err := q.ListTimeSpent(ctx)
+	if err != nil {
+		return ts, ergo.Wrap(err, "failed to list time spent")
+	}
+	for _, row := range rows {
+		ts.add(row.Reviewer, row.Sha, row.Seconds)
+	}
+	for _, r := range reviewers {
+		ts.add(r.Name, r.CurrentSha.String, openSeconds(r, now))
+	}
+	return ts, nil
+}
+
+func (ts *timeSpent) add(reviewer, sha string, secs int64) {
+	if secs == 0 {
+		return
+	}
+	ts.byCommit[sha] += secs
+	ts.byReviewer[reviewer] += secs
+	ts.total += secs
+}
+
+// openSeconds is how long the reviewer has been on their current commit.
+func openSeconds(r db.Reviewer, now time.Time) int64 {
+	if !r.CurrentSha.Valid || !r.EnteredAt.Valid {
+		return 0
+	}
+	entered, err := time.Parse(time.RFC3339, r.EnteredAt.String)
+	if err != nil {
+		return 0
+	}
+	return max(int64(now.Sub(entered)/time.Second), 0)
+}
+
+// formatDuration renders seconds in whole minutes: "12m", "1h05m". Callers leave out
+// durations under a minute, which are noise when estimating review effort.
+func formatDuration(secs int64) string {
+	if secs < 3600 {
+		return fmt.Sprintf("%dm", secs/60)
+	}
+	return fmt.Sprintf("%dh%02dm", secs/3600, secs%3600/60)
+}
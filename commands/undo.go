@@ -0,0 +1,365 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/FujishigeTemma/git-review/internal/repository"
+	"github.com/google/uuid"
+	"github.com/newmo-oss/ergo"
+)
+
+type UndoCmd struct{}
+
+// Run reverses the latest add, delete, resolve or unresolve made from this worktree, as
+// long as the threads it touched have not changed since.
+func (c *UndoCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
+	if err := requireActive(repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	var undone string
+	if err := repo.WithTx(ctx, func(q *db.Queries) error {
+		entry, err := q.GetLastAction(ctx, g.Reviewer)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return ergo.Wrap(err, "failed to read the action log")
+		}
+		var rec undoRecord
+		if err := json.Unmarshal([]byte(entry.Data), &rec); err != nil {
+			return ergo.Wrap(err, "failed to read the action log", slog.Int64("action_id", entry.ID))
+		}
+		if err := rec.restore(ctx, q); err != nil {
+			return ergo.Wrap(err, "cannot undo "+entry.Summary)
+		}
+		if err := q.DeleteAction(ctx, entry.ID); err != nil {
+			return ergo.Wrap(err, "failed to update the action log")
+		}
+		undone = entry.Summary
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if undone == "" {
+		out.Info("Nothing to undo.")
+		return nil
+	}
+	out.Ok("Undid " + undone + ".")
+	return nil
+}
+
+// undoRecord is what the action log keeps to reverse an action: the threads it touched
+// as they were before and as it left them, and the IDs of the comments it added.
+type undoRecord struct {
+	threadSnapshot
+	Added []uuid.UUID    `json:"added,omitempty"`
+	After threadSnapshot `json:"after"`
+}
+
+// threadSnapshot is every comment of some threads, with their files and history.
+type threadSnapshot struct {
+	Comments []db.Comment        `json:"comments"`
+	Files    []db.CommentFile    `json:"files"`
+	History  []db.CommentHistory `json:"history"`
+}
+
+// snapshotThreads records the whole threads containing the given comments, before an
+// action changes them.
+func snapshotThreads(ctx context.Context, q *db.Queries, ids ...uuid.UUID) (undoRecord, error) {
+	snap, err := takeSnapshot(ctx, q, ids)
+	if err != nil {
+		return undoRecord{}, err
+	}
+	return undoRecord{threadSnapshot: snap}, nil
+}
+
+// takeSnapshot records the threads containing the given comments as they are now. IDs
+// that no longer exist are skipped.
+func takeSnapshot(ctx context.Context, q *db.Queries, ids []uuid.UUID) (threadSnapshot, error) {
+	all, err := q.ListAllComments(ctx)
+	if err != nil {
+		return threadSnapshot{}, ergo.Wrap(err, "failed to load comments")
+	}
+	idMap := buildIDMap(all)
+	roots := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		if c, ok := idMap[id.String()]; ok {
+			roots[findRoot(idMap, c).ID] = true
+		}
+	}
+
+	var snap threadSnapshot
+	inThreads := map[uuid.UUID]bool{}
+	for _, c := range all {
+		if !roots[findRoot(idMap, c).ID] {
+			continue
+		}
+		inThreads[c.ID] = true
+		snap.Comments = append(snap.Comments, c)
+		history, err := q.ListCommentHistory(ctx, c.ID)
+		if err != nil {
+			return threadSnapshot{}, ergo.Wrap(err, "failed to list comment history")
+		}
+		snap.History = append(snap.History, history...)
+	}
+	files, err := q.ListCommentFiles(ctx)
+	if err != nil {
+		return threadSnapshot{}, ergo.Wrap(err, "failed to load comment files")
+	}
+	for _, f := range files {
+		if inThreads[f.CommentID] {
+			snap.Files = append(snap.Files, f)
+		}
+	}
+	return snap, nil
+}
+
+// commentIDs returns the IDs of comments, to snapshot their threads.
+func commentIDs(comments []db.Comment) []uuid.UUID {
+	ids := make([]uuid.UUID, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+// touched returns the IDs of every comment the action could have changed.
+func (rec undoRecord) touched() []uuid.UUID {
+	return append(commentIDs(rec.Comments), rec.Added...)
+}
+
+// logAction appends an action to the log so that undo run from the same worktree can
+// reverse it. reviewer is the worktree the action was made from, which keys undo, and
+// actor who it is attributed to, which -a or GIT_REVIEW_AUTHOR may set apart. summary
+// completes "Undid …", e.g. "resolve of [0193a2b4]". It is called once the action has
+// made its changes, so that the threads can be recorded as it left them.
+func logAction(ctx context.Context, q *db.Queries, reviewer, actor, action, summary string, rec undoRecord) error {
+	after, err := takeSnapshot(ctx, q, rec.touched())
+	if err != nil {
+		return err
+	}
+	rec.After = after
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return ergo.Wrap(err, "failed to encode the action log entry")
+	}
+	if err := q.InsertAction(ctx, db.InsertActionParams{
+		Reviewer:  reviewer,
+		Actor:     actor,
+		Action:    action,
+		Summary:   summary,
+		Data:      string(data),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		return ergo.Wrap(err, "failed to record the action", slog.String("action", action))
+	}
+	return nil
+}
+
+// restore reverses only what the action itself changed: it deletes the comments it
+// added, puts back the ones it deleted, resets the fields it changed and drops the
+// history it recorded. It refuses when the threads are no longer as the action left
+// them, because reversing it would then overwrite someone else's later changes.
+func (rec undoRecord) restore(ctx context.Context, q *db.Queries) error {
+	now, err := takeSnapshot(ctx, q, rec.touched())
+	if err != nil {
+		return err
+	}
+	if err := rec.After.unchangedIn(now); err != nil {
+		return err
+	}
+
+	before := map[uuid.UUID]db.Comment{}
+	for _, c := range rec.Comments {
+		before[c.ID] = c
+	}
+	current := map[uuid.UUID]db.Comment{}
+	for _, c := range now.Comments {
+		current[c.ID] = c
+	}
+
+	for _, c := range now.Comments {
+		if _, ok := before[c.ID]; ok {
+			continue
+		}
+		if err := q.DeleteComment(ctx, c.ID); err != nil {
+			return ergo.Wrap(err, "failed to delete comment", slog.String("comment_id", c.ID.String()))
+		}
+	}
+
+	// UUIDv7 IDs sort chronologically, so parents are inserted before their replies
+	comments := slices.Clone(rec.Comments)
+	slices.SortFunc(comments, func(a, b db.Comment) int { return cmp.Compare(a.ID.String(), b.ID.String()) })
+	reinserted := map[uuid.UUID]bool{}
+	for _, c := range comments {
+		if _, ok := current[c.ID]; ok {
+			continue
+		}
+		reinserted[c.ID] = true
+		if err := q.InsertComment(ctx, db.InsertCommentParams{
+			ID:            c.ID,
+			ParentID:      c.ParentID,
+			Commit:        c.Commit,
+			File:          c.File,
+			StartLine:     c.StartLine,
+			EndLine:       c.EndLine,
+			Body:          c.Body,
+			ResolvedAt:    c.ResolvedAt,
+			ResolvedBy:    c.ResolvedBy,
+			CreatedAt:     c.CreatedAt,
+			CreatedBy:     c.CreatedBy,
+			NeedsResponse: c.NeedsResponse,
+			Tree:          c.Tree,
+			Severity:      c.Severity,
+			AlsoCommit:    c.AlsoCommit,
+			Fixup:         c.Fixup,
+			Assignee:      c.Assignee,
+			OnBase:        c.OnBase,
+		}); err != nil {
+			return ergo.Wrap(err, "failed to restore comment", slog.String("comment_id", c.ID.String()))
+		}
+	}
+	for _, c := range comments {
+		if cur, ok := current[c.ID]; !ok || cur == c {
+			continue
+		}
+		if err := q.RestoreComment(ctx, db.RestoreCommentParams{
+			ParentID:      c.ParentID,
+			ResolvedAt:    c.ResolvedAt,
+			ResolvedBy:    c.ResolvedBy,
+			NeedsResponse: c.NeedsResponse,
+			Severity:      c.Severity,
+			AlsoCommit:    c.AlsoCommit,
+			Fixup:         c.Fixup,
+			Assignee:      c.Assignee,
+			OnBase:        c.OnBase,
+			ID:            c.ID,
+		}); err != nil {
+			return ergo.Wrap(err, "failed to restore comment", slog.String("comment_id", c.ID.String()))
+		}
+	}
+	// A comment that kept its row may have had files moved onto it, as a promoted reply has
+	beforeFiles, nowFiles := filesByComment(rec.Files), filesByComment(now.Files)
+	for id, files := range nowFiles {
+		if _, ok := before[id]; !ok || reinserted[id] || slices.Equal(files, beforeFiles[id]) {
+			continue
+		}
+		if err := q.DeleteCommentFiles(ctx, id); err != nil {
+			return ergo.Wrap(err, "failed to restore comment files", slog.String("comment_id", id.String()))
+		}
+		reinserted[id] = true
+	}
+	for _, f := range rec.Files {
+		if !reinserted[f.CommentID] {
+			continue
+		}
+		if err := q.InsertCommentFile(ctx, db.InsertCommentFileParams{CommentID: f.CommentID, Position: f.Position, File: f.File}); err != nil {
+			return ergo.Wrap(err, "failed to restore comment file", slog.String("file", f.File))
+		}
+	}
+
+	// History rows that undo itself reinserted have new IDs, so rows are matched by content
+	remaining := countHistory(rec.History)
+	for _, h := range now.History {
+		if k := historyKey(h); remaining[k] > 0 {
+			remaining[k]--
+			continue
+		}
+		if err := q.DeleteCommentHistory(ctx, h.ID); err != nil {
+			return ergo.Wrap(err, "failed to delete comment history", slog.String("comment_id", h.CommentID.String()))
+		}
+	}
+	// What remains went with the comments the action deleted
+	for _, h := range rec.History {
+		k := historyKey(h)
+		if remaining[k] == 0 {
+			continue
+		}
+		remaining[k]--
+		if err := q.InsertCommentHistory(ctx, db.InsertCommentHistoryParams{
+			CommentID: h.CommentID,
+			Action:    h.Action,
+			Body:      h.Body,
+			ChangedBy: h.ChangedBy,
+			ChangedAt: h.ChangedAt,
+		}); err != nil {
+			return ergo.Wrap(err, "failed to restore comment history", slog.String("comment_id", h.CommentID.String()))
+		}
+	}
+	return nil
+}
+
+// unchangedIn reports an error naming a comment that was added, removed or changed, or
+// whose history grew, between the snapshot and now.
+func (snap threadSnapshot) unchangedIn(now threadSnapshot) error {
+	then := map[uuid.UUID]db.Comment{}
+	for _, c := range snap.Comments {
+		then[c.ID] = c
+	}
+	for _, c := range now.Comments {
+		prev, ok := then[c.ID]
+		if !ok {
+			return ergo.New(fmt.Sprintf("[%s] was added to the thread since", internal.ShortID(c.ID)))
+		}
+		if prev != c {
+			return ergo.New(fmt.Sprintf("[%s] has changed since", internal.ShortID(c.ID)))
+		}
+		delete(then, c.ID)
+	}
+	for id := range then {
+		return ergo.New(fmt.Sprintf("[%s] was deleted since", internal.ShortID(id)))
+	}
+
+	remaining := countHistory(snap.History)
+	for _, h := range now.History {
+		k := historyKey(h)
+		if remaining[k] == 0 {
+			return ergo.New(fmt.Sprintf("[%s] has changed since", internal.ShortID(h.CommentID)))
+		}
+		remaining[k]--
+	}
+	if len(now.History) != len(snap.History) {
+		return ergo.New("the thread's history has changed since")
+	}
+	return nil
+}
+
+// filesByComment groups comment files by the comment they belong to.
+func filesByComment(files []db.CommentFile) map[uuid.UUID][]db.CommentFile {
+	byComment := map[uuid.UUID][]db.CommentFile{}
+	for _, f := range files {
+		byComment[f.CommentID] = append(byComment[f.CommentID], f)
+	}
+	return byComment
+}
+
+// historyKey identifies a history row by its content.
+func historyKey(h db.CommentHistory) db.CommentHistory {
+	h.ID = 0
+	return h
+}
+
+// countHistory counts history rows by content.
+func countHistory(history []db.CommentHistory) map[db.CommentHistory]int {
+	counts := map[db.CommentHistory]int{}
+	for _, h := range history {
+		counts[historyKey(h)]++
+	}
+	return counts
+}
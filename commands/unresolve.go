@@ -2,11 +2,12 @@ package commands
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/FujishigeTemma/git-review/internal"
+	"github.com/FujishigeTemma/git-review/internal/db"
 	"github.com/FujishigeTemma/git-review/internal/git"
 	"github.com/FujishigeTemma/git-review/internal/output"
 	"github.com/FujishigeTemma/git-review/internal/repository"
@@ -14,7 +15,9 @@ import (
 )
 
 type UnresolveCmd struct {
-	ID string `arg:"" help:"ID (or prefix) of the thread to unresolve."`
+	IDs    []string `arg:"" optional:"" name:"id" help:"IDs (or prefixes) of the threads to unresolve." completion:"ids"`
+	Commit string   `help:"Unresolve all resolved threads on a commit (hash prefix)." name:"commit" xor:"scope" completion:"commits"`
+	All    bool     `help:"Unresolve all resolved threads in the review." name:"all" xor:"scope"`
 }
 
 func (c *UnresolveCmd) Run(g *git.Git, repo *repository.Repository, out *output.Output) error {
@@ -22,27 +25,113 @@ func (c *UnresolveCmd) Run(g *git.Git, repo *repository.Repository, out *output.
 		return err
 	}
 
+	if c.Commit != "" || c.All {
+		if len(c.IDs) > 0 {
+			return ergo.New("a comment ID cannot be combined with --commit or --all", slog.String("comment_id", c.IDs[0]))
+		}
+		return c.unresolveBatch(repo, out, g.Reviewer, defaultAuthor(g))
+	}
+	if len(c.IDs) == 0 {
+		return ergo.New("specify a comment ID, --commit <hash>, or --all")
+	}
+
 	ctx := context.Background()
-	q := repo.Queries()
+	now := time.Now().UTC().Format(time.RFC3339)
+	name := defaultAuthor(g)
 
-	comment, err := q.FindCommentByPrefix(ctx, sql.NullString{String: c.ID, Valid: true})
-	if err != nil {
-		return ergo.New("comment not found", slog.String("comment_id", c.ID))
-	}
+	var unresolved []db.Comment
+	var failed int
+	if err := repo.WithTx(ctx, func(q *db.Queries) error {
+		var err error
+		unresolved, failed, err = findComments(ctx, q, out, c.IDs, func(comment db.Comment) error {
+			if comment.ParentID.Valid {
+				return ergo.New("only root comments can be unresolved", slog.String("comment_id", comment.ID.String()))
+			}
+			if !comment.ResolvedAt.Valid {
+				return ergo.New("thread is not resolved")
+			}
+			return nil
+		})
+		if err != nil || len(unresolved) == 0 {
+			return err
+		}
 
-	if comment.ParentID.Valid {
-		return ergo.New("only root comments can be unresolved", slog.String("comment_id", c.ID))
+		rec, err := snapshotThreads(ctx, q, commentIDs(unresolved)...)
+		if err != nil {
+			return err
+		}
+		for _, comment := range unresolved {
+			if err := unresolveThread(ctx, q, comment, name, now); err != nil {
+				return err
+			}
+		}
+		return logAction(ctx, q, g.Reviewer, name, "unresolve", commentsSummary("unresolve", unresolved, "thread", "threads"), rec)
+	}); err != nil {
+		return err
 	}
 
-	if !comment.ResolvedAt.Valid {
-		return ergo.New("thread is not resolved")
+	for _, comment := range unresolved {
+		out.Ok(fmt.Sprintf("Unresolved [%s]", internal.ShortID(comment.ID)))
+	}
+	if failed > 0 {
+		return batchFailed(failed, len(c.IDs), "threads could not be unresolved")
 	}
+	return nil
+}
+
+// unresolveBatch reopens every resolved root thread on the selected commit (or all commits)
+// in a single transaction, as name and logged for undo by reviewer. Threads that are
+// already unresolved are left untouched.
+func (c *UnresolveCmd) unresolveBatch(repo *repository.Repository, out *output.Output, reviewer, name string) error {
+	ctx := context.Background()
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	var n int
+	if err := repo.WithTx(ctx, func(q *db.Queries) error {
+		var commitSHA string
+		if c.Commit != "" {
+			target, err := findCommit(ctx, q, c.Commit)
+			if err != nil {
+				return err
+			}
+			commitSHA = target.Sha
+		}
 
-	if err := q.UnresolveComment(ctx, comment.ID); err != nil {
-		return ergo.Wrap(err, "failed to unresolve comment")
+		comments, err := q.ListAllComments(ctx)
+		if err != nil {
+			return ergo.Wrap(err, "failed to load comments")
+		}
+
+		var targets []db.Comment
+		for _, cm := range comments {
+			if cm.ParentID.Valid || !cm.ResolvedAt.Valid {
+				continue
+			}
+			if commitSHA != "" && cm.Commit.String != commitSHA {
+				continue
+			}
+			targets = append(targets, cm)
+		}
+		if len(targets) == 0 {
+			return nil
+		}
+
+		rec, err := snapshotThreads(ctx, q, commentIDs(targets)...)
+		if err != nil {
+			return err
+		}
+		for _, cm := range targets {
+			if err := unresolveThread(ctx, q, cm, name, now); err != nil {
+				return err
+			}
+			n++
+		}
+		return logAction(ctx, q, reviewer, name, "unresolve", commentsSummary("unresolve", targets, "thread", "threads"), rec)
+	}); err != nil {
+		return err
 	}
 
-	out.Ok(fmt.Sprintf("Unresolved [%s]", internal.ShortID(comment.ID)))
+	out.Ok(fmt.Sprintf("Unresolved %d %s", n, internal.Pluralize(n, "thread", "threads")))
 
 	return nil
 }
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/FujishigeTemma/git-review/internal/git"
+	"github.com/FujishigeTemma/git-review/internal/output"
+	"github.com/newmo-oss/ergo"
+)
+
+type WhoamiCmd struct{}
+
+// Run shows the reviewer identity derived from the current worktree, which add and
+// resolve attribute comments to unless -a or $GIT_REVIEW_AUTHOR says otherwise, and
+// where that worktree lives.
+func (c *WhoamiCmd) Run(g *git.Git, out *output.Output) error {
+	top, err := g.TopLevel()
+	if err != nil {
+		return ergo.Wrap(err, "failed to resolve worktree path")
+	}
+	reviewer := g.Reviewer
+	if reviewer == "" {
+		reviewer = "(default)"
+	}
+	out.Printf("reviewer   %s\n", reviewer)
+	if name := os.Getenv(authorEnv); name != "" {
+		out.Printf("author     %s (from %s)\n", name, authorEnv)
+	}
+	out.Printf("worktree   %s\n", top)
+	out.Printf("commonDir  %s\n", g.CommonDir)
+	return nil
+}
@@ -9,33 +9,79 @@ import (
 	null "github.com/guregu/null/v6"
 )
 
+type ActionLog struct {
+	ID        int64
+	Reviewer  string
+	Action    string
+	Summary   string
+	Data      string
+	CreatedAt string
+	Actor     string
+}
+
 type Comment struct {
-	ID         uuid.UUID
-	ParentID   uuid.NullUUID
-	Commit     string
-	File       null.String
-	StartLine  null.Int
-	EndLine    null.Int
-	Body       string
-	ResolvedAt null.String
-	ResolvedBy null.String
-	CreatedAt  string
-	CreatedBy  string
+	ID            uuid.UUID
+	ParentID      uuid.NullUUID
+	Commit        null.String
+	File          null.String
+	StartLine     null.Int
+	EndLine       null.Int
+	Body          string
+	ResolvedAt    null.String
+	ResolvedBy    null.String
+	CreatedAt     string
+	CreatedBy     string
+	NeedsResponse bool
+	Tree          null.String
+	Severity      null.String
+	AlsoCommit    null.String
+	Fixup         null.String
+	Assignee      null.String
+	OnBase        bool
+}
+
+type CommentFile struct {
+	CommentID uuid.UUID
+	Position  int64
+	File      string
+}
+
+type CommentHistory struct {
+	ID        int64
+	CommentID uuid.UUID
+	Action    string
+	Body      string
+	ChangedBy string
+	ChangedAt string
 }
 
 type Commit struct {
 	Sha      string
 	Message  string
 	Position int64
+	Author   null.String
 }
 
 type Reviewer struct {
 	Name       string
 	CurrentSha null.String
+	EnteredAt  null.String
+	Role       null.String
 }
 
 type Session struct {
-	BaseRef   string
-	Branch    string
-	CreatedAt string
+	BaseRef      string
+	Branch       string
+	CreatedAt    string
+	HeadSha      string
+	StashSha     null.String
+	ReturnBranch null.String
+	AnnotateOnly bool
+	BranchSha    null.String
+}
+
+type TimeSpent struct {
+	Reviewer string
+	Sha      string
+	Seconds  int64
 }
@@ -13,6 +13,35 @@ import (
 	null "github.com/guregu/null/v6"
 )
 
+const addTimeSpent = `-- name: AddTimeSpent :exec
+
+INSERT INTO time_spent (reviewer, sha, seconds) VALUES (?, ?, ?)
+ON CONFLICT (reviewer, sha) DO UPDATE SET seconds = seconds + excluded.seconds
+`
+
+type AddTimeSpentParams struct {
+	Reviewer string
+	Sha      string
+	Seconds  int64
+}
+
+// Time spent
+func (q *Queries) AddTimeSpent(ctx context.Context, arg AddTimeSpentParams) error {
+	_, err := q.db.ExecContext(ctx, addTimeSpent, arg.Reviewer, arg.Sha, arg.Seconds)
+	return err
+}
+
+const clearNeedsResponse = `-- name: ClearNeedsResponse :exec
+
+UPDATE comments SET needs_response = 0 WHERE id = ?
+`
+
+// Questions
+func (q *Queries) ClearNeedsResponse(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, clearNeedsResponse, id)
+	return err
+}
+
 const countCommits = `-- name: CountCommits :one
 SELECT COUNT(*) FROM commits
 `
@@ -24,6 +53,24 @@ func (q *Queries) CountCommits(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const deleteAction = `-- name: DeleteAction :exec
+DELETE FROM action_log WHERE id = ?
+`
+
+func (q *Queries) DeleteAction(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteAction, id)
+	return err
+}
+
+const deleteAllActions = `-- name: DeleteAllActions :exec
+DELETE FROM action_log
+`
+
+func (q *Queries) DeleteAllActions(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllActions)
+	return err
+}
+
 const deleteAllComments = `-- name: DeleteAllComments :exec
 DELETE FROM comments
 `
@@ -42,6 +89,24 @@ func (q *Queries) DeleteComment(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteCommentFiles = `-- name: DeleteCommentFiles :exec
+DELETE FROM comment_files WHERE comment_id = ?
+`
+
+func (q *Queries) DeleteCommentFiles(ctx context.Context, commentID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteCommentFiles, commentID)
+	return err
+}
+
+const deleteCommentHistory = `-- name: DeleteCommentHistory :exec
+DELETE FROM comment_history WHERE id = ?
+`
+
+func (q *Queries) DeleteCommentHistory(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteCommentHistory, id)
+	return err
+}
+
 const deleteCommits = `-- name: DeleteCommits :exec
 DELETE FROM commits
 `
@@ -69,43 +134,96 @@ func (q *Queries) DeleteSession(ctx context.Context) error {
 	return err
 }
 
-const findCommentByPrefix = `-- name: FindCommentByPrefix :one
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
-FROM comments WHERE id LIKE ?||'%'
+const deleteTimeSpent = `-- name: DeleteTimeSpent :exec
+DELETE FROM time_spent
 `
 
-func (q *Queries) FindCommentByPrefix(ctx context.Context, dollar_1 sql.NullString) (Comment, error) {
-	row := q.db.QueryRowContext(ctx, findCommentByPrefix, dollar_1)
-	var i Comment
-	err := row.Scan(
-		&i.ID,
-		&i.ParentID,
-		&i.Commit,
-		&i.File,
-		&i.StartLine,
-		&i.EndLine,
-		&i.Body,
-		&i.ResolvedAt,
-		&i.ResolvedBy,
-		&i.CreatedAt,
-		&i.CreatedBy,
-	)
-	return i, err
+func (q *Queries) DeleteTimeSpent(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteTimeSpent)
+	return err
 }
 
-const findCommitBySHAPrefix = `-- name: FindCommitBySHAPrefix :one
-SELECT sha, message, position FROM commits WHERE sha LIKE ?||'%'
+const findCommentsByPrefix = `-- name: FindCommentsByPrefix :many
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
+FROM comments WHERE id LIKE ?||'%' ORDER BY id
 `
 
-func (q *Queries) FindCommitBySHAPrefix(ctx context.Context, dollar_1 sql.NullString) (Commit, error) {
-	row := q.db.QueryRowContext(ctx, findCommitBySHAPrefix, dollar_1)
-	var i Commit
-	err := row.Scan(&i.Sha, &i.Message, &i.Position)
-	return i, err
+func (q *Queries) FindCommentsByPrefix(ctx context.Context, dollar_1 sql.NullString) ([]Comment, error) {
+	rows, err := q.db.QueryContext(ctx, findCommentsByPrefix, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Comment
+	for rows.Next() {
+		var i Comment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentID,
+			&i.Commit,
+			&i.File,
+			&i.StartLine,
+			&i.EndLine,
+			&i.Body,
+			&i.ResolvedAt,
+			&i.ResolvedBy,
+			&i.CreatedAt,
+			&i.CreatedBy,
+			&i.NeedsResponse,
+			&i.Tree,
+			&i.Severity,
+			&i.AlsoCommit,
+			&i.Fixup,
+			&i.Assignee,
+			&i.OnBase,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findCommitsBySHAPrefix = `-- name: FindCommitsBySHAPrefix :many
+SELECT sha, message, position, author FROM commits WHERE sha LIKE ?||'%' ORDER BY position
+`
+
+func (q *Queries) FindCommitsBySHAPrefix(ctx context.Context, dollar_1 sql.NullString) ([]Commit, error) {
+	rows, err := q.db.QueryContext(ctx, findCommitsBySHAPrefix, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Commit
+	for rows.Next() {
+		var i Commit
+		if err := rows.Scan(
+			&i.Sha,
+			&i.Message,
+			&i.Position,
+			&i.Author,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getComment = `-- name: GetComment :one
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments WHERE id = ?
 `
 
@@ -124,72 +242,156 @@ func (q *Queries) GetComment(ctx context.Context, id uuid.UUID) (Comment, error)
 		&i.ResolvedBy,
 		&i.CreatedAt,
 		&i.CreatedBy,
+		&i.NeedsResponse,
+		&i.Tree,
+		&i.Severity,
+		&i.AlsoCommit,
+		&i.Fixup,
+		&i.Assignee,
+		&i.OnBase,
 	)
 	return i, err
 }
 
 const getCommitByPosition = `-- name: GetCommitByPosition :one
-SELECT sha, message, position FROM commits WHERE position = ?
+SELECT sha, message, position, author FROM commits WHERE position = ?
 `
 
 func (q *Queries) GetCommitByPosition(ctx context.Context, position int64) (Commit, error) {
 	row := q.db.QueryRowContext(ctx, getCommitByPosition, position)
 	var i Commit
-	err := row.Scan(&i.Sha, &i.Message, &i.Position)
+	err := row.Scan(
+		&i.Sha,
+		&i.Message,
+		&i.Position,
+		&i.Author,
+	)
 	return i, err
 }
 
 const getCommitBySHA = `-- name: GetCommitBySHA :one
-SELECT sha, message, position FROM commits WHERE sha = ?
+SELECT sha, message, position, author FROM commits WHERE sha = ?
 `
 
 func (q *Queries) GetCommitBySHA(ctx context.Context, sha string) (Commit, error) {
 	row := q.db.QueryRowContext(ctx, getCommitBySHA, sha)
 	var i Commit
-	err := row.Scan(&i.Sha, &i.Message, &i.Position)
+	err := row.Scan(
+		&i.Sha,
+		&i.Message,
+		&i.Position,
+		&i.Author,
+	)
+	return i, err
+}
+
+const getLastAction = `-- name: GetLastAction :one
+SELECT id, reviewer, action, summary, data, created_at, actor FROM action_log WHERE reviewer = ? ORDER BY id DESC LIMIT 1
+`
+
+func (q *Queries) GetLastAction(ctx context.Context, reviewer string) (ActionLog, error) {
+	row := q.db.QueryRowContext(ctx, getLastAction, reviewer)
+	var i ActionLog
+	err := row.Scan(
+		&i.ID,
+		&i.Reviewer,
+		&i.Action,
+		&i.Summary,
+		&i.Data,
+		&i.CreatedAt,
+		&i.Actor,
+	)
 	return i, err
 }
 
 const getReviewer = `-- name: GetReviewer :one
-SELECT name, current_sha FROM reviewers WHERE name = ?
+SELECT name, current_sha, entered_at, role FROM reviewers WHERE name = ?
 `
 
 func (q *Queries) GetReviewer(ctx context.Context, name string) (Reviewer, error) {
 	row := q.db.QueryRowContext(ctx, getReviewer, name)
 	var i Reviewer
-	err := row.Scan(&i.Name, &i.CurrentSha)
+	err := row.Scan(
+		&i.Name,
+		&i.CurrentSha,
+		&i.EnteredAt,
+		&i.Role,
+	)
 	return i, err
 }
 
 const getSession = `-- name: GetSession :one
-SELECT base_ref, branch, created_at FROM session LIMIT 1
+SELECT base_ref, branch, created_at, head_sha, stash_sha, return_branch, annotate_only, branch_sha FROM session LIMIT 1
 `
 
 func (q *Queries) GetSession(ctx context.Context) (Session, error) {
 	row := q.db.QueryRowContext(ctx, getSession)
 	var i Session
-	err := row.Scan(&i.BaseRef, &i.Branch, &i.CreatedAt)
+	err := row.Scan(
+		&i.BaseRef,
+		&i.Branch,
+		&i.CreatedAt,
+		&i.HeadSha,
+		&i.StashSha,
+		&i.ReturnBranch,
+		&i.AnnotateOnly,
+		&i.BranchSha,
+	)
 	return i, err
 }
 
+const insertAction = `-- name: InsertAction :exec
+
+INSERT INTO action_log (reviewer, action, summary, data, created_at, actor) VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type InsertActionParams struct {
+	Reviewer  string
+	Action    string
+	Summary   string
+	Data      string
+	CreatedAt string
+	Actor     string
+}
+
+// Action log
+func (q *Queries) InsertAction(ctx context.Context, arg InsertActionParams) error {
+	_, err := q.db.ExecContext(ctx, insertAction,
+		arg.Reviewer,
+		arg.Action,
+		arg.Summary,
+		arg.Data,
+		arg.CreatedAt,
+		arg.Actor,
+	)
+	return err
+}
+
 const insertComment = `-- name: InsertComment :exec
 
-INSERT INTO comments (id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+INSERT INTO comments (id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type InsertCommentParams struct {
-	ID         uuid.UUID
-	ParentID   uuid.NullUUID
-	Commit     string
-	File       null.String
-	StartLine  null.Int
-	EndLine    null.Int
-	Body       string
-	ResolvedAt null.String
-	ResolvedBy null.String
-	CreatedAt  string
-	CreatedBy  string
+	ID            uuid.UUID
+	ParentID      uuid.NullUUID
+	Commit        null.String
+	File          null.String
+	StartLine     null.Int
+	EndLine       null.Int
+	Body          string
+	ResolvedAt    null.String
+	ResolvedBy    null.String
+	CreatedAt     string
+	CreatedBy     string
+	NeedsResponse bool
+	Tree          null.String
+	Severity      null.String
+	AlsoCommit    null.String
+	Fixup         null.String
+	Assignee      null.String
+	OnBase        bool
 }
 
 // Comments
@@ -206,62 +408,166 @@ func (q *Queries) InsertComment(ctx context.Context, arg InsertCommentParams) er
 		arg.ResolvedBy,
 		arg.CreatedAt,
 		arg.CreatedBy,
+		arg.NeedsResponse,
+		arg.Tree,
+		arg.Severity,
+		arg.AlsoCommit,
+		arg.Fixup,
+		arg.Assignee,
+		arg.OnBase,
+	)
+	return err
+}
+
+const insertCommentFile = `-- name: InsertCommentFile :exec
+
+INSERT INTO comment_files (comment_id, position, file) VALUES (?, ?, ?)
+`
+
+type InsertCommentFileParams struct {
+	CommentID uuid.UUID
+	Position  int64
+	File      string
+}
+
+// Comment files
+func (q *Queries) InsertCommentFile(ctx context.Context, arg InsertCommentFileParams) error {
+	_, err := q.db.ExecContext(ctx, insertCommentFile, arg.CommentID, arg.Position, arg.File)
+	return err
+}
+
+const insertCommentHistory = `-- name: InsertCommentHistory :exec
+
+INSERT INTO comment_history (comment_id, action, body, changed_by, changed_at) VALUES (?, ?, ?, ?, ?)
+`
+
+type InsertCommentHistoryParams struct {
+	CommentID uuid.UUID
+	Action    string
+	Body      string
+	ChangedBy string
+	ChangedAt string
+}
+
+// Comment history
+func (q *Queries) InsertCommentHistory(ctx context.Context, arg InsertCommentHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, insertCommentHistory,
+		arg.CommentID,
+		arg.Action,
+		arg.Body,
+		arg.ChangedBy,
+		arg.ChangedAt,
 	)
 	return err
 }
 
 const insertCommit = `-- name: InsertCommit :exec
 
-INSERT INTO commits (sha, message, position) VALUES (?, ?, ?)
+INSERT INTO commits (sha, message, position, author) VALUES (?, ?, ?, ?)
 `
 
 type InsertCommitParams struct {
 	Sha      string
 	Message  string
 	Position int64
+	Author   null.String
 }
 
 // Commits
 func (q *Queries) InsertCommit(ctx context.Context, arg InsertCommitParams) error {
-	_, err := q.db.ExecContext(ctx, insertCommit, arg.Sha, arg.Message, arg.Position)
+	_, err := q.db.ExecContext(ctx, insertCommit,
+		arg.Sha,
+		arg.Message,
+		arg.Position,
+		arg.Author,
+	)
 	return err
 }
 
 const insertReviewer = `-- name: InsertReviewer :exec
 
-INSERT INTO reviewers (name, current_sha) VALUES (?, ?)
+INSERT INTO reviewers (name, current_sha, role) VALUES (?, ?, ?)
 `
 
 type InsertReviewerParams struct {
 	Name       string
 	CurrentSha null.String
+	Role       null.String
 }
 
 // Reviewers
 func (q *Queries) InsertReviewer(ctx context.Context, arg InsertReviewerParams) error {
-	_, err := q.db.ExecContext(ctx, insertReviewer, arg.Name, arg.CurrentSha)
+	_, err := q.db.ExecContext(ctx, insertReviewer, arg.Name, arg.CurrentSha, arg.Role)
 	return err
 }
 
 const insertSession = `-- name: InsertSession :exec
 
-INSERT INTO session (base_ref, branch, created_at) VALUES (?, ?, ?)
+INSERT INTO session (base_ref, branch, created_at, head_sha, stash_sha, return_branch, annotate_only, branch_sha) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type InsertSessionParams struct {
-	BaseRef   string
-	Branch    string
-	CreatedAt string
+	BaseRef      string
+	Branch       string
+	CreatedAt    string
+	HeadSha      string
+	StashSha     null.String
+	ReturnBranch null.String
+	AnnotateOnly bool
+	BranchSha    null.String
 }
 
 // Session
 func (q *Queries) InsertSession(ctx context.Context, arg InsertSessionParams) error {
-	_, err := q.db.ExecContext(ctx, insertSession, arg.BaseRef, arg.Branch, arg.CreatedAt)
+	_, err := q.db.ExecContext(ctx, insertSession,
+		arg.BaseRef,
+		arg.Branch,
+		arg.CreatedAt,
+		arg.HeadSha,
+		arg.StashSha,
+		arg.ReturnBranch,
+		arg.AnnotateOnly,
+		arg.BranchSha,
+	)
 	return err
 }
 
+const listAllCommentHistory = `-- name: ListAllCommentHistory :many
+SELECT id, comment_id, action, body, changed_by, changed_at FROM comment_history ORDER BY id
+`
+
+func (q *Queries) ListAllCommentHistory(ctx context.Context) ([]CommentHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listAllCommentHistory)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommentHistory
+	for rows.Next() {
+		var i CommentHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.CommentID,
+			&i.Action,
+			&i.Body,
+			&i.ChangedBy,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAllComments = `-- name: ListAllComments :many
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments
 `
 
@@ -286,6 +592,74 @@ func (q *Queries) ListAllComments(ctx context.Context) ([]Comment, error) {
 			&i.ResolvedBy,
 			&i.CreatedAt,
 			&i.CreatedBy,
+			&i.NeedsResponse,
+			&i.Tree,
+			&i.Severity,
+			&i.AlsoCommit,
+			&i.Fixup,
+			&i.Assignee,
+			&i.OnBase,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommentFiles = `-- name: ListCommentFiles :many
+SELECT comment_id, position, file FROM comment_files ORDER BY comment_id, position
+`
+
+func (q *Queries) ListCommentFiles(ctx context.Context) ([]CommentFile, error) {
+	rows, err := q.db.QueryContext(ctx, listCommentFiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommentFile
+	for rows.Next() {
+		var i CommentFile
+		if err := rows.Scan(&i.CommentID, &i.Position, &i.File); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommentHistory = `-- name: ListCommentHistory :many
+SELECT id, comment_id, action, body, changed_by, changed_at FROM comment_history WHERE comment_id = ? ORDER BY id
+`
+
+func (q *Queries) ListCommentHistory(ctx context.Context, commentID uuid.UUID) ([]CommentHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listCommentHistory, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommentHistory
+	for rows.Next() {
+		var i CommentHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.CommentID,
+			&i.Action,
+			&i.Body,
+			&i.ChangedBy,
+			&i.ChangedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -301,11 +675,11 @@ func (q *Queries) ListAllComments(ctx context.Context) ([]Comment, error) {
 }
 
 const listCommentsByCommit = `-- name: ListCommentsByCommit :many
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments WHERE "commit" = ?
 `
 
-func (q *Queries) ListCommentsByCommit(ctx context.Context, commit string) ([]Comment, error) {
+func (q *Queries) ListCommentsByCommit(ctx context.Context, commit null.String) ([]Comment, error) {
 	rows, err := q.db.QueryContext(ctx, listCommentsByCommit, commit)
 	if err != nil {
 		return nil, err
@@ -326,6 +700,13 @@ func (q *Queries) ListCommentsByCommit(ctx context.Context, commit string) ([]Co
 			&i.ResolvedBy,
 			&i.CreatedAt,
 			&i.CreatedBy,
+			&i.NeedsResponse,
+			&i.Tree,
+			&i.Severity,
+			&i.AlsoCommit,
+			&i.Fixup,
+			&i.Assignee,
+			&i.OnBase,
 		); err != nil {
 			return nil, err
 		}
@@ -341,7 +722,7 @@ func (q *Queries) ListCommentsByCommit(ctx context.Context, commit string) ([]Co
 }
 
 const listCommentsByCreator = `-- name: ListCommentsByCreator :many
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments WHERE created_by = ?
 `
 
@@ -366,6 +747,13 @@ func (q *Queries) ListCommentsByCreator(ctx context.Context, createdBy string) (
 			&i.ResolvedBy,
 			&i.CreatedAt,
 			&i.CreatedBy,
+			&i.NeedsResponse,
+			&i.Tree,
+			&i.Severity,
+			&i.AlsoCommit,
+			&i.Fixup,
+			&i.Assignee,
+			&i.OnBase,
 		); err != nil {
 			return nil, err
 		}
@@ -381,7 +769,7 @@ func (q *Queries) ListCommentsByCreator(ctx context.Context, createdBy string) (
 }
 
 const listCommentsByFile = `-- name: ListCommentsByFile :many
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments WHERE file = ?
 `
 
@@ -406,6 +794,13 @@ func (q *Queries) ListCommentsByFile(ctx context.Context, file null.String) ([]C
 			&i.ResolvedBy,
 			&i.CreatedAt,
 			&i.CreatedBy,
+			&i.NeedsResponse,
+			&i.Tree,
+			&i.Severity,
+			&i.AlsoCommit,
+			&i.Fixup,
+			&i.Assignee,
+			&i.OnBase,
 		); err != nil {
 			return nil, err
 		}
@@ -421,7 +816,7 @@ func (q *Queries) ListCommentsByFile(ctx context.Context, file null.String) ([]C
 }
 
 const listCommits = `-- name: ListCommits :many
-SELECT sha, message, position FROM commits ORDER BY position
+SELECT sha, message, position, author FROM commits ORDER BY position
 `
 
 func (q *Queries) ListCommits(ctx context.Context) ([]Commit, error) {
@@ -433,7 +828,12 @@ func (q *Queries) ListCommits(ctx context.Context) ([]Commit, error) {
 	var items []Commit
 	for rows.Next() {
 		var i Commit
-		if err := rows.Scan(&i.Sha, &i.Message, &i.Position); err != nil {
+		if err := rows.Scan(
+			&i.Sha,
+			&i.Message,
+			&i.Position,
+			&i.Author,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -448,7 +848,7 @@ func (q *Queries) ListCommits(ctx context.Context) ([]Commit, error) {
 }
 
 const listReviewers = `-- name: ListReviewers :many
-SELECT name, current_sha FROM reviewers
+SELECT name, current_sha, entered_at, role FROM reviewers
 `
 
 func (q *Queries) ListReviewers(ctx context.Context) ([]Reviewer, error) {
@@ -460,7 +860,39 @@ func (q *Queries) ListReviewers(ctx context.Context) ([]Reviewer, error) {
 	var items []Reviewer
 	for rows.Next() {
 		var i Reviewer
-		if err := rows.Scan(&i.Name, &i.CurrentSha); err != nil {
+		if err := rows.Scan(
+			&i.Name,
+			&i.CurrentSha,
+			&i.EnteredAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTimeSpent = `-- name: ListTimeSpent :many
+SELECT reviewer, sha, seconds FROM time_spent
+`
+
+func (q *Queries) ListTimeSpent(ctx context.Context) ([]TimeSpent, error) {
+	rows, err := q.db.QueryContext(ctx, listTimeSpent)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TimeSpent
+	for rows.Next() {
+		var i TimeSpent
+		if err := rows.Scan(&i.Reviewer, &i.Sha, &i.Seconds); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -476,7 +908,7 @@ func (q *Queries) ListReviewers(ctx context.Context) ([]Reviewer, error) {
 
 const listUnresolvedRoots = `-- name: ListUnresolvedRoots :many
 
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments WHERE parent_id IS NULL AND resolved_at IS NULL
 `
 
@@ -502,6 +934,13 @@ func (q *Queries) ListUnresolvedRoots(ctx context.Context) ([]Comment, error) {
 			&i.ResolvedBy,
 			&i.CreatedAt,
 			&i.CreatedBy,
+			&i.NeedsResponse,
+			&i.Tree,
+			&i.Severity,
+			&i.AlsoCommit,
+			&i.Fixup,
+			&i.Assignee,
+			&i.OnBase,
 		); err != nil {
 			return nil, err
 		}
@@ -516,6 +955,51 @@ func (q *Queries) ListUnresolvedRoots(ctx context.Context) ([]Comment, error) {
 	return items, nil
 }
 
+const moveCommentFiles = `-- name: MoveCommentFiles :exec
+UPDATE comment_files SET comment_id = ? WHERE comment_id = ?
+`
+
+type MoveCommentFilesParams struct {
+	CommentID   uuid.UUID
+	CommentID_2 uuid.UUID
+}
+
+func (q *Queries) MoveCommentFiles(ctx context.Context, arg MoveCommentFilesParams) error {
+	_, err := q.db.ExecContext(ctx, moveCommentFiles, arg.CommentID, arg.CommentID_2)
+	return err
+}
+
+const promoteComment = `-- name: PromoteComment :exec
+UPDATE comments SET parent_id = NULL, resolved_at = ?, resolved_by = ?, needs_response = ?, severity = ?, also_commit = ?, fixup = ?, assignee = ?, on_base = ? WHERE id = ?
+`
+
+type PromoteCommentParams struct {
+	ResolvedAt    null.String
+	ResolvedBy    null.String
+	NeedsResponse bool
+	Severity      null.String
+	AlsoCommit    null.String
+	Fixup         null.String
+	Assignee      null.String
+	OnBase        bool
+	ID            uuid.UUID
+}
+
+func (q *Queries) PromoteComment(ctx context.Context, arg PromoteCommentParams) error {
+	_, err := q.db.ExecContext(ctx, promoteComment,
+		arg.ResolvedAt,
+		arg.ResolvedBy,
+		arg.NeedsResponse,
+		arg.Severity,
+		arg.AlsoCommit,
+		arg.Fixup,
+		arg.Assignee,
+		arg.OnBase,
+		arg.ID,
+	)
+	return err
+}
+
 const reparentChildren = `-- name: ReparentChildren :exec
 UPDATE comments SET parent_id = ? WHERE parent_id = ?
 `
@@ -547,6 +1031,39 @@ func (q *Queries) ResolveComment(ctx context.Context, arg ResolveCommentParams)
 	return err
 }
 
+const restoreComment = `-- name: RestoreComment :exec
+UPDATE comments SET parent_id = ?, resolved_at = ?, resolved_by = ?, needs_response = ?, severity = ?, also_commit = ?, fixup = ?, assignee = ?, on_base = ? WHERE id = ?
+`
+
+type RestoreCommentParams struct {
+	ParentID      uuid.NullUUID
+	ResolvedAt    null.String
+	ResolvedBy    null.String
+	NeedsResponse bool
+	Severity      null.String
+	AlsoCommit    null.String
+	Fixup         null.String
+	Assignee      null.String
+	OnBase        bool
+	ID            uuid.UUID
+}
+
+func (q *Queries) RestoreComment(ctx context.Context, arg RestoreCommentParams) error {
+	_, err := q.db.ExecContext(ctx, restoreComment,
+		arg.ParentID,
+		arg.ResolvedAt,
+		arg.ResolvedBy,
+		arg.NeedsResponse,
+		arg.Severity,
+		arg.AlsoCommit,
+		arg.Fixup,
+		arg.Assignee,
+		arg.OnBase,
+		arg.ID,
+	)
+	return err
+}
+
 const sessionExists = `-- name: SessionExists :one
 SELECT COUNT(*) FROM session
 `
@@ -567,16 +1084,60 @@ func (q *Queries) UnresolveComment(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const updateCommentCommit = `-- name: UpdateCommentCommit :exec
+UPDATE comments SET "commit" = ?, tree = ? WHERE id = ?
+`
+
+type UpdateCommentCommitParams struct {
+	Commit null.String
+	Tree   null.String
+	ID     uuid.UUID
+}
+
+func (q *Queries) UpdateCommentCommit(ctx context.Context, arg UpdateCommentCommitParams) error {
+	_, err := q.db.ExecContext(ctx, updateCommentCommit, arg.Commit, arg.Tree, arg.ID)
+	return err
+}
+
+const updateCommitPosition = `-- name: UpdateCommitPosition :exec
+UPDATE commits SET position = ? WHERE sha = ?
+`
+
+type UpdateCommitPositionParams struct {
+	Position int64
+	Sha      string
+}
+
+func (q *Queries) UpdateCommitPosition(ctx context.Context, arg UpdateCommitPositionParams) error {
+	_, err := q.db.ExecContext(ctx, updateCommitPosition, arg.Position, arg.Sha)
+	return err
+}
+
 const updateReviewerCurrent = `-- name: UpdateReviewerCurrent :exec
-UPDATE reviewers SET current_sha = ? WHERE name = ?
+UPDATE reviewers SET current_sha = ?, entered_at = ? WHERE name = ?
 `
 
 type UpdateReviewerCurrentParams struct {
 	CurrentSha null.String
+	EnteredAt  null.String
 	Name       string
 }
 
 func (q *Queries) UpdateReviewerCurrent(ctx context.Context, arg UpdateReviewerCurrentParams) error {
-	_, err := q.db.ExecContext(ctx, updateReviewerCurrent, arg.CurrentSha, arg.Name)
+	_, err := q.db.ExecContext(ctx, updateReviewerCurrent, arg.CurrentSha, arg.EnteredAt, arg.Name)
+	return err
+}
+
+const updateReviewerRole = `-- name: UpdateReviewerRole :exec
+UPDATE reviewers SET role = ? WHERE name = ?
+`
+
+type UpdateReviewerRoleParams struct {
+	Role null.String
+	Name string
+}
+
+func (q *Queries) UpdateReviewerRole(ctx context.Context, arg UpdateReviewerRoleParams) error {
+	_, err := q.db.ExecContext(ctx, updateReviewerRole, arg.Role, arg.Name)
 	return err
 }
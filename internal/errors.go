@@ -1,6 +1,12 @@
 package internal
 
-import "github.com/newmo-oss/ergo"
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/newmo-oss/ergo"
+)
 
 var (
 	ErrCodeNotInRepo      = ergo.NewCode("NotInRepo", "not in a git repository")
@@ -11,5 +17,61 @@ var (
 	ErrCodeNoCommits      = ergo.NewCode("NoCommits", "no commits to review")
 	ErrCodeDetachedHead   = ergo.NewCode("DetachedHead", "detached HEAD state")
 	ErrCodeWrongWorktree  = ergo.NewCode("WrongWorktree", "must run from main worktree")
+	ErrCodeStaleCommit    = ergo.NewCode("StaleCommit", "reviewed commit no longer exists")
+	ErrCodeUnresolved     = ergo.NewCode("Unresolved", "unresolved threads remain")
 )
 
+
+// UserMessage returns the error message without the "CodeName: " prefix
+// that ergo.WithCode adds, for showing to users.
+func UserMessage(err error) string {
+	msg := err.Error()
+	if code := ergo.CodeOf(err); !code.IsZero() {
+		prefix := code.String() + ": "
+		if rest, ok := strings.CutPrefix(msg, prefix); ok && rest != "" {
+			msg = rest
+		}
+	}
+	return msg
+}
+
+// ErrorJSON returns err as {"error": {"code": "...", "message": "..."}}, for scripts
+// that pass --json. The code is the ergo code name, e.g. "NoReview", and is left out for
+// errors without one; the message is the one UserMessage shows.
+func ErrorJSON(err error) []byte {
+	type errorBody struct {
+		Code    string `json:"code,omitempty"`
+		Message string `json:"message"`
+	}
+	var body errorBody
+	if code := ergo.CodeOf(err); !code.IsZero() {
+		body.Code = code.Key()
+	}
+	body.Message = UserMessage(err)
+	data, _ := json.Marshal(map[string]errorBody{"error": body})
+	return data
+}
+
+// ExitCode returns the process exit status for err: 2 when a review gate failed
+// because threads are unresolved, so CI can tell it apart from a failed command, else 1.
+func ExitCode(err error) int {
+	if ergo.CodeOf(err) == ErrCodeUnresolved {
+		return 2
+	}
+	return 1
+}
+
+type silentError struct{ error }
+
+func (e silentError) Unwrap() error { return e.error }
+
+// Silent marks err as reported through the exit status alone, so no message is printed.
+func Silent(err error) error {
+	return silentError{err}
+}
+
+// IsSilent reports whether err was marked with Silent.
+func IsSilent(err error) bool {
+	var s silentError
+	return errors.As(err, &s)
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/guregu/null/v6"
 )
@@ -32,6 +33,25 @@ func ShortID(id fmt.Stringer) string {
 	return s
 }
 
+// UniquePrefixes returns, for each string, its shortest prefix of at least minLen characters
+// that no other string in the list shares.
+func UniquePrefixes(ss []string, minLen int) []string {
+	prefixes := make([]string, len(ss))
+	for i, s := range ss {
+		n := min(minLen, len(s))
+		for j, other := range ss {
+			if i == j {
+				continue
+			}
+			for n < len(s) && strings.HasPrefix(other, s[:n]) {
+				n++
+			}
+		}
+		prefixes[i] = s[:n]
+	}
+	return prefixes
+}
+
 // FormatLineRange formats null.Int start/end as "N" or "N-M".
 // Returns "" if start is null.
 func FormatLineRange(startLine, endLine null.Int) string {
@@ -89,6 +89,12 @@ func TestFormatLineRange(t *testing.T) {
 			end:   null.Int{},
 			want:  "10",
 		},
+		{
+			name:  "start null end valid",
+			start: null.Int{},
+			end:   null.IntFrom(25),
+			want:  "",
+		},
 		{
 			name:  "both valid same",
 			start: null.IntFrom(5),
@@ -111,3 +117,28 @@ func TestFormatLineRange(t *testing.T) {
 		})
 	}
 }
+
+func TestUniquePrefixes(t *testing.T) {
+	tests := []struct {
+		name   string
+		ss     []string
+		minLen int
+		want   []string
+	}{
+		{"distinct at min length", []string{"abc123", "abd456"}, 2, []string{"abc", "abd"}},
+		{"already unique", []string{"abc123", "xyz456"}, 3, []string{"abc", "xyz"}},
+		{"shared long prefix", []string{"0194b5a0-1234", "0194b5a0-1299", "0194b5a1-0000"}, 8, []string{"0194b5a0-123", "0194b5a0-129", "0194b5a1"}},
+		{"one is prefix of another", []string{"abc", "abcdef"}, 2, []string{"abc", "abcd"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UniquePrefixes(tt.ss, tt.minLen)
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("UniquePrefixes(%q, %d) = %q, want %q", tt.ss, tt.minLen, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
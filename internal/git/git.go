@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/newmo-oss/ergo"
@@ -15,11 +17,57 @@ type Git struct {
 	WorkDir   string
 	CommonDir string // Absolute path to shared .git directory.
 	Reviewer  string // Worktree name. Empty string for main worktree.
+
+	diffs *diffCache // parsed diffs, shared with worktree Gits
+}
+
+// diffKey identifies a diff: the changes from parent to sha limited to file.
+// An empty parent means the commit's own parent; an empty file means all files.
+type diffKey struct{ parent, sha, file string }
+
+// diffCache memoizes diffs and the file contents quoted next to them for the lifetime
+// of a command, so features that look at the same commit's changes do not each run git
+// again. A long-running process calls ResetCache between commands.
+type diffCache struct {
+	text  map[diffKey]cached[string]   // Diff and FileDiff
+	hunks map[diffKey]cached[[]Hunk]   // Hunks
+	lines map[diffKey]cached[[]string] // FileLines, keyed by sha and file
+}
+
+// cached is a memoized result, failures included.
+type cached[T any] struct {
+	value T
+	err   error
+}
+
+func newDiffCache() *diffCache {
+	return &diffCache{
+		text:  map[diffKey]cached[string]{},
+		hunks: map[diffKey]cached[[]Hunk]{},
+		lines: map[diffKey]cached[[]string]{},
+	}
+}
+
+// memo returns the result stored under key, computing and storing it on first use.
+func memo[T any](m map[diffKey]cached[T], key diffKey, compute func() (T, error)) (T, error) {
+	if c, ok := m[key]; ok {
+		return c.value, c.err
+	}
+	value, err := compute()
+	m[key] = cached[T]{value: value, err: err}
+	return value, err
+}
+
+// execGit runs git in dir and returns its stdout; tests replace it to count invocations.
+var execGit = func(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	return cmd.Output()
 }
 
 // New creates a Git instance, resolving CommonDir and Reviewer at construction time.
 func New(workDir string) (*Git, error) {
-	g := &Git{WorkDir: workDir}
+	g := &Git{WorkDir: workDir, diffs: newDiffCache()}
 
 	commonDir, err := g.Run("rev-parse", "--git-common-dir")
 	if err != nil {
@@ -51,20 +99,25 @@ func (g *Git) ForWorktree(name, path string) *Git {
 		WorkDir:   path,
 		CommonDir: g.CommonDir,
 		Reviewer:  name,
+		diffs:     g.diffs,
 	}
 }
 
 // Run executes a git command and returns trimmed stdout.
 func (g *Git) Run(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
-	cmd.Dir = g.WorkDir
-	out, err := cmd.Output()
+	out, err := g.output(args...)
+	return strings.TrimSpace(out), err
+}
+
+// output executes a git command and returns its stdout as is.
+func (g *Git) output(args ...string) (string, error) {
+	out, err := execGit(g.WorkDir, args...)
 	if err != nil {
 		return "", ergo.Wrap(err, "git command failed",
 			slog.String("args", strings.Join(args, " ")),
 			slog.String("work_dir", g.WorkDir))
 	}
-	return strings.TrimSpace(string(out)), nil
+	return string(out), nil
 }
 
 // RunSilent executes a git command, ignoring output. Returns error if non-zero exit.
@@ -83,6 +136,10 @@ func (g *Git) GitDir() (string, error) {
 	return g.Run("rev-parse", "--absolute-git-dir")
 }
 
+func (g *Git) TopLevel() (string, error) {
+	return g.Run("rev-parse", "--show-toplevel")
+}
+
 func (g *Git) CurrentBranch() (string, error) {
 	return g.Run("branch", "--show-current")
 }
@@ -91,6 +148,11 @@ func (g *Git) RefExists(ref string) bool {
 	return g.RunSilent("rev-parse", "--verify", ref) == nil
 }
 
+// CommitExists reports whether sha names a commit object in the repository.
+func (g *Git) CommitExists(sha string) bool {
+	return g.RunSilent("cat-file", "-e", sha+"^{commit}") == nil
+}
+
 func (g *Git) IsClean() (bool, error) {
 	if err := g.RunSilent("diff", "--cached", "--quiet"); err != nil {
 		var exitErr *exec.ExitError
@@ -109,20 +171,236 @@ func (g *Git) IsClean() (bool, error) {
 	return true, nil
 }
 
+// StashPush stashes uncommitted changes to tracked files, including the staged ones,
+// and returns the SHA of the stash entry.
+func (g *Git) StashPush(message string) (string, error) {
+	if err := g.RunSilent("stash", "push", "--quiet", "-m", message); err != nil {
+		return "", err
+	}
+	return g.Run("rev-parse", "refs/stash")
+}
+
+// StashPop applies the stash entry with the given SHA, restoring what was staged, and
+// drops it. The entry is kept if it does not apply cleanly.
+func (g *Git) StashPop(sha string) error {
+	out, err := g.Run("stash", "list", "--format=%H")
+	if err != nil {
+		return err
+	}
+	for i, entry := range strings.Split(out, "\n") {
+		if entry == sha {
+			return g.RunSilent("stash", "pop", "--index", "--quiet", "stash@{"+strconv.Itoa(i)+"}")
+		}
+	}
+	return ergo.New("stash entry not found", slog.String("sha", sha))
+}
+
+// IsAncestor reports whether ancestor is reachable from ref.
+func (g *Git) IsAncestor(ancestor, ref string) bool {
+	return g.RunSilent("merge-base", "--is-ancestor", ancestor, ref) == nil
+}
+
 func (g *Git) MergeBase(ref1, ref2 string) (string, error) {
 	return g.Run("merge-base", ref1, ref2)
 }
 
-// RevList returns commit SHAs in reverse chronological order (oldest first).
-func (g *Git) RevList(rangeSpec string) ([]string, error) {
-	out, err := g.Run("rev-list", "--reverse", rangeSpec)
+// RevList returns commit SHAs in reverse chronological order (oldest first). Options such
+// as --no-merges go before the range.
+func (g *Git) RevList(rangeSpec string, options ...string) ([]string, error) {
+	args := append(append([]string{"rev-list", "--reverse"}, options...), rangeSpec)
+	out, err := g.Run(args...)
 	if err != nil {
 		return nil, err
 	}
-	if out == "" {
+	return splitLines(out), nil
+}
+
+// RevListTopo returns the commits reachable from tips but not from exclude, oldest first
+// in topological order.
+func (g *Git) RevListTopo(exclude string, tips ...string) ([]string, error) {
+	args := append([]string{"rev-list", "--reverse", "--topo-order"}, tips...)
+	out, err := g.Run(append(args, "^"+exclude)...)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+// LogEntry is a commit SHA with its subject line.
+type LogEntry struct {
+	SHA     string
+	Subject string
+}
+
+// Log returns the SHA and subject of each commit in rangeSpec, oldest first, from a single
+// git log. Options such as --no-merges go before the range.
+func (g *Git) Log(rangeSpec string, options ...string) ([]LogEntry, error) {
+	args := append(append([]string{"log", "--reverse", "--format=%H %s"}, options...), rangeSpec)
+	out, err := g.Run(args...)
+	if err != nil {
+		return nil, err
+	}
+	var entries []LogEntry
+	for _, line := range splitLines(out) {
+		sha, subject, _ := strings.Cut(line, " ")
+		entries = append(entries, LogEntry{SHA: sha, Subject: subject})
+	}
+	return entries, nil
+}
+
+// Merges returns those of the given commits that are merges.
+func (g *Git) Merges(shas ...string) ([]string, error) {
+	out, err := g.Run(append([]string{"rev-list", "--no-walk", "--merges"}, shas...)...)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+// Parents returns the parents of a commit, first parent first.
+func (g *Git) Parents(sha string) ([]string, error) {
+	out, err := g.Run("rev-list", "--parents", "-n", "1", sha)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
 		return nil, nil
 	}
-	return strings.Split(out, "\n"), nil
+	return fields[1:], nil
+}
+
+// ChangedFiles returns the paths touched by the given commit against its first parent.
+func (g *Git) ChangedFiles(sha string) ([]string, error) {
+	out, err := g.Run("diff-tree", "--no-commit-id", "--name-only", "-r", "--root", "--diff-merges=first-parent", sha)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+// ChangesPath reports whether the given commit changes path (a file, or anything under
+// a directory) against its first parent.
+func (g *Git) ChangesPath(sha, path string) (bool, error) {
+	out, err := g.Run("diff-tree", "--no-commit-id", "--name-only", "-r", "--root", "--diff-merges=first-parent", sha, "--", path)
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
+// IsBinary reports whether git treats file as binary in the diff introduced by the given
+// commit (against its first parent), honoring .gitattributes. A file the commit does not
+// touch is reported as not binary.
+func (g *Git) IsBinary(sha, file string) (bool, error) {
+	out, err := g.Run("diff-tree", "--numstat", "--no-commit-id", "-r", "--root", "--diff-merges=first-parent", sha, "--", file)
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(out, "-\t-\t"), nil
+}
+
+// FileExists reports whether file is in the tree of the given commit.
+func (g *Git) FileExists(sha, file string) bool {
+	return g.RunSilent("cat-file", "-e", sha+":"+file) == nil
+}
+
+// FileLines returns the lines of file as of the given commit, indentation intact.
+func (g *Git) FileLines(sha, file string) ([]string, error) {
+	return memo(g.cache().lines, diffKey{sha: sha, file: file}, func() ([]string, error) {
+		out, err := g.output("cat-file", "-p", sha+":"+file)
+		if err != nil {
+			return nil, err
+		}
+		return strings.Split(strings.TrimSuffix(out, "\n"), "\n"), nil
+	})
+}
+
+// LsFiles returns all tracked paths in the working tree.
+func (g *Git) LsFiles() ([]string, error) {
+	out, err := g.Run("ls-files")
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+// Diff returns the unified diff between two commits. The result is cached on g.
+func (g *Git) Diff(from, to string) (string, error) {
+	return memo(g.cache().text, diffKey{parent: from, sha: to}, func() (string, error) {
+		return g.Run("diff", "--no-color", "--no-ext-diff", from, to)
+	})
+}
+
+// FileDiff returns the unified diff of file between two commits. The result is cached on g.
+func (g *Git) FileDiff(from, to, file string) (string, error) {
+	return memo(g.cache().text, diffKey{parent: from, sha: to, file: file}, func() (string, error) {
+		return g.Run("diff", "--no-color", "--no-ext-diff", from, to, "--", file)
+	})
+}
+
+// Hunk is a changed block of a file diff, in new-side line numbers (1-based, inclusive).
+type Hunk struct {
+	Header string // "@@ -a,b +c,d @@" line as printed by git.
+	Start  int64
+	End    int64
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// Hunks returns the hunks of file in the diff introduced by the given commit.
+// The parsed result is cached on g.
+func (g *Git) Hunks(sha, file string) ([]Hunk, error) {
+	return memo(g.cache().hunks, diffKey{sha: sha, file: file}, func() ([]Hunk, error) {
+		out, err := g.Run("show", "--format=", sha, "--", file)
+		if err != nil {
+			return nil, err
+		}
+		return parseHunks(out), nil
+	})
+}
+
+// ResetCache forgets the cached diffs, for g and the worktree Gits sharing its cache, so a
+// process that runs several commands gives each one a fresh view of the repository.
+func (g *Git) ResetCache() {
+	*g.cache() = *newDiffCache()
+}
+
+// cache returns the diff cache, creating it for a Git not made by New.
+func (g *Git) cache() *diffCache {
+	if g.diffs == nil {
+		g.diffs = newDiffCache()
+	}
+	return g.diffs
+}
+
+// parseHunks extracts the hunk headers of a unified diff.
+func parseHunks(diff string) []Hunk {
+	var hunks []Hunk
+	for _, line := range splitLines(diff) {
+		m := hunkHeader.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		start, _ := strconv.ParseInt(m[1], 10, 64)
+		count := int64(1)
+		if m[2] != "" {
+			count, _ = strconv.ParseInt(m[2], 10, 64)
+		}
+		// A pure deletion has no new-side lines; anchor it at the line it follows.
+		end := start + count - 1
+		if count == 0 {
+			start = max(start, 1)
+			end = start
+		}
+		hunks = append(hunks, Hunk{Header: line, Start: start, End: end})
+	}
+	return hunks
+}
+
+// TreeSHA returns the SHA of the tree recorded by the given commit.
+func (g *Git) TreeSHA(ref string) (string, error) {
+	return g.Run("rev-parse", ref+"^{tree}")
 }
 
 func (g *Git) Oneline(ref string) (string, error) {
@@ -133,6 +411,11 @@ func (g *Git) Subject(ref string) (string, error) {
 	return g.Run("log", "-1", "--format=%s", ref)
 }
 
+// Author returns the author of ref as "Name <email>".
+func (g *Git) Author(ref string) (string, error) {
+	return g.Run("log", "-1", "--format=%an <%ae>", ref)
+}
+
 func (g *Git) FullMessage(ref string) (string, error) {
 	return g.Run("log", "-1", "--format=%B", ref)
 }
@@ -145,6 +428,11 @@ func (g *Git) CheckoutForce(ref string) error {
 	return g.RunSilent("checkout", "--force", ref, "--quiet")
 }
 
+// CheckoutDetached force-checks out a commit with a detached HEAD.
+func (g *Git) CheckoutDetached(sha string) error {
+	return g.RunSilent("checkout", "--force", "--detach", sha, "--quiet")
+}
+
 // NotesAppend appends a message to git notes for the given SHA.
 // Falls back to "notes add" if "notes append" fails (no existing notes).
 func (g *Git) NotesAppend(sha, message string) error {
@@ -154,6 +442,40 @@ func (g *Git) NotesAppend(sha, message string) error {
 	return nil
 }
 
+// NotesReplace sets the git note for the given SHA, overwriting any existing note.
+func (g *Git) NotesReplace(sha, message string) error {
+	return g.RunSilent("notes", "add", "--force", "-m", message, sha)
+}
+
+// NotedCommits returns the set of commits that have a note on ref, or on the default
+// notes ref when ref is empty.
+func (g *Git) NotedCommits(ref string) (map[string]bool, error) {
+	out, err := g.Run(notesArgs(ref, "list")...)
+	if err != nil {
+		return nil, err
+	}
+	noted := map[string]bool{}
+	for _, line := range splitLines(out) {
+		// "<note blob> <annotated commit>"
+		if _, sha, ok := strings.Cut(line, " "); ok {
+			noted[sha] = true
+		}
+	}
+	return noted, nil
+}
+
+// NotesShow returns the note on sha from ref, or from the default notes ref when ref is empty.
+func (g *Git) NotesShow(ref, sha string) (string, error) {
+	return g.Run(notesArgs(ref, "show", sha)...)
+}
+
+func notesArgs(ref string, args ...string) []string {
+	if ref != "" {
+		return append([]string{"notes", "--ref=" + ref}, args...)
+	}
+	return append([]string{"notes"}, args...)
+}
+
 func (g *Git) WorktreeAdd(path string) error {
 	return g.RunSilent("worktree", "add", path, "--detach")
 }
@@ -162,6 +484,79 @@ func (g *Git) WorktreeRemove(path string) error {
 	return g.RunSilent("worktree", "remove", path, "--force")
 }
 
+// WorktreePrune drops registrations of worktrees whose directories no longer exist.
+func (g *Git) WorktreePrune() error {
+	return g.RunSilent("worktree", "prune")
+}
+
+// IsRegisteredWorktree reports whether git lists path among the repository's worktrees,
+// whether or not it is usable.
+func (g *Git) IsRegisteredWorktree(path string) (bool, error) {
+	out, err := g.Run("worktree", "list", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	want := canonicalPath(path)
+	for _, line := range splitLines(out) {
+		if p, ok := strings.CutPrefix(line, "worktree "); ok && canonicalPath(p) == want {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// canonicalPath resolves symlinks in path when it exists, so paths can be compared.
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// IsWorktree reports whether path is the top level of a valid working tree.
+func (g *Git) IsWorktree(path string) bool {
+	top, err := g.Run("-C", path, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return false
+	}
+	want, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	got, err := filepath.EvalSymlinks(top)
+	return err == nil && got == want
+}
+
+// Editor returns the editor command git would use (GIT_EDITOR, core.editor, VISUAL, EDITOR).
+func (g *Git) Editor() (string, error) {
+	return g.Run("var", "GIT_EDITOR")
+}
+
+// ConfigAll returns every value of a multi-valued config key, or nil when it is unset.
+func (g *Git) ConfigAll(key string) []string {
+	out, err := g.Run("config", "--get-all", key)
+	if err != nil {
+		return nil
+	}
+	return splitLines(out)
+}
+
+// SetConfigAll replaces every value of a config key in the repository's local config.
+// With no values the key is removed.
+func (g *Git) SetConfigAll(key string, values []string) error {
+	_ = g.RunSilent("config", "--local", "--unset-all", key) // fails when the key is unset
+	for _, v := range values {
+		if err := g.RunSilent("config", "--local", "--add", key, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (g *Git) ReadTreeReset(ref string) error {
 	return g.RunSilent("read-tree", "-u", "--reset", ref)
 }
@@ -170,6 +565,11 @@ func (g *Git) DiffStagedStat() (string, error) {
 	return g.Run("diff", "--staged", "--stat")
 }
 
+// DiffStat returns the diffstat between two commits.
+func (g *Git) DiffStat(from, to string) (string, error) {
+	return g.Run("diff", "--stat", from, to)
+}
+
 // worktreeName returns the worktree name if running inside a linked worktree,
 // or "" if in the main worktree. commonDir is passed from New() to avoid
 // re-running "rev-parse --git-common-dir".
@@ -183,3 +583,11 @@ func (g *Git) worktreeName(commonDir string) (string, error) {
 	}
 	return filepath.Base(gitDir), nil
 }
+
+// splitLines splits trimmed command output into lines, returning nil for empty output.
+func splitLines(out string) []string {
+	if out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
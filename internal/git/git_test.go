@@ -0,0 +1,97 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffCache_RunsGitOncePerDiff(t *testing.T) {
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@test.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-b", "main")
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("a\nb\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", ".")
+	run("commit", "-m", "init")
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("a\nb\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("commit", "-am", "add c")
+
+	g, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha, err := g.Run("rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := g.Run("rev-parse", "HEAD~1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs := 0
+	defer func(orig func(string, ...string) ([]byte, error)) { execGit = orig }(execGit)
+	execGit = func(dir string, args ...string) ([]byte, error) {
+		runs++
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		return cmd.Output()
+	}
+
+	// Each lookup is made twice, the second time through a worktree Git sharing the cache
+	other := g.ForWorktree("other", dir)
+	for _, gg := range []*Git{g, other} {
+		hunks, err := gg.Hunks(sha, "app.js")
+		if err != nil || len(hunks) != 1 || hunks[0].Start != 1 || hunks[0].End != 3 {
+			t.Fatalf("Hunks = %+v, %v; want one hunk L1-3", hunks, err)
+		}
+		if diff, err := gg.FileDiff(parent, sha, "app.js"); err != nil || !strings.Contains(diff, "+c") {
+			t.Fatalf("FileDiff = %q, %v", diff, err)
+		}
+		if diff, err := gg.Diff(parent, sha); err != nil || !strings.Contains(diff, "+c") {
+			t.Fatalf("Diff = %q, %v", diff, err)
+		}
+		if lines, err := gg.FileLines(sha, "app.js"); err != nil || len(lines) != 3 {
+			t.Fatalf("FileLines = %q, %v", lines, err)
+		}
+	}
+	if runs != 4 {
+		t.Errorf("git ran %d times, want once per distinct lookup (4)", runs)
+	}
+
+	// A different parent or file scope is a different diff
+	if _, err := g.FileDiff(parent, sha, "other.js"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Diff(sha, sha); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 6 {
+		t.Errorf("git ran %d times, want each new scope to run it (6)", runs)
+	}
+
+	// A reset, as serve makes between requests, empties the cache the worktree Git shares
+	g.ResetCache()
+	if _, err := other.Hunks(sha, "app.js"); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 7 {
+		t.Errorf("git ran %d times, want a reset to run it again (7)", runs)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"golang.org/x/term"
 )
@@ -19,17 +20,28 @@ const (
 
 // Output handles formatted terminal output with optional color support.
 type Output struct {
-	Stdout io.Writer
-	Stderr io.Writer
-	Color  bool
+	Stdin       io.Reader
+	Stdout      io.Writer
+	Stderr      io.Writer
+	Color       bool
+	Interactive bool // Both stdin and stdout are terminals, so prompting is possible.
+	Terminal    bool // Stdout is a terminal, so the screen can be redrawn.
+	Quiet       bool // Drop Info, Ok and Notef output; warnings, errors and Printf still print.
+	JSON        bool // The global --json: machine-readable results where a command has them.
+	Porcelain   bool // The global --porcelain: add, resolve and delete report with Record instead of Ok.
 }
 
-// New creates an Output with TTY-based color detection.
+// New creates an Output with TTY-based color and interactivity detection.
+// Color is also off for TERM=dumb and when NO_COLOR is set (https://no-color.org).
 func New() *Output {
+	stdoutTTY := term.IsTerminal(int(os.Stdout.Fd()))
 	return &Output{
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
-		Color:  term.IsTerminal(int(os.Stdout.Fd())),
+		Stdin:       os.Stdin,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+		Color:       stdoutTTY && os.Getenv("TERM") != "dumb" && os.Getenv("NO_COLOR") == "",
+		Interactive: stdoutTTY && term.IsTerminal(int(os.Stdin.Fd())),
+		Terminal:    stdoutTTY,
 	}
 }
 
@@ -40,14 +52,50 @@ func (o *Output) colorize(color, msg string) string {
 	return color + msg + colorReset
 }
 
-func (o *Output) Info(msg string)  { fmt.Fprintln(o.Stdout, o.colorize(colorCyan, msg)) }
+func (o *Output) Info(msg string) {
+	if !o.Quiet {
+		fmt.Fprintln(o.Stdout, o.colorize(colorCyan, msg))
+	}
+}
+
+func (o *Output) Ok(msg string) {
+	if !o.Quiet {
+		fmt.Fprintln(o.Stdout, o.colorize(colorGreen, msg))
+	}
+}
+
 func (o *Output) Warn(msg string)  { fmt.Fprintln(o.Stderr, o.colorize(colorYellow, "Warning: "+msg)) }
-func (o *Output) Ok(msg string)    { fmt.Fprintln(o.Stdout, o.colorize(colorGreen, msg)) }
 func (o *Output) Err(msg string)   { fmt.Fprintln(o.Stderr, o.colorize(colorRed, "Error: "+msg)) }
 func (o *Output) Bold(msg string) string   { return o.colorize(colorBold, msg) }
 func (o *Output) Green(msg string) string  { return o.colorize(colorGreen, msg) }
 func (o *Output) Yellow(msg string) string { return o.colorize(colorYellow, msg) }
 
+// Width returns the number of columns of the terminal stdout is on, or 0 when it is not one.
+func (o *Output) Width() int {
+	f, ok := o.Stdout.(*os.File)
+	if !ok || !o.Terminal {
+		return 0
+	}
+	w, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
 func (o *Output) Printf(format string, args ...any) {
 	fmt.Fprintf(o.Stdout, format, args...)
 }
+
+// Record prints one result of a change for --porcelain: its fields separated by tabs, on
+// a line of its own, never colored. --quiet does not drop it.
+func (o *Output) Record(fields ...string) {
+	fmt.Fprintln(o.Stdout, strings.Join(fields, "\t"))
+}
+
+// Notef prints decorative output such as banners and next-step hints, which --quiet drops.
+func (o *Output) Notef(format string, args ...any) {
+	if !o.Quiet {
+		fmt.Fprintf(o.Stdout, format, args...)
+	}
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/newmo-oss/ergo"
+)
+
+// Lock takes an exclusive lock on the review directory dir, so that commands changing the
+// review from several worktrees run one after another instead of interleaving their steps.
+// waiting is called once if another process holds the lock, before blocking until it is
+// released. The lock is released by the returned func, or when the process exits.
+func Lock(dir string, waiting func()) (func(), error) {
+	path := filepath.Join(dir, "lock")
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, ergo.Wrap(err, "failed to open review lock", slog.String("path", path))
+	}
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		waiting()
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+	}
+	if err != nil {
+		f.Close()
+		return nil, ergo.Wrap(err, "failed to lock review", slog.String("path", path))
+	}
+	return func() { f.Close() }, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -35,15 +36,25 @@ func Create(dbPath string, schema string) (*Repository, error) {
 		return nil, err
 	}
 
+	// A review DB left behind by an older version is brought up to date before the
+	// schema's CREATE ... IF NOT EXISTS statements run against it
+	if err := migrate(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
 	if _, err := conn.Exec(schema); err != nil {
 		conn.Close()
 		return nil, ergo.Wrap(err, "failed to create schema")
 	}
+	if err := setUserVersion(conn, SchemaVersion()); err != nil {
+		conn.Close()
+		return nil, err
+	}
 
 	return &Repository{conn: conn, q: db.New(conn)}, nil
 }
 
-// Open opens an existing review DB.
+// Open opens an existing review DB, applying any migrations it is missing.
 func Open(dbPath string) (*Repository, error) {
 	if _, err := os.Stat(dbPath); err != nil {
 		return nil, ergo.Wrap(err, "review database not found",
@@ -61,6 +72,11 @@ func Open(dbPath string) (*Repository, error) {
 		return nil, err
 	}
 
+	if err := migrate(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
 	return &Repository{conn: conn, q: db.New(conn)}, nil
 }
 
@@ -95,3 +111,169 @@ func setPragmas(conn *sql.DB) error {
 	}
 	return nil
 }
+
+// migrations upgrade a review DB one schema version at a time: migrations[i] takes it
+// from version i+1 to i+2. schema.sql always describes the latest version, so a schema
+// change goes both there and at the end of this list; existing entries never change.
+// Version 1 is the schema of the first release, before any column was added.
+var migrations = []string{
+	// 2: comments.needs_response
+	`ALTER TABLE comments ADD COLUMN needs_response BOOLEAN NOT NULL DEFAULT 0;`,
+	// 3: comments.tree
+	`ALTER TABLE comments ADD COLUMN tree TEXT;`,
+	// 4: session.head_sha, taken to be the last reviewed commit, since reviews covered
+	// base..HEAD
+	`ALTER TABLE session ADD COLUMN head_sha TEXT NOT NULL DEFAULT '';
+UPDATE session SET head_sha = COALESCE((SELECT sha FROM commits ORDER BY position DESC LIMIT 1), '');`,
+	// 5: comments.severity
+	`ALTER TABLE comments ADD COLUMN severity TEXT;`,
+	// 6: comments."commit" nullable, for review summaries. SQLite cannot drop NOT NULL, so
+	// the table is rebuilt; renaming the old one first keeps its self-reference from
+	// cascading into the copy when it is dropped
+	`ALTER TABLE comments RENAME TO comments_old;
+CREATE TABLE comments (
+    id             TEXT PRIMARY KEY,
+    parent_id      TEXT REFERENCES comments(id) ON DELETE CASCADE,
+    "commit"       TEXT REFERENCES commits(sha),
+    file           TEXT,
+    start_line     INTEGER,
+    end_line       INTEGER,
+    body           TEXT NOT NULL,
+    resolved_at    TEXT,
+    resolved_by    TEXT,
+    created_at     TEXT NOT NULL,
+    created_by     TEXT NOT NULL,
+    needs_response BOOLEAN NOT NULL DEFAULT 0,
+    tree           TEXT,
+    severity       TEXT
+);
+INSERT INTO comments (id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity)
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity
+FROM comments_old;
+DROP TABLE comments_old;
+CREATE INDEX IF NOT EXISTS idx_comments_commit ON comments("commit");
+CREATE INDEX IF NOT EXISTS idx_comments_parent ON comments(parent_id);`,
+	// 7: comments.also_commit
+	`ALTER TABLE comments ADD COLUMN also_commit TEXT REFERENCES commits(sha);`,
+	// 8: reviewers.entered_at and time_spent
+	`ALTER TABLE reviewers ADD COLUMN entered_at TEXT;
+CREATE TABLE IF NOT EXISTS time_spent (
+    reviewer       TEXT NOT NULL,
+    sha            TEXT NOT NULL REFERENCES commits(sha),
+    seconds        INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (reviewer, sha)
+);`,
+	// 9: session.stash_sha
+	`ALTER TABLE session ADD COLUMN stash_sha TEXT;`,
+	// 10: comment_files
+	`CREATE TABLE IF NOT EXISTS comment_files (
+    comment_id     TEXT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    position       INTEGER NOT NULL,
+    file           TEXT NOT NULL,
+    PRIMARY KEY (comment_id, position)
+);`,
+	// 11: comment_history
+	`CREATE TABLE IF NOT EXISTS comment_history (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    comment_id     TEXT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    action         TEXT NOT NULL,
+    body           TEXT NOT NULL,
+    changed_by     TEXT NOT NULL,
+    changed_at     TEXT NOT NULL
+);`,
+	// 12: reviewers.role
+	`ALTER TABLE reviewers ADD COLUMN role TEXT;`,
+	// 13: action_log
+	`CREATE TABLE IF NOT EXISTS action_log (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    reviewer       TEXT NOT NULL,
+    action         TEXT NOT NULL,
+    summary        TEXT NOT NULL,
+    data           TEXT NOT NULL,
+    created_at     TEXT NOT NULL
+);`,
+	// 14: session.return_branch
+	`ALTER TABLE session ADD COLUMN return_branch TEXT;`,
+	// 15: comments.fixup
+	`ALTER TABLE comments ADD COLUMN fixup TEXT;`,
+	// 16: session.annotate_only
+	`ALTER TABLE session ADD COLUMN annotate_only BOOLEAN NOT NULL DEFAULT 0;`,
+	// 17: commits.author
+	`ALTER TABLE commits ADD COLUMN author TEXT;`,
+	// 18: comments.assignee
+	`ALTER TABLE comments ADD COLUMN assignee TEXT;`,
+	// 19: comments.on_base
+	`ALTER TABLE comments ADD COLUMN on_base BOOLEAN NOT NULL DEFAULT 0;`,
+	// 20: session.branch_sha
+	`ALTER TABLE session ADD COLUMN branch_sha TEXT;`,
+	// 21: action_log.actor
+	`ALTER TABLE action_log ADD COLUMN actor TEXT NOT NULL DEFAULT '';`,
+}
+
+// SchemaVersion returns the schema version of a DB created or migrated by this build,
+// stored in the DB as PRAGMA user_version.
+func SchemaVersion() int {
+	return 1 + len(migrations)
+}
+
+// migrate applies the migrations a DB is missing, each in its own transaction together
+// with the version bump. A DB without tables is left alone for Create to initialize,
+// and one with tables but no version predates versioning and has the version 1 schema.
+func migrate(conn *sql.DB) error {
+	version, err := userVersion(conn)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		var tables int
+		if err := conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'session'").Scan(&tables); err != nil {
+			return ergo.Wrap(err, "failed to inspect database")
+		}
+		if tables == 0 {
+			return nil
+		}
+		version = 1
+		if err := setUserVersion(conn, version); err != nil {
+			return err
+		}
+	}
+	if version > SchemaVersion() {
+		return ergo.New("review database was created by a newer git-review; upgrade to continue this review",
+			slog.Int("version", version), slog.Int("supported", SchemaVersion()))
+	}
+
+	for v := version; v < SchemaVersion(); v++ {
+		tx, err := conn.Begin()
+		if err != nil {
+			return ergo.Wrap(err, "failed to begin transaction")
+		}
+		if _, err := tx.Exec(migrations[v-1]); err != nil {
+			_ = tx.Rollback()
+			return ergo.Wrap(err, "failed to migrate database", slog.Int("version", v+1))
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", v+1)); err != nil {
+			_ = tx.Rollback()
+			return ergo.Wrap(err, "failed to record schema version", slog.Int("version", v+1))
+		}
+		if err := tx.Commit(); err != nil {
+			return ergo.Wrap(err, "failed to migrate database", slog.Int("version", v+1))
+		}
+	}
+	return nil
+}
+
+func userVersion(conn *sql.DB) (int, error) {
+	var v int
+	if err := conn.QueryRow("PRAGMA user_version").Scan(&v); err != nil {
+		return 0, ergo.Wrap(err, "failed to read schema version")
+	}
+	return v, nil
+}
+
+func setUserVersion(conn *sql.DB, v int) error {
+	// PRAGMA takes no bind parameters
+	if _, err := conn.Exec(fmt.Sprintf("PRAGMA user_version = %d", v)); err != nil {
+		return ergo.Wrap(err, "failed to record schema version")
+	}
+	return nil
+}
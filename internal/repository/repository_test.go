@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+const testSchema = `CREATE TABLE IF NOT EXISTS session (base_ref TEXT PRIMARY KEY);`
+
+func TestMigrate_UpgradesOlderDatabases(t *testing.T) {
+	saved := migrations
+	t.Cleanup(func() { migrations = saved })
+	migrations = nil
+
+	dbPath := filepath.Join(t.TempDir(), "review.db")
+	repo, err := Create(dbPath, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := userVersion(repo.conn); v != 1 {
+		t.Errorf("Create should store version 1, got %d", v)
+	}
+
+	// A DB from before versioning has tables but no version
+	if err := setUserVersion(repo.conn, 0); err != nil {
+		t.Fatal(err)
+	}
+	repo.Close()
+
+	migrations = []string{`ALTER TABLE session ADD COLUMN note TEXT;`}
+	repo, err = Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	if v, _ := userVersion(repo.conn); v != 2 {
+		t.Errorf("Open should migrate to version 2, got %d", v)
+	}
+	if _, err := repo.conn.Exec(`INSERT INTO session (base_ref, note) VALUES ('main', 'x')`); err != nil {
+		t.Errorf("migration was not applied: %v", err)
+	}
+}
+
+func TestMigrate_RejectsNewerDatabases(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "review.db")
+	repo, err := Create(dbPath, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setUserVersion(repo.conn, SchemaVersion()+1); err != nil {
+		t.Fatal(err)
+	}
+	repo.Close()
+
+	if repo, err := Open(dbPath); err == nil {
+		repo.Close()
+		t.Error("Open should refuse a DB from a newer schema version")
+	}
+}
+
+func TestMigrate_LeavesEmptyDatabasesToCreate(t *testing.T) {
+	conn, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "review.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := migrate(conn); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := userVersion(conn); v != 0 {
+		t.Errorf("an empty DB should stay unversioned, got %d", v)
+	}
+}
+
+func TestLock_SecondHolderWaits(t *testing.T) {
+	dir := t.TempDir()
+	unlock, err := Lock(dir, func() { t.Error("the first Lock should not wait") })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waited := make(chan struct{})
+	acquired := make(chan struct{})
+	go func() {
+		unlock2, err := Lock(dir, func() { close(waited) })
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		close(acquired)
+		unlock2()
+	}()
+
+	<-waited
+	select {
+	case <-acquired:
+		t.Fatal("the second Lock should block while the first is held")
+	default:
+	}
+	unlock()
+	<-acquired
+}
@@ -3,6 +3,7 @@ package main
 import (
 	_ "embed"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 
@@ -27,48 +28,116 @@ type CLI struct {
 	Add       commands.AddCmd       `cmd:"" help:"Add comment to current commit."`
 	Next      commands.NextCmd      `cmd:"" help:"Move to next commit."`
 	Jump      commands.JumpCmd      `cmd:"" help:"Jump to a specific commit."`
+	Reorder   commands.ReorderCmd   `cmd:"" help:"Change the order commits are reviewed in."`
 	List      commands.ListCmd      `cmd:"" help:"Show all comments (Markdown)."`
+	Threads   commands.ThreadsCmd   `cmd:"" help:"List threads with reply counts, most recently active first."`
+	Replies   commands.RepliesCmd   `cmd:"" help:"Show only the replies below a comment."`
+	Patch     commands.PatchCmd     `cmd:"" help:"Export comments as a diff annotated with the review."`
 	Status    commands.StatusCmd    `cmd:"" help:"Show review progress."`
+	Stats     commands.StatsCmd     `cmd:"" help:"Summarize review activity."`
 	Delete    commands.DeleteCmd    `cmd:"" help:"Delete a comment by ID."`
+	Move      commands.MoveCmd      `cmd:"" help:"Move a thread to another commit."`
+	Open      commands.OpenCmd      `cmd:"" help:"Open $EDITOR on a commented file at its line."`
 	Resolve   commands.ResolveCmd   `cmd:"" help:"Resolve a thread."`
 	Unresolve commands.UnresolveCmd `cmd:"" help:"Unresolve a thread."`
+	Dismiss   commands.DismissCmd   `cmd:"" help:"Clear the needs-response flag on a question."`
+	History   commands.HistoryCmd   `cmd:"" help:"Show when a comment was made and every change to it since."`
+	Log       commands.LogCmd       `cmd:"" help:"Show the review's activity as a feed: comments made and threads resolved or reopened, oldest first."`
+	Undo      commands.UndoCmd      `cmd:"" help:"Undo the last add, delete, resolve or unresolve made from this worktree."`
 	Finish    commands.FinishCmd    `cmd:"" help:"Finish review and write git notes."`
 	Abort     commands.AbortCmd     `cmd:"" help:"Cancel review and clean up."`
 	State     commands.StateCmd     `cmd:"" hidden:""`
 	Skill     commands.SkillCmd     `cmd:"" help:"Show AI Agent workflow guide."`
 
-	repo *repository.Repository
+	MergeSession commands.MergeSessionCmd `cmd:"" help:"Merge comments from another review database."`
+	Import       commands.ImportCmd       `cmd:"" help:"Restore a review exported with 'git review state'."`
+	Config       commands.ConfigCmd       `cmd:"" help:"Show or change the default reviewer and base branches."`
+	Whoami       commands.WhoamiCmd       `cmd:"" help:"Show the reviewer identity this worktree acts as."`
+	Notes        commands.NotesCmd        `cmd:"" help:"Show the notes finished reviews left on this branch."`
+
+	Completion commands.CompletionCmd `cmd:"" hidden:"" help:"Output shell completion script (bash, zsh, fish)."`
+	Complete   commands.CompleteCmd   `cmd:"" name:"__complete" hidden:""`
+
+	ServeStdio bool   `name:"serve-stdio" help:"Answer JSON-RPC requests (add, list, next, resolve, state) on stdin until EOF."`
+	Color      bool   `help:"Always color output, even when it is not a terminal." xor:"color"`
+	NoColor    bool   `name:"no-color" help:"Never color output." xor:"color"`
+	Quiet      bool   `help:"Print only results and errors: no banners, hints or confirmations."`
+	JSON       bool   `name:"json" help:"Report errors on stderr as JSON ({\"error\": {\"code\", \"message\"}}), and print status and stats as JSON."`
+	Porcelain  bool   `help:"Report what add, resolve and delete changed as stable tab-separated lines (added, resolved, deleted, promoted; then the full comment ID and, for added, its commit) instead of messages."`
+	RepoDir    string `name:"repo" short:"C" default:"." type:"existingdir" placeholder:"PATH" help:"Operate on the repository (or worktree) at PATH instead of the current directory, like git -C."`
+
+	repo   *repository.Repository
+	unlock func()
 }
 
 // AfterApply runs after flag parsing, before Run().
 // Binds shared dependencies to Kong context for injection into Run().
 func (c *CLI) AfterApply(ctx *kong.Context) error {
-	ctx.Bind(output.New())
+	out := output.New()
+	if c.Color || c.NoColor {
+		out.Color = c.Color
+	}
+	out.Quiet = c.Quiet
+	out.JSON = c.JSON
+	out.Porcelain = c.Porcelain
+	// A compact status ends up in shell prompts, where stray escapes garble the line
+	compact := ctx.Selected().Name == "status" && c.Status.Compact
+	if compact && !c.Color {
+		out.Color = false
+	}
+	ctx.Bind(out)
 
-	if ctx.Selected().Name == "skill" {
+	// --serve-stdio opens git and the database itself, per request.
+	if c.ServeStdio || ctx.Selected().Name == "skill" || ctx.Selected().Name == "completion" {
 		return nil
 	}
 
-	g, err := git.New(".")
+	g, err := openGit(c.RepoDir)
 	if err != nil {
-		return ergo.WithCode(
-			ergo.New("not in a git repository"),
-			internal.ErrCodeNotInRepo)
+		if compact {
+			ctx.Bind((*git.Git)(nil))
+			ctx.Bind((*repository.Repository)(nil))
+			return nil
+		}
+		return err
 	}
 	ctx.Bind(g)
 
-	dbPath := filepath.Join(g.CommonDir, "review", "review.db")
+	dbPath := commands.ReviewDBPath(g)
+	creating := ctx.Selected().Name == "start" || ctx.Selected().Name == "import"
+	if creating {
+		// The review directory holds the lock, so it must exist before the review does
+		if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+			return ergo.Wrap(err, "failed to create review directory", slog.String("path", filepath.Dir(dbPath)))
+		}
+	}
+	// Commands that change the review take turns, so multi-step changes made from
+	// several worktrees at once cannot interleave. resolve -i locks only while it saves,
+	// not while it waits for the reviewer's choice
+	interactive := ctx.Selected().Name == "resolve" && c.Resolve.Interactive
+	if !commands.ReadOnlyCommands[ctx.Selected().Name] && !interactive {
+		if c.unlock, err = commands.LockReview(dbPath, out); err != nil {
+			return err
+		}
+	}
 	var repo *repository.Repository
-	if ctx.Selected().Name == "start" {
+	if creating {
 		repo, err = repository.Create(dbPath, schema)
 	} else {
 		repo, err = repository.Open(dbPath)
 	}
 	if err != nil {
-		if ctx.Selected().Name == "state" {
-			// state outputs "null" when no review exists
+		switch ctx.Selected().Name {
+		case "state", "__complete", "config", "whoami", "notes":
+			// state outputs "null", completion falls back to ls-files, config, whoami and notes need no review
 			ctx.Bind((*repository.Repository)(nil))
 			return nil
+		case "status":
+			if compact {
+				// prints nothing, so a prompt can call it unconditionally
+				ctx.Bind((*repository.Repository)(nil))
+				return nil
+			}
 		}
 		return err
 	}
@@ -78,31 +147,61 @@ func (c *CLI) AfterApply(ctx *kong.Context) error {
 	return nil
 }
 
+// openGit opens the repository containing dir, which is "." unless --repo says otherwise.
+func openGit(dir string) (*git.Git, error) {
+	g, err := git.New(dir)
+	if err != nil {
+		return nil, ergo.WithCode(
+			ergo.New("not in a git repository", slog.String("dir", dir)),
+			internal.ErrCodeNotInRepo)
+	}
+	return g, nil
+}
+
+func serveStdio(dir string) error {
+	g, err := openGit(dir)
+	if err != nil {
+		return err
+	}
+	return commands.ServeStdio(g, commands.ReviewDBPath(g), os.Stdin, os.Stdout)
+}
+
 func main() {
 	var cli CLI
-	ctx := kong.Parse(&cli,
+	parser := kong.Must(&cli,
 		kong.Name("git-review"),
 		kong.Description("Commit review workflow for AI Agent collaboration"),
 		kong.UsageOnError(),
 		kong.Bind(commands.SkillMarkdown(skill)),
 	)
+	ctx, err := parser.Parse(os.Args[1:])
+	if err != nil && !cli.JSON {
+		parser.FatalIfErrorf(err)
+	}
 	defer func() {
 		if cli.repo != nil {
 			cli.repo.Close()
 		}
+		if cli.unlock != nil {
+			cli.unlock()
+		}
 	}()
 
-	if err := ctx.Run(); err != nil {
-		// ergo.WithCode wraps the message as "CodeName: message".
-		// Strip the code prefix to show a clean user-facing message.
-		msg := err.Error()
-		if code := ergo.CodeOf(err); !code.IsZero() {
-			prefix := code.String() + ": "
-			if len(msg) > len(prefix) && msg[:len(prefix)] == prefix {
-				msg = msg[len(prefix):]
-			}
+	switch {
+	case err != nil: // failed parsing or setup, with --json
+	case cli.ServeStdio:
+		err = serveStdio(cli.RepoDir)
+	default:
+		err = ctx.Run()
+	}
+	if err != nil {
+		switch {
+		case internal.IsSilent(err):
+		case cli.JSON:
+			fmt.Fprintf(os.Stderr, "%s\n", internal.ErrorJSON(err))
+		default:
+			fmt.Fprintf(os.Stderr, "Error: %s\n", internal.UserMessage(err))
 		}
-		fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
-		os.Exit(1)
+		os.Exit(internal.ExitCode(err))
 	}
 }
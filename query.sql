@@ -1,10 +1,10 @@
 -- Session
 
 -- name: InsertSession :exec
-INSERT INTO session (base_ref, branch, created_at) VALUES (?, ?, ?);
+INSERT INTO session (base_ref, branch, created_at, head_sha, stash_sha, return_branch, annotate_only, branch_sha) VALUES (?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: GetSession :one
-SELECT base_ref, branch, created_at FROM session LIMIT 1;
+SELECT base_ref, branch, created_at, head_sha, stash_sha, return_branch, annotate_only, branch_sha FROM session LIMIT 1;
 
 -- name: SessionExists :one
 SELECT COUNT(*) FROM session;
@@ -15,19 +15,22 @@ DELETE FROM session;
 -- Commits
 
 -- name: InsertCommit :exec
-INSERT INTO commits (sha, message, position) VALUES (?, ?, ?);
+INSERT INTO commits (sha, message, position, author) VALUES (?, ?, ?, ?);
 
 -- name: ListCommits :many
-SELECT sha, message, position FROM commits ORDER BY position;
+SELECT sha, message, position, author FROM commits ORDER BY position;
 
 -- name: GetCommitByPosition :one
-SELECT sha, message, position FROM commits WHERE position = ?;
+SELECT sha, message, position, author FROM commits WHERE position = ?;
 
 -- name: GetCommitBySHA :one
-SELECT sha, message, position FROM commits WHERE sha = ?;
+SELECT sha, message, position, author FROM commits WHERE sha = ?;
 
--- name: FindCommitBySHAPrefix :one
-SELECT sha, message, position FROM commits WHERE sha LIKE ?||'%';
+-- name: FindCommitsBySHAPrefix :many
+SELECT sha, message, position, author FROM commits WHERE sha LIKE ?||'%' ORDER BY position;
+
+-- name: UpdateCommitPosition :exec
+UPDATE commits SET position = ? WHERE sha = ?;
 
 -- name: CountCommits :one
 SELECT COUNT(*) FROM commits;
@@ -38,51 +41,117 @@ DELETE FROM commits;
 -- Reviewers
 
 -- name: InsertReviewer :exec
-INSERT INTO reviewers (name, current_sha) VALUES (?, ?);
+INSERT INTO reviewers (name, current_sha, role) VALUES (?, ?, ?);
 
 -- name: GetReviewer :one
-SELECT name, current_sha FROM reviewers WHERE name = ?;
+SELECT name, current_sha, entered_at, role FROM reviewers WHERE name = ?;
 
 -- name: ListReviewers :many
-SELECT name, current_sha FROM reviewers;
+SELECT name, current_sha, entered_at, role FROM reviewers;
+
+-- name: UpdateReviewerRole :exec
+UPDATE reviewers SET role = ? WHERE name = ?;
 
 -- name: UpdateReviewerCurrent :exec
-UPDATE reviewers SET current_sha = ? WHERE name = ?;
+UPDATE reviewers SET current_sha = ?, entered_at = ? WHERE name = ?;
 
 -- name: DeleteReviewers :exec
 DELETE FROM reviewers;
 
+-- Time spent
+
+-- name: AddTimeSpent :exec
+INSERT INTO time_spent (reviewer, sha, seconds) VALUES (?, ?, ?)
+ON CONFLICT (reviewer, sha) DO UPDATE SET seconds = seconds + excluded.seconds;
+
+-- name: ListTimeSpent :many
+SELECT reviewer, sha, seconds FROM time_spent;
+
+-- name: DeleteTimeSpent :exec
+DELETE FROM time_spent;
+
 -- Comments
 
 -- name: InsertComment :exec
-INSERT INTO comments (id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+INSERT INTO comments (id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: GetComment :one
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments WHERE id = ?;
 
--- name: FindCommentByPrefix :one
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
-FROM comments WHERE id LIKE ?||'%';
+-- name: FindCommentsByPrefix :many
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
+FROM comments WHERE id LIKE ?||'%' ORDER BY id;
 
 -- name: ListAllComments :many
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments;
 
 -- name: ListCommentsByCommit :many
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments WHERE "commit" = ?;
 
+-- name: UpdateCommentCommit :exec
+UPDATE comments SET "commit" = ?, tree = ? WHERE id = ?;
+
 -- name: ReparentChildren :exec
 UPDATE comments SET parent_id = ? WHERE parent_id = ?;
 
+-- name: PromoteComment :exec
+UPDATE comments SET parent_id = NULL, resolved_at = ?, resolved_by = ?, needs_response = ?, severity = ?, also_commit = ?, fixup = ?, assignee = ?, on_base = ? WHERE id = ?;
+
+-- name: RestoreComment :exec
+UPDATE comments SET parent_id = ?, resolved_at = ?, resolved_by = ?, needs_response = ?, severity = ?, also_commit = ?, fixup = ?, assignee = ?, on_base = ? WHERE id = ?;
+
 -- name: DeleteComment :exec
 DELETE FROM comments WHERE id = ?;
 
 -- name: DeleteAllComments :exec
 DELETE FROM comments;
 
+-- Comment files
+
+-- name: InsertCommentFile :exec
+INSERT INTO comment_files (comment_id, position, file) VALUES (?, ?, ?);
+
+-- name: MoveCommentFiles :exec
+UPDATE comment_files SET comment_id = ? WHERE comment_id = ?;
+
+-- name: DeleteCommentFiles :exec
+DELETE FROM comment_files WHERE comment_id = ?;
+
+-- name: ListCommentFiles :many
+SELECT comment_id, position, file FROM comment_files ORDER BY comment_id, position;
+
+-- Comment history
+
+-- name: InsertCommentHistory :exec
+INSERT INTO comment_history (comment_id, action, body, changed_by, changed_at) VALUES (?, ?, ?, ?, ?);
+
+-- name: ListCommentHistory :many
+SELECT id, comment_id, action, body, changed_by, changed_at FROM comment_history WHERE comment_id = ? ORDER BY id;
+
+-- name: ListAllCommentHistory :many
+SELECT id, comment_id, action, body, changed_by, changed_at FROM comment_history ORDER BY id;
+
+-- name: DeleteCommentHistory :exec
+DELETE FROM comment_history WHERE id = ?;
+
+-- Action log
+
+-- name: InsertAction :exec
+INSERT INTO action_log (reviewer, action, summary, data, created_at, actor) VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetLastAction :one
+SELECT id, reviewer, action, summary, data, created_at, actor FROM action_log WHERE reviewer = ? ORDER BY id DESC LIMIT 1;
+
+-- name: DeleteAction :exec
+DELETE FROM action_log WHERE id = ?;
+
+-- name: DeleteAllActions :exec
+DELETE FROM action_log;
+
 -- Resolve
 
 -- name: ResolveComment :exec
@@ -91,16 +160,21 @@ UPDATE comments SET resolved_at = ?, resolved_by = ? WHERE id = ? AND parent_id
 -- name: UnresolveComment :exec
 UPDATE comments SET resolved_at = NULL, resolved_by = NULL WHERE id = ?;
 
+-- Questions
+
+-- name: ClearNeedsResponse :exec
+UPDATE comments SET needs_response = 0 WHERE id = ?;
+
 -- Filtered list queries
 
 -- name: ListUnresolvedRoots :many
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments WHERE parent_id IS NULL AND resolved_at IS NULL;
 
 -- name: ListCommentsByCreator :many
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments WHERE created_by = ?;
 
 -- name: ListCommentsByFile :many
-SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by
+SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by, needs_response, tree, severity, also_commit, fixup, assignee, on_base
 FROM comments WHERE file = ?;
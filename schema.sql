@@ -5,24 +5,39 @@
 CREATE TABLE IF NOT EXISTS session (
     base_ref   TEXT PRIMARY KEY,
     branch     TEXT NOT NULL,
-    created_at TEXT NOT NULL
+    created_at TEXT NOT NULL,
+    head_sha   TEXT NOT NULL,
+    stash_sha  TEXT,
+    return_branch TEXT,
+    annotate_only BOOLEAN NOT NULL DEFAULT 0,
+    branch_sha TEXT
 );
 
 CREATE TABLE IF NOT EXISTS commits (
     sha      TEXT PRIMARY KEY,
     message  TEXT NOT NULL,
-    position INTEGER NOT NULL UNIQUE
+    position INTEGER NOT NULL UNIQUE,
+    author   TEXT
 );
 
 CREATE TABLE IF NOT EXISTS reviewers (
     name           TEXT PRIMARY KEY,
-    current_sha    TEXT REFERENCES commits(sha)
+    current_sha    TEXT REFERENCES commits(sha),
+    entered_at     TEXT,
+    role           TEXT
+);
+
+CREATE TABLE IF NOT EXISTS time_spent (
+    reviewer       TEXT NOT NULL,
+    sha            TEXT NOT NULL REFERENCES commits(sha),
+    seconds        INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (reviewer, sha)
 );
 
 CREATE TABLE IF NOT EXISTS comments (
     id             TEXT PRIMARY KEY,
     parent_id      TEXT REFERENCES comments(id) ON DELETE CASCADE,
-    "commit"       TEXT NOT NULL REFERENCES commits(sha),
+    "commit"       TEXT REFERENCES commits(sha),
     file           TEXT,
     start_line     INTEGER,
     end_line       INTEGER,
@@ -30,7 +45,46 @@ CREATE TABLE IF NOT EXISTS comments (
     resolved_at    TEXT,
     resolved_by    TEXT,
     created_at     TEXT NOT NULL,
-    created_by     TEXT NOT NULL
+    created_by     TEXT NOT NULL,
+    needs_response BOOLEAN NOT NULL DEFAULT 0,
+    tree           TEXT,
+    severity       TEXT,
+    also_commit    TEXT REFERENCES commits(sha),
+    fixup          TEXT,
+    assignee       TEXT,
+    on_base        BOOLEAN NOT NULL DEFAULT 0
+);
+
+-- Files after the first of a comment on several files; the first is comments.file
+CREATE TABLE IF NOT EXISTS comment_files (
+    comment_id     TEXT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    position       INTEGER NOT NULL,
+    file           TEXT NOT NULL,
+    PRIMARY KEY (comment_id, position)
+);
+
+-- Changes made to a comment after it was added, oldest first: the action ("resolved",
+-- "unresolved") with the body as it was before the change, who made it and when
+CREATE TABLE IF NOT EXISTS comment_history (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    comment_id     TEXT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    action         TEXT NOT NULL,
+    body           TEXT NOT NULL,
+    changed_by     TEXT NOT NULL,
+    changed_at     TEXT NOT NULL
+);
+
+-- Mutating actions, oldest first, for undo: who acted (the worktree's reviewer), the
+-- action ("add", "delete", "resolve", "unresolve"), a short description, and as JSON the
+-- threads it touched as they were before and the comments it added
+CREATE TABLE IF NOT EXISTS action_log (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    reviewer       TEXT NOT NULL,
+    action         TEXT NOT NULL,
+    summary        TEXT NOT NULL,
+    data           TEXT NOT NULL,
+    created_at     TEXT NOT NULL,
+    actor          TEXT NOT NULL DEFAULT ''
 );
 
 CREATE INDEX IF NOT EXISTS idx_comments_commit ON comments("commit");
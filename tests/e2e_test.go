@@ -1,8 +1,18 @@
 package tests
 
 import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
 )
 
 func TestStart_CreatesDBAndShowsCommits(t *testing.T) {
@@ -51,6 +61,26 @@ func TestNext_ShowsMessageWhenAllReviewed(t *testing.T) {
 	assertContains(t, "all reviewed message", output, "All commits reviewed")
 }
 
+func TestNext_SkipCommented(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "Seen this one")
+
+	commits := loadState(t, dir)["commits"].([]interface{})
+	mustRunGR(t, dir, "jump", commits[0].(string))
+
+	output := mustRunGR(t, dir, "next", "--skip-commented")
+	assertContains(t, "skips commented commit", output, "Add main entry")
+	assertContains(t, "shows position", output, "[3/3]")
+
+	mustRunGR(t, dir, "add", "And this one")
+	mustRunGR(t, dir, "jump", commits[0].(string))
+	output = mustRunGR(t, dir, "next", "--skip-commented")
+	assertContains(t, "reports nothing left", output, "All remaining commits have comments")
+}
+
 func TestAdd_GeneralAndFileComments(t *testing.T) {
 	t.Parallel()
 	dir := setupTestRepo(t)
@@ -98,6 +128,28 @@ func TestAdd_RangeComment(t *testing.T) {
 	}
 }
 
+func TestAdd_HunkFillsLineRange(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "next")
+
+	output := mustRunGR(t, dir, "add", "-f", "app.js", "--hunk", "1", "Whole block")
+	assertContains(t, "shows hunk range", output, "app.js:1-3 Whole block")
+
+	c := findCommentByBody(stateComments(t, loadState(t, dir)), "Whole block")
+	if c["startLine"].(float64) != 1 || c["endLine"].(float64) != 3 {
+		t.Errorf("got lines %v-%v, want 1-3", c["startLine"], c["endLine"])
+	}
+
+	output, err := runGR(t, dir, "add", "-f", "app.js", "--hunk", "2", "Missing")
+	if err == nil {
+		t.Fatal("expected error for out-of-range hunk")
+	}
+	assertContains(t, "lists hunks", output, "1: L1-3  @@ -1,2 +1,3 @@")
+}
+
 func TestList_ShowsAllComments(t *testing.T) {
 	t.Parallel()
 	dir := setupTestRepo(t)
@@ -170,6 +222,280 @@ func TestAbort_RemovesStateAndRestoresBranch(t *testing.T) {
 	assertDirNotExists(t, filepath.Join(dir, ".git", "review"))
 }
 
+func TestAbort_KeepNotesWritesCommentsBeforeCleanup(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Worth keeping")
+
+	output := mustRunGR(t, dir, "abort", "--keep-notes")
+	assertContains(t, "abort message", output, "aborted")
+	assertDirNotExists(t, filepath.Join(dir, ".git", "review"))
+
+	notes := gitCmd(t, dir, "log", "--notes", "--format=%N", "main..feature/test")
+	assertContains(t, "notes kept", notes, "Worth keeping")
+}
+
+func TestAbort_RequiresForceWhenCommentsWouldBeLost(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Not yet written anywhere")
+
+	output, err := runGR(t, dir, "abort")
+	if err == nil {
+		t.Fatalf("expected abort without a terminal to require --force\n%s", output)
+	}
+	assertContains(t, "explains", output, "would delete 1 comment")
+	assertContains(t, "suggests force", output, "--force")
+	assertFileExists(t, filepath.Join(dir, ".git", "review", "review.db"))
+
+	mustRunGR(t, dir, "abort", "--force")
+	assertDirNotExists(t, filepath.Join(dir, ".git", "review"))
+}
+
+func TestAbort_WithoutKeepNotesWritesNoNotes(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Throwaway")
+	mustRunGR(t, dir, "abort", "--force")
+
+	notes := gitCmd(t, dir, "log", "--notes", "--format=%N", "main..feature/test")
+	assertNotContains(t, "no notes", notes, "Throwaway")
+}
+
+func TestStart_RejoinReusesWorktreeAtRecordedPosition(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "start", "-a", "alice")
+
+	worktree := filepath.Join(dir, ".git", "review", "worktrees", "alice")
+	mustRunGR(t, worktree, "next")
+
+	output := mustRunGR(t, dir, "start", "-a", "alice")
+	assertContains(t, "rejoined", output, "Rejoined Review as alice")
+	assertContains(t, "lands at recorded position", output, "[2/3]")
+
+	state := loadState(t, worktree)
+	if state["current"] != float64(1) {
+		t.Errorf("current: got %v, want 1", state["current"])
+	}
+}
+
+func TestStart_RejoinRecreatesMissingWorktree(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "start", "-a", "alice")
+
+	worktree := filepath.Join(dir, ".git", "review", "worktrees", "alice")
+	if err := os.RemoveAll(worktree); err != nil {
+		t.Fatal(err)
+	}
+
+	output := mustRunGR(t, dir, "start", "-a", "alice")
+	assertContains(t, "rejoined", output, "Rejoined Review as alice")
+	assertFileExists(t, filepath.Join(worktree, "app.js"))
+}
+
+func TestStart_RejoinRefusesToRemoveRegisteredWorktree(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "start", "-a", "alice")
+
+	// A worktree git still registers but cannot open may hold work, so it is left alone
+	worktree := filepath.Join(dir, ".git", "review", "worktrees", "alice")
+	writeFile(t, worktree, "notes.txt", "unsaved thoughts\n")
+	writeFile(t, worktree, ".git", "gitdir: "+filepath.Join(dir, "nowhere")+"\n")
+
+	output, err := runGR(t, dir, "start", "-a", "alice")
+	if err == nil {
+		t.Fatalf("rejoin should refuse a broken registered worktree:\n%s", output)
+	}
+	assertContains(t, "reason", output, "registered worktree")
+	assertFileExists(t, filepath.Join(worktree, "notes.txt"))
+}
+
+func TestStart_SingleReviewsOneCommitAgainstItsParent(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	sha := gitCmd(t, dir, "rev-parse", "HEAD~1")
+
+	output := mustRunGR(t, dir, "start", "--single", sha)
+	assertContains(t, "one commit", output, "1 commit(s)")
+	assertContains(t, "reviews requested commit", output, "Add goodbye function")
+
+	staged := gitCmd(t, dir, "diff", "--cached")
+	assertContains(t, "stages the commit's change", staged, "+function goodbye()")
+	assertNotContains(t, "excludes earlier commits", staged, "+function hello()")
+
+	state := loadState(t, dir)
+	commits := state["commits"].([]interface{})
+	if len(commits) != 1 {
+		t.Fatalf("expected 1 commit, got %d", len(commits))
+	}
+}
+
+func TestStart_SingleRejectsRootCommit(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+
+	output, err := runGR(t, dir, "start", "--single", "main")
+	if err == nil {
+		t.Fatal("expected error for root commit")
+	}
+	assertContains(t, "explains root commit", output, "root commit")
+}
+
+func TestStart_WarnsWhenBaseIsNotAnAncestor(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	diverged := gitCmd(t, dir, "commit-tree", "-p", "main", "-m", "Diverged", "main^{tree}")
+	gitCmd(t, dir, "branch", "diverged", diverged)
+
+	output, err := runGR(t, dir, "start", "--strict", "diverged")
+	if err == nil {
+		t.Fatal("expected --strict to refuse a base that is not an ancestor")
+	}
+	assertContains(t, "suggests the merge-base", output, "git review "+gitCmd(t, dir, "rev-parse", "--short=7", "main"))
+
+	output = mustRunGR(t, dir, "start", "diverged")
+	assertContains(t, "warns without --strict", output, "diverged is not an ancestor of HEAD")
+}
+
+func TestStart_RefusesDirtyWorkingTree(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	writeFile(t, dir, "app.js", "// work in progress\n")
+
+	output, err := runGR(t, dir)
+	if err == nil {
+		t.Fatal("expected start to refuse a dirty working tree")
+	}
+	assertContains(t, "explains the refusal", output, "uncommitted changes")
+	assertContains(t, "suggests autostash", output, "--autostash")
+	if _, err := runGR(t, dir, "status"); err == nil {
+		t.Error("expected no review in progress")
+	}
+}
+
+func TestStart_AutostashRestoresChangesOnAbort(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	writeFile(t, dir, "app.js", "// work in progress\n")
+
+	output := mustRunGR(t, dir, "start", "--autostash")
+	assertContains(t, "reports the stash", output, "Stashed local changes")
+
+	output = mustRunGR(t, dir, "abort")
+	assertContains(t, "reports the restore", output, "Restored the local changes")
+	data, err := os.ReadFile(filepath.Join(dir, "app.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "// work in progress\n" {
+		t.Errorf("expected local change restored, got %q", data)
+	}
+	if stashes := gitCmd(t, dir, "stash", "list"); stashes != "" {
+		t.Errorf("expected the stash to be dropped, got %q", stashes)
+	}
+}
+
+func TestMergeSession_CombinesCommentsFromAnotherReview(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-a", "alice", "-f", "app.js", "-l", "1", "From alice")
+	state := loadState(t, dir)
+	aliceRoot := findCommentByBody(stateComments(t, state), "From alice")["id"].(string)
+	mustRunGR(t, dir, "add", "-a", "alice", "-r", aliceRoot, "Alice follow-up")
+
+	data, err := os.ReadFile(filepath.Join(dir, ".git", "review", "review.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(t.TempDir(), "alice.db")
+	if err := os.WriteFile(other, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mustRunGR(t, dir, "abort", "-f")
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-a", "bob", "From bob")
+
+	output := mustRunGR(t, dir, "merge-session", other)
+	assertContains(t, "reports merged comments", output, "Merged 2 comments")
+
+	comments := stateComments(t, loadState(t, dir))
+	if len(comments) != 3 {
+		t.Fatalf("expected 3 comments, got %d", len(comments))
+	}
+	if findCommentByBody(comments, "From bob") == nil {
+		t.Error("current comment missing after merge")
+	}
+	reply := findCommentByBody(comments, "Alice follow-up")
+	if reply == nil || reply["parentId"] != aliceRoot {
+		t.Errorf("reply not threaded under merged root: %v", reply)
+	}
+
+	output = mustRunGR(t, dir, "merge-session", other)
+	assertContains(t, "de-duplicates by ID", output, "Merged 0 comments")
+}
+
+func TestList_MarksCommentsMadeBeforeAmend(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "3", "Drop the log")
+
+	output := mustRunGR(t, dir, "list")
+	assertNotContains(t, "fresh comment unmarked", output, "[pre-amend]")
+
+	// Amend the reviewed tip commit from a separate worktree
+	amend := filepath.Join(t.TempDir(), "amend")
+	gitCmd(t, dir, "worktree", "add", amend, "feature/test")
+	writeFile(t, amend, "app.js", "function hello() { return \"hello\"; }\nfunction goodbye() { return \"bye\"; }\nconsole.log(goodbye());\n")
+	gitCmd(t, amend, "commit", "-a", "--amend", "--no-edit")
+
+	output = mustRunGR(t, dir, "list")
+	assertContains(t, "marks pre-amend comment", output, "Drop the log [pre-amend]")
+
+	output = mustRunGR(t, dir, "status")
+	assertContains(t, "status counts pre-amend", output, "1 comment, 1 pre-amend")
+}
+
+func TestList_DoesNotGuessBetweenCommitsWithTheSameSubject(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	writeFile(t, dir, "notes.txt", "first\n")
+	gitCmd(t, dir, "add", "notes.txt")
+	gitCmd(t, dir, "commit", "-m", "Tweak")
+	writeFile(t, dir, "notes.txt", "second\n")
+	gitCmd(t, dir, "commit", "-a", "-m", "Tweak")
+
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "-f", "notes.txt", "Say why")
+
+	// A new commit changes the count, so counterparts are matched by subject
+	extra := filepath.Join(t.TempDir(), "extra")
+	gitCmd(t, dir, "worktree", "add", extra, "feature/test")
+	writeFile(t, extra, "other.txt", "more\n")
+	gitCmd(t, extra, "add", "other.txt")
+	gitCmd(t, extra, "commit", "-m", "Add other file")
+
+	output := mustRunGR(t, dir, "list")
+	assertContains(t, "lists comment", output, "Say why")
+	assertNotContains(t, "ambiguous subject is not matched", output, "[pre-amend]")
+}
+
 func TestStatus_ShowsProgressAndCommentCount(t *testing.T) {
 	t.Parallel()
 	dir := setupTestRepo(t)
@@ -184,6 +510,127 @@ func TestStatus_ShowsProgressAndCommentCount(t *testing.T) {
 	assertContains(t, "shows comment count", output, "1 comment")
 }
 
+func TestStatus_FailOnUnresolved(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "status", "--fail-on-unresolved")
+	mustRunGR(t, dir, "add", "Open thread")
+
+	exitCode := func(err error) int {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("expected an exit error, got %v", err)
+		}
+		return exitErr.ExitCode()
+	}
+
+	out, err := runGR(t, dir, "status", "--fail-on-unresolved")
+	if code := exitCode(err); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+	assertContains(t, "still prints progress", out, "Review Progress")
+	assertContains(t, "reports count", out, "1 unresolved thread")
+
+	out, err = runGR(t, dir, "status", "--fail-on-unresolved", "--quiet")
+	if code := exitCode(err); code != 2 {
+		t.Errorf("quiet exit code = %d, want 2", code)
+	}
+	if out != "" {
+		t.Errorf("--quiet should print nothing, got:\n%s", out)
+	}
+
+	state := loadState(t, dir)
+	mustRunGR(t, dir, "resolve", findCommentByBody(stateComments(t, state), "Open thread")["id"].(string))
+	mustRunGR(t, dir, "status", "--fail-on-unresolved", "--quiet")
+}
+
+func TestStatus_JSON(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "Open thread")
+	mustRunGR(t, dir, "add", "Done thread")
+	state := loadState(t, dir)
+	mustRunGR(t, dir, "resolve", findCommentByBody(stateComments(t, state), "Done thread")["id"].(string))
+
+	var st struct {
+		Branch          string
+		CurrentPosition *int64 `json:"currentPosition"`
+		TotalCommits    int    `json:"totalCommits"`
+		Reviewers       []struct {
+			Name     string
+			Position *int64
+		}
+		Commits []struct {
+			Message  string
+			Comments int
+		}
+		ResolvedThreads int `json:"resolvedThreads"`
+		OpenThreads     int `json:"openThreads"`
+	}
+	if err := json.Unmarshal([]byte(mustRunGR(t, dir, "status", "--json")), &st); err != nil {
+		t.Fatalf("invalid status JSON: %v", err)
+	}
+	if st.Branch != "feature/test" || st.TotalCommits != 3 || len(st.Commits) != 3 {
+		t.Errorf("unexpected status: %+v", st)
+	}
+	if st.CurrentPosition == nil || *st.CurrentPosition != 1 {
+		t.Errorf("currentPosition = %v, want 1", st.CurrentPosition)
+	}
+	if len(st.Reviewers) != 1 || st.Reviewers[0].Position == nil || *st.Reviewers[0].Position != 1 {
+		t.Errorf("unexpected reviewers: %+v", st.Reviewers)
+	}
+	if st.Commits[1].Message != "Add goodbye function" || st.Commits[1].Comments != 2 || st.Commits[0].Comments != 0 {
+		t.Errorf("unexpected per-commit counts: %+v", st.Commits)
+	}
+	if st.OpenThreads != 1 || st.ResolvedThreads != 1 {
+		t.Errorf("threads = %d open, %d resolved; want 1 and 1", st.OpenThreads, st.ResolvedThreads)
+	}
+}
+
+func TestStats_SummarizesComments(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "fix this")
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "and this")
+	mustRunGR(t, dir, "add", "general note")
+
+	state := loadState(t, dir)
+	root := findCommentByBody(stateComments(t, state), "fix this")
+	mustRunGR(t, dir, "resolve", root["id"].(string))
+
+	output := mustRunGR(t, dir, "stats")
+	assertContains(t, "total", output, "Comments: 3")
+	assertContains(t, "threads", output, "3 (1 resolved, 2 open, 33% resolved)")
+	assertContains(t, "busiest file", output, "Busiest file: app.js (2 comments)")
+
+	var s map[string]interface{}
+	if err := json.Unmarshal([]byte(mustRunGR(t, dir, "stats", "--json")), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s["comments"].(float64) != 3 || s["busiestFile"].(string) != "app.js" {
+		t.Errorf("unexpected stats JSON: %v", s)
+	}
+}
+
+func TestStatus_ShowsBaseOneline(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+
+	output := mustRunGR(t, dir, "status")
+	assertContains(t, "status shows base", output, "Base: ")
+	assertContains(t, "status shows base subject", output, "Initial commit")
+
+	state := loadState(t, dir)
+	if oneline, _ := state["baseOneline"].(string); !strings.HasSuffix(oneline, " Initial commit") {
+		t.Errorf("baseOneline = %q, want suffix %q", oneline, " Initial commit")
+	}
+}
+
 func TestNoArgs_ShowsStatusDuringReview(t *testing.T) {
 	t.Parallel()
 	dir := setupTestRepo(t)
@@ -193,6 +640,21 @@ func TestNoArgs_ShowsStatusDuringReview(t *testing.T) {
 	assertContains(t, "shows status on no args", output, "Review Progress")
 }
 
+func TestNoArgs_ShowsWhereToResume(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+
+	output := mustRunGR(t, dir)
+	assertContains(t, "current position", output, "You are on commit 2/3: Add goodbye function")
+	assertContains(t, "restore command", output, "git review jump 2")
+	assertContains(t, "next command", output, "git review next      Continue with 3/3: Add main entry")
+
+	mustRunGR(t, dir, "next")
+	assertContains(t, "last commit", mustRunGR(t, dir), "git review finish")
+}
+
 func TestUnknownCommand_ReturnsErrorDuringReview(t *testing.T) {
 	t.Parallel()
 	dir := setupTestRepo(t)
@@ -318,6 +780,48 @@ func TestReplyTo_CreatesReply(t *testing.T) {
 	}
 }
 
+func TestAdd_QuestionClearedByReplyFromOtherAuthor(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-a", "alice", "--question", "Why not a const?")
+	mustRunGR(t, dir, "add", "-a", "alice", "Plain note")
+
+	output := mustRunGR(t, dir, "list", "--needs-response")
+	assertContains(t, "shows open question", output, "Why not a const? @alice [needs response]")
+	assertNotContains(t, "hides plain note", output, "Plain note")
+
+	state := loadState(t, dir)
+	question := findCommentByBody(stateComments(t, state), "Why not a const?")
+	questionID := question["id"].(string)
+
+	mustRunGR(t, dir, "add", "-a", "alice", "--reply-to", questionID, "Any thoughts?")
+	output = mustRunGR(t, dir, "list", "--needs-response")
+	assertContains(t, "own reply keeps question open", output, "Why not a const?")
+
+	mustRunGR(t, dir, "add", "-a", "bob", "--reply-to", questionID, "It gets reassigned.")
+	output = mustRunGR(t, dir, "list", "--needs-response")
+	assertNotContains(t, "answered question hidden", output, "Why not a const?")
+}
+
+func TestDismiss_ClearsQuestion(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "--question", "Is this tested?")
+
+	state := loadState(t, dir)
+	question := findCommentByBody(stateComments(t, state), "Is this tested?")
+	mustRunGR(t, dir, "dismiss", question["id"].(string)[:8])
+
+	output := mustRunGR(t, dir, "list", "--needs-response")
+	assertNotContains(t, "dismissed question hidden", output, "Is this tested?")
+
+	if _, err := runGR(t, dir, "dismiss", question["id"].(string)[:8]); err == nil {
+		t.Error("expected error dismissing a comment that is not a question")
+	}
+}
+
 func TestReplyTo_InheritsCommitFromParent(t *testing.T) {
 	t.Parallel()
 	dir := setupTestRepo(t)
@@ -388,23 +892,71 @@ func TestDelete_HardDeleteRoot_CascadesChildren(t *testing.T) {
 	}
 }
 
-func TestDelete_NonRoot_ReparentsChildren(t *testing.T) {
+func TestDelete_PromoteKeepsReplies(t *testing.T) {
 	t.Parallel()
 	dir := setupTestRepo(t)
 	mustRunGR(t, dir)
-	mustRunGR(t, dir, "next")
-	mustRunGR(t, dir, "add", "parent comment")
-
-	state := loadState(t, dir)
-	comments := stateComments(t, state)
-	parentID := comments[0]["id"].(string)
+	second := gitCmd(t, dir, "rev-parse", "feature/test~1")
+	mustRunGR(t, dir, "add", "--severity", "major", "-q", "--assign", "bob", "--also-commit", second, "--fixup", second,
+		"-f", "app.js", "-f", "README.md", "--force", "parent comment")
+	parentID := findCommentByBody(stateComments(t, loadState(t, dir)), "parent comment")["id"].(string)
 
-	mustRunGR(t, dir, "add", "--reply-to", parentID, "middle reply")
+	mustRunGR(t, dir, "add", "--reply-to", parentID, "reply 1")
+	mustRunGR(t, dir, "add", "--reply-to", parentID, "reply 2")
+	reply1ID := findCommentByBody(stateComments(t, loadState(t, dir)), "reply 1")["id"].(string)
 
-	state = loadState(t, dir)
-	comments = stateComments(t, state)
-	middleReply := findCommentByBody(comments, "middle reply")
-	middleID := middleReply["id"].(string)
+	output := mustRunGR(t, dir, "delete", "--promote", parentID)
+	assertContains(t, "names new root", output, "is now the root of the thread")
+
+	remaining := stateComments(t, loadState(t, dir))
+	if len(remaining) != 2 {
+		t.Fatalf("expected 2 comments after promote, got %d", len(remaining))
+	}
+	reply1 := findCommentByBody(remaining, "reply 1")
+	if reply1["parentId"] != nil {
+		t.Errorf("oldest reply should be the new root, got parentId=%v", reply1["parentId"])
+	}
+	if reply1["resolvedAt"] == nil || reply1["severity"] != "major" {
+		t.Errorf("new root should keep the thread's resolution and severity, got %v / %v", reply1["resolvedAt"], reply1["severity"])
+	}
+	if reply1["assignee"] != "bob" || reply1["fixup"] != second || reply1["alsoCommit"] != second || reply1["needsResponse"] != true {
+		t.Errorf("new root should keep the thread's assignee, fixup, second commit and question, got %v", reply1)
+	}
+	if files, _ := reply1["files"].([]interface{}); len(files) != 2 || files[1] != "README.md" {
+		t.Errorf("new root should keep the thread's files, got %v", reply1["files"])
+	}
+	if got := findCommentByBody(remaining, "reply 2")["parentId"]; got != reply1ID {
+		t.Errorf("reply 2 should move under the new root, got parentId=%v", got)
+	}
+
+	mustRunGR(t, dir, "undo")
+	restored := stateComments(t, loadState(t, dir))
+	if files, _ := findCommentByBody(restored, "parent comment")["files"].([]interface{}); len(files) != 2 {
+		t.Errorf("undo should give the root back its files, got %v", files)
+	}
+	reply1 = findCommentByBody(restored, "reply 1")
+	if reply1["parentId"] != parentID || reply1["assignee"] != nil || reply1["files"] != nil {
+		t.Errorf("undo should turn the new root back into a plain reply, got %v", reply1)
+	}
+}
+
+func TestDelete_NonRoot_ReparentsChildren(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "parent comment")
+
+	state := loadState(t, dir)
+	comments := stateComments(t, state)
+	parentID := comments[0]["id"].(string)
+
+	mustRunGR(t, dir, "add", "--reply-to", parentID, "middle reply")
+
+	state = loadState(t, dir)
+	comments = stateComments(t, state)
+	middleReply := findCommentByBody(comments, "middle reply")
+	middleID := middleReply["id"].(string)
 
 	mustRunGR(t, dir, "add", "--reply-to", middleID, "grandchild")
 
@@ -551,6 +1103,36 @@ func TestResolve_ErrorOnNonRoot(t *testing.T) {
 	}
 }
 
+func TestResolve_ByCreatorScopedToCommit(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-a", "alice", "Alice on first")
+	mustRunGR(t, dir, "add", "-a", "bob", "Bob on first")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "-a", "alice", "Alice on second")
+	firstSHA := loadState(t, dir)["commits"].([]interface{})[0].(string)
+
+	output := mustRunGR(t, dir, "resolve", "--creator", "alice", "--commit", firstSHA[:7], "-a", "lead")
+	assertContains(t, "reports count", output, "Resolved 1 thread")
+
+	comments := stateComments(t, loadState(t, dir))
+	if c := findCommentByBody(comments, "Alice on first"); c["resolvedBy"] != "lead" {
+		t.Errorf("alice's thread on the first commit: resolvedBy = %v, want lead", c["resolvedBy"])
+	}
+	for _, body := range []string{"Bob on first", "Alice on second"} {
+		if findCommentByBody(comments, body)["resolvedAt"] != nil {
+			t.Errorf("%q should stay open", body)
+		}
+	}
+
+	output = mustRunGR(t, dir, "resolve", "--creator", "alice")
+	assertContains(t, "resolves the rest of alice's threads", output, "Resolved 1 thread")
+	if findCommentByBody(stateComments(t, loadState(t, dir)), "Bob on first")["resolvedAt"] != nil {
+		t.Error("bob's thread should stay open")
+	}
+}
+
 func TestUnresolve_UnresolvesComment(t *testing.T) {
 	t.Parallel()
 	dir := setupTestRepo(t)
@@ -572,6 +1154,84 @@ func TestUnresolve_UnresolvesComment(t *testing.T) {
 	}
 }
 
+func TestUnresolve_ByCommit(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Issue on first")
+	mustRunGR(t, dir, "add", "Another on first")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "Issue on second")
+
+	state := loadState(t, dir)
+	comments := stateComments(t, state)
+	for _, c := range comments {
+		mustRunGR(t, dir, "resolve", c["id"].(string), "-a", "reviewer")
+	}
+	firstSHA := state["commits"].([]interface{})[0].(string)
+
+	output := mustRunGR(t, dir, "unresolve", "--commit", firstSHA[:7])
+	assertContains(t, "reports count", output, "Unresolved 2 threads")
+
+	state = loadState(t, dir)
+	comments = stateComments(t, state)
+	if findCommentByBody(comments, "Issue on first")["resolvedAt"] != nil {
+		t.Error("comment on first commit should be unresolved")
+	}
+	if findCommentByBody(comments, "Another on first")["resolvedAt"] != nil {
+		t.Error("comment on first commit should be unresolved")
+	}
+	if findCommentByBody(comments, "Issue on second")["resolvedAt"] == nil {
+		t.Error("comment on second commit should stay resolved")
+	}
+}
+
+func TestUnresolve_All(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Issue on first")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "Issue on second")
+	mustRunGR(t, dir, "add", "Still open")
+
+	state := loadState(t, dir)
+	comments := stateComments(t, state)
+	mustRunGR(t, dir, "resolve", findCommentByBody(comments, "Issue on first")["id"].(string))
+	mustRunGR(t, dir, "resolve", findCommentByBody(comments, "Issue on second")["id"].(string))
+
+	output := mustRunGR(t, dir, "unresolve", "--all")
+	assertContains(t, "reports count", output, "Unresolved 2 threads")
+
+	state = loadState(t, dir)
+	for _, c := range stateComments(t, state) {
+		if c["resolvedAt"] != nil {
+			t.Errorf("comment %v should be unresolved", c["body"])
+		}
+	}
+}
+
+func TestUnresolve_BatchIsNoOpWhenNothingResolved(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Open issue")
+
+	output := mustRunGR(t, dir, "unresolve", "--all")
+	assertContains(t, "reports zero", output, "Unresolved 0 threads")
+}
+
+func TestUnresolve_ErrorWithoutTarget(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+
+	_, err := runGR(t, dir, "unresolve")
+	if err == nil {
+		t.Fatal("expected error when no ID, --commit, or --all given")
+	}
+}
+
 func TestState_OutputsJSON(t *testing.T) {
 	t.Parallel()
 	dir := setupTestRepo(t)
@@ -633,6 +1293,115 @@ func TestJump_ToSpecificCommit(t *testing.T) {
 	assertContains(t, "shows position", output, "[2/3]")
 }
 
+func TestReorder_NextFollowsNewOrderWithTrueDiffs(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+
+	commits := loadState(t, dir)["commits"].([]interface{})
+	first, second, third := commits[0].(string), commits[1].(string), commits[2].(string)
+
+	output := mustRunGR(t, dir, "reorder", third[:7], first[:7], second[:7])
+	assertContains(t, "lists new order", output, "1. "+third[:7]+" Add main entry")
+
+	output = mustRunGR(t, dir, "jump", third[:7])
+	assertContains(t, "third commit comes first", output, "[1/3]")
+	diff := gitCmd(t, dir, "diff", "--cached")
+	assertContains(t, "diff against true parent", diff, "+console.log(hello());")
+	assertNotContains(t, "only the commit's own change", diff, "+function goodbye")
+
+	output = mustRunGR(t, dir, "next")
+	assertContains(t, "next follows new order", output, "Add hello function")
+	assertContains(t, "shows new position", output, "[2/3]")
+	diff = gitCmd(t, dir, "diff", "--cached")
+	assertContains(t, "first commit diffs against base", diff, "+function hello")
+	assertNotContains(t, "only the commit's own change", diff, "+console.log")
+
+	output = mustRunGR(t, dir, "next")
+	assertContains(t, "next follows new order", output, "Add goodbye function")
+	assertContains(t, "shows new position", output, "[3/3]")
+	diff = gitCmd(t, dir, "diff", "--cached")
+	assertContains(t, "diff against true parent", diff, "+function goodbye")
+	assertNotContains(t, "only the commit's own change", diff, "+console.log")
+
+	assertContains(t, "end of new order", mustRunGR(t, dir, "next"), "All commits reviewed")
+}
+
+func TestReorder_RequiresEveryCommitOnce(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+
+	commits := loadState(t, dir)["commits"].([]interface{})
+	first, second := commits[0].(string), commits[1].(string)
+
+	if _, err := runGR(t, dir, "reorder", second[:7], first[:7]); err == nil {
+		t.Error("expected error when a commit is missing")
+	}
+	if _, err := runGR(t, dir, "reorder", second[:7], first[:7], first[:7]); err == nil {
+		t.Error("expected error for a repeated commit")
+	}
+}
+
+func TestJump_ByPosition(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+
+	output := mustRunGR(t, dir, "jump", "3")
+	assertContains(t, "jumps to third commit", output, "Add main entry")
+	assertContains(t, "shows position", output, "[3/3]")
+
+	output = mustRunGR(t, dir, "jump", "1")
+	assertContains(t, "jumps back to first commit", output, "Add hello function")
+
+	for _, pos := range []string{"0", "4"} {
+		if out, err := runGR(t, dir, "jump", pos); err == nil {
+			t.Errorf("jump %s: expected out-of-range error", pos)
+		} else {
+			assertContains(t, "explains range", out, "out of range")
+		}
+	}
+}
+
+func TestOpen_LaunchesEditorAtCommentLine(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "2", "Rename goodbye")
+	id := findCommentByBody(stateComments(t, loadState(t, dir)), "Rename goodbye")["id"].(string)
+
+	// The "editor" records its arguments and the file it was given
+	argsFile := filepath.Join(t.TempDir(), "args")
+	editor := []string{"VISUAL=", `EDITOR=sh -c 'echo "$@" > ` + argsFile + `; cat "$2" >> ` + argsFile + `' editor`}
+
+	mustOK := func(out string, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("open: %v\n%s", err, out)
+		}
+	}
+	mustOK(runGRWithEnv(t, dir, editor, "open", id))
+	got, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, "positions at line", string(got), "+2 "+filepath.Join(dir, "app.js"))
+	assertContains(t, "reviewed version of the file", string(got), "goodbye")
+	assertNotContains(t, "not a later version", string(got), "console.log")
+
+	mustOK(runGRWithEnv(t, dir, editor, "open", "-f", "app.js", "-l", "1-2"))
+	got, _ = os.ReadFile(argsFile)
+	assertContains(t, "uses start of range", string(got), "+1 ")
+
+	out, err := runGRWithEnv(t, dir, []string{"VISUAL=", "EDITOR="}, "open", id)
+	if err == nil {
+		t.Fatal("expected an error without an editor")
+	}
+	assertContains(t, "explains missing editor", out, "No editor set")
+}
+
 func TestJump_NotFound(t *testing.T) {
 	t.Parallel()
 	dir := setupTestRepo(t)
@@ -663,17 +1432,2131 @@ func TestList_FilterByUnresolved(t *testing.T) {
 	assertNotContains(t, "hides resolved", output, "resolved issue")
 }
 
-func TestFinish_WritesGitNotes(t *testing.T) {
+func TestList_CollapseResolved(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Resolved thread")
+	mustRunGR(t, dir, "add", "Open thread")
+
+	state := loadState(t, dir)
+	comments := stateComments(t, state)
+	resolvedID := findCommentByBody(comments, "Resolved thread")["id"].(string)
+	openID := findCommentByBody(comments, "Open thread")["id"].(string)
+
+	mustRunGR(t, dir, "add", "--reply-to", resolvedID, "First reply")
+	mustRunGR(t, dir, "add", "--reply-to", resolvedID, "Second reply")
+	mustRunGR(t, dir, "add", "--reply-to", openID, "Open reply")
+	mustRunGR(t, dir, "resolve", resolvedID, "-a", "reviewer")
+
+	output := mustRunGR(t, dir, "list", "--collapse-resolved")
+	assertContains(t, "shows resolved root", output, "Resolved thread")
+	assertContains(t, "shows resolved tag", output, "[resolved by reviewer]")
+	assertContains(t, "shows reply count", output, "(2 replies)")
+	assertNotContains(t, "hides first reply", output, "First reply")
+	assertNotContains(t, "hides second reply", output, "Second reply")
+	assertContains(t, "shows unresolved reply", output, "Open reply")
+
+	output = mustRunGR(t, dir, "list", "--collapse-resolved", "--unresolved")
+	assertNotContains(t, "--unresolved hides resolved", output, "Resolved thread")
+	assertContains(t, "--unresolved keeps open threads whole", output, "Open reply")
+}
+
+func TestList_HTMLLinksIssueReferences(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	gitCmd(t, dir, "config", "--add", "review.issueLinkPattern", `#(\d+)`)
+	gitCmd(t, dir, "config", "--add", "review.issueLinkUrl", "https://example/issues/$1")
+	gitCmd(t, dir, "config", "--add", "review.issueLinkPattern", `\b(JIRA-\d+)\b`)
+	gitCmd(t, dir, "config", "--add", "review.issueLinkUrl", "https://jira.example/browse/$1")
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Fixes #123 & JIRA-456")
+
+	output := mustRunGR(t, dir, "list", "--format=html")
+	assertContains(t, "links issue", output, `Fixes <a href="https://example/issues/123">#123</a> &amp; `)
+	assertContains(t, "links second pattern", output, `<a href="https://jira.example/browse/JIRA-456">JIRA-456</a>`)
+
+	output = mustRunGR(t, dir, "list")
+	assertContains(t, "links in markdown", output, "Fixes [#123](https://example/issues/123) & ")
+
+	state := loadState(t, dir)
+	comment := findCommentByBody(stateComments(t, state), "Fixes #123 & JIRA-456")
+	if comment == nil {
+		t.Error("stored body should be unchanged")
+	}
+}
+
+func TestServeStdio_AddThenState(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"add","params":{"message":"Served comment","file":"app.js","line":"1"}}
+{"jsonrpc":"2.0","id":2,"method":"state"}
+`
+	out, err := runGRWithInput(t, dir, input, "--serve-stdio")
+	if err != nil {
+		t.Fatalf("serve-stdio: %v\n%s", err, out)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 responses, got %d:\n%s", len(lines), out)
+	}
+
+	var add struct {
+		ID     int `json:"id"`
+		Result struct {
+			Output string `json:"output"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &add); err != nil {
+		t.Fatalf("add response: %v\n%s", err, lines[0])
+	}
+	if add.ID != 1 {
+		t.Errorf("add response id = %d, want 1", add.ID)
+	}
+	assertContains(t, "add output", add.Result.Output, "Served comment")
+
+	var state struct {
+		ID     int                    `json:"id"`
+		Result map[string]interface{} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &state); err != nil {
+		t.Fatalf("state response: %v\n%s", err, lines[1])
+	}
+	if state.ID != 2 {
+		t.Errorf("state response id = %d, want 2", state.ID)
+	}
+	c := findCommentByBody(stateComments(t, state.Result), "Served comment")
+	if c == nil {
+		t.Fatal("state should include the comment added over the same connection")
+	}
+	if c["file"] != "app.js" {
+		t.Errorf("file = %v, want app.js", c["file"])
+	}
+}
+
+func TestServeStdio_FollowsARestartedReview(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "From the first review")
+
+	cmd := exec.Command(binaryPath, "--serve-stdio")
+	cmd.Dir = dir
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		stdin.Close()
+		_ = cmd.Wait()
+	}()
+	responses := json.NewDecoder(stdout)
+	state := func() []map[string]interface{} {
+		t.Helper()
+		if _, err := stdin.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"state"}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		var resp struct {
+			Result map[string]interface{} `json:"result"`
+		}
+		if err := responses.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return stateComments(t, resp.Result)
+	}
+
+	if findCommentByBody(state(), "From the first review") == nil {
+		t.Fatal("state should show the first review")
+	}
+
+	// Between two requests the review ends and another begins in a new database file
+	mustRunGR(t, dir, "abort", "--force")
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "From the second review")
+
+	comments := state()
+	if findCommentByBody(comments, "From the second review") == nil || findCommentByBody(comments, "From the first review") != nil {
+		t.Errorf("state should show only the second review, got %v", comments)
+	}
+}
+
+func TestServeStdio_ParamsKeepFlagDefaults(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Served thread")
+
+	// Neither request sets format or sort, which default to markdown and commit
+	input := `{"jsonrpc":"2.0","id":1,"method":"list","params":{"stat":true}}
+{"jsonrpc":"2.0","id":2,"method":"list","params":{}}
+`
+	out, err := runGRWithInput(t, dir, input, "--serve-stdio")
+	if err != nil {
+		t.Fatalf("serve-stdio: %v\n%s", err, out)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 responses, got %d:\n%s", len(lines), out)
+	}
+	for i, want := range []string{"1 comment (1 open)", "# Review Comments"} {
+		var resp struct {
+			Result struct {
+				Output string `json:"output"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &resp); err != nil {
+			t.Fatalf("response %d: %v\n%s", i+1, err, lines[i])
+		}
+		if resp.Error != nil {
+			t.Fatalf("response %d failed: %s", i+1, resp.Error.Message)
+		}
+		assertContains(t, "list response", resp.Result.Output, want)
+	}
+}
+
+func TestResolve_AmbiguousPrefix(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "First")
+	mustRunGR(t, dir, "add", "Second")
+
+	// UUIDv7 IDs created moments apart share their leading timestamp digits.
+	state := loadState(t, dir)
+	first := findCommentByBody(stateComments(t, state), "First")["id"].(string)
+	out, err := runGR(t, dir, "resolve", first[:2])
+	if err == nil {
+		t.Fatal("expected an error for a prefix matching two comments")
+	}
+	assertContains(t, "ambiguity error", out, "ambiguous comment ID prefix")
+
+	mustRunGR(t, dir, "resolve", first)
+	state = loadState(t, dir)
+	if findCommentByBody(stateComments(t, state), "Second")["resolvedAt"] != nil {
+		t.Error("ambiguous prefix must not resolve the other comment")
+	}
+}
+
+func TestList_FlatAcrossCommits(t *testing.T) {
 	t.Parallel()
 	dir := setupTestRepo(t)
 	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "On first")
 	mustRunGR(t, dir, "next")
-	mustRunGR(t, dir, "add", "Good function naming")
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "2", "On second")
 	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "On third")
+
+	commits := loadState(t, dir)["commits"].([]interface{})
+	output := mustRunGR(t, dir, "list", "--flat")
+	assertNotContains(t, "no commit headers", output, "## Commit")
+	assertContains(t, "first with SHA", output, "("+commits[0].(string)[:7]+") On first")
+	assertContains(t, "second with SHA and location", output, "("+commits[1].(string)[:7]+") app.js:2: On second")
+	assertContains(t, "third with SHA", output, "("+commits[2].(string)[:7]+") On third")
+	if strings.Index(output, "On first") > strings.Index(output, "On third") {
+		t.Error("flat output should be chronological")
+	}
+}
+
+func TestList_ByFileAcrossCommits(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "Hello on first")
+	mustRunGR(t, dir, "add", "Overall on first")
 	mustRunGR(t, dir, "next")
-	mustRunGR(t, dir, "finish")
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "2", "Goodbye on second")
 
-	// Check git notes on the first commit (Add hello function)
-	notes := gitCmd(t, dir, "log", "--notes", "--format=%N", "main..feature/test")
-	assertContains(t, "notes contain comment", notes, "Good function naming")
+	commits := loadState(t, dir)["commits"].([]interface{})
+	output := mustRunGR(t, dir, "list", "--by-file")
+	assertNotContains(t, "no commit headers", output, "## Commit")
+	if n := strings.Count(output, "## app.js"); n != 1 {
+		t.Errorf("expected one app.js section, got %d:\n%s", n, output)
+	}
+	assertContains(t, "first with SHA", output, "("+commits[0].(string)[:7]+") L1: Hello on first")
+	assertContains(t, "second with SHA", output, "("+commits[1].(string)[:7]+") L2: Goodbye on second")
+	if strings.Index(output, "Hello on first") > strings.Index(output, "Goodbye on second") {
+		t.Error("threads in a file should follow review order")
+	}
+	if strings.Index(output, "## General") < strings.Index(output, "Goodbye on second") {
+		t.Error("comments without a file should come after the file sections")
+	}
+
+	if _, err := runGR(t, dir, "list", "--by-file", "--flat"); err == nil {
+		t.Error("expected --by-file and --flat together to fail")
+	}
+}
+
+func TestList_StatCountsPerCommit(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "2", "Open thread")
+	mustRunGR(t, dir, "add", "Fixed thread")
+	state := loadState(t, dir)
+	fixed := findCommentByBody(stateComments(t, state), "Fixed thread")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", fixed, "Done")
+	mustRunGR(t, dir, "resolve", fixed)
+
+	commits := state["commits"].([]interface{})
+	output := mustRunGR(t, dir, "list", "--stat")
+	assertContains(t, "commit without comments", output, "1/3 "+commits[0].(string)[:7]+" Add hello function — no comments")
+	assertContains(t, "counts", output, "2/3 "+commits[1].(string)[:7]+" Add goodbye function — 3 comments (1 open, 1 resolved)")
+	assertNotContains(t, "no thread bodies", output, "Open thread")
+}
+
+func TestList_GFMRendersTaskLists(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "2", "Use bcrypt\nnot md5")
+	mustRunGR(t, dir, "add", "Fixed thread")
+	state := loadState(t, dir)
+	fixed := findCommentByBody(stateComments(t, state), "Fixed thread")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", fixed, "Done")
+	mustRunGR(t, dir, "resolve", fixed)
+	state = loadState(t, dir)
+	done := findCommentByBody(stateComments(t, state), "Done")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", done, "Thanks")
+
+	output := mustRunGR(t, dir, "list", "--gfm")
+	assertContains(t, "resolved thread checked", output, "- [x] Fixed thread")
+	assertContains(t, "nested replies", output, "\n  - Done\n    - Thanks\n")
+	assertContains(t, "location as inline code", output, "- [ ] `app.js:2` Use bcrypt\n  not md5\n")
+	assertContains(t, "commit without comments", output, "_No comments_")
+	assertNotContains(t, "no IDs", output, "["+fixed[:8]+"]")
+
+	if _, err := runGR(t, dir, "list", "--gfm", "--format", "html"); err == nil {
+		t.Error("--gfm with --format=html should fail")
+	}
+}
+
+func TestStatus_WatchRedrawsOnChange(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+
+	logPath := filepath.Join(t.TempDir(), "watch.log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logFile.Close()
+	cmd := exec.Command(binaryPath, "status", "--watch")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TERM=dumb")
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor := func(what string) string {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			data, _ := os.ReadFile(logPath)
+			if strings.Contains(string(data), what) || time.Now().After(deadline) {
+				return string(data)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	assertContains(t, "first frame", waitFor("Review Progress"), "Review Progress")
+	mustRunGR(t, dir, "add", "Live comment")
+	assertContains(t, "redrawn after add", waitFor("(1 comment"), "(1 comment")
+
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("status --watch should exit cleanly on interrupt: %v", err)
+	}
+
+	output, err := runGR(t, dir, "status", "--watch", "--json")
+	if err == nil {
+		t.Errorf("--watch with --json should fail:\n%s", output)
+	}
+}
+
+func TestList_DepthLimitsReplyNesting(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Root comment")
+	parent := findCommentByBody(stateComments(t, loadState(t, dir)), "Root comment")["id"].(string)
+	for _, body := range []string{"First level", "Second level", "Third level"} {
+		mustRunGR(t, dir, "add", "-r", parent, body)
+		parent = findCommentByBody(stateComments(t, loadState(t, dir)), body)["id"].(string)
+	}
+
+	output := mustRunGR(t, dir, "list", "--depth", "1")
+	assertContains(t, "direct reply shown", output, "First level")
+	assertNotContains(t, "deeper replies hidden", output, "Second level")
+	assertContains(t, "hidden replies counted", output, "(… 2 more replies)")
+
+	output = mustRunGR(t, dir, "list", "--depth", "1", "--gfm")
+	assertContains(t, "gfm direct reply", output, "  - First level")
+	assertContains(t, "gfm hint nested under the reply", output, "    - (… 2 more replies)")
+
+	output = mustRunGR(t, dir, "list")
+	assertContains(t, "all replies by default", output, "Third level")
+	assertNotContains(t, "no hint by default", output, "more repl")
+}
+
+func TestHistory_RecordsResolutionChanges(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "Tracked thread")
+	id := findCommentByBody(stateComments(t, loadState(t, dir)), "Tracked thread")["id"].(string)
+	mustRunGR(t, dir, "resolve", id, "-a", "bob")
+	mustRunGR(t, dir, "unresolve", id)
+
+	output := mustRunGR(t, dir, "history", id)
+	assertContains(t, "header", output, "app.js:1: Tracked thread")
+	created := strings.Index(output, " created ")
+	resolved := strings.Index(output, " resolved   bob")
+	unresolved := strings.Index(output, " unresolved ")
+	if created < 0 || resolved < created || unresolved < resolved {
+		t.Errorf("expected created, resolved by bob, unresolved in order:\n%s", output)
+	}
+}
+
+func TestJump_NextUnresolvedFindsOpenThreads(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "First commit thread")
+	mustRunGR(t, dir, "jump", "3")
+	mustRunGR(t, dir, "add", "Last commit thread")
+	mustRunGR(t, dir, "jump", "2")
+
+	output := mustRunGR(t, dir, "jump", "--next-unresolved")
+	assertContains(t, "jumps forward", output, "[3/3]")
+	output = mustRunGR(t, dir, "jump", "--next-unresolved")
+	assertContains(t, "wraps around", output, "[1/3]")
+
+	for _, body := range []string{"First commit thread", "Last commit thread"} {
+		mustRunGR(t, dir, "resolve", findCommentByBody(stateComments(t, loadState(t, dir)), body)["id"].(string))
+	}
+	output = mustRunGR(t, dir, "jump", "--next-unresolved")
+	assertContains(t, "nothing left", output, "No unresolved threads remain.")
+}
+
+func TestState_WithDiffIncludesCurrentCommit(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+
+	if _, ok := loadState(t, dir)["diff"]; ok {
+		t.Error("state should not include the diff by default")
+	}
+	var state map[string]interface{}
+	if err := json.Unmarshal([]byte(mustRunGR(t, dir, "state", "--with-diff")), &state); err != nil {
+		t.Fatal(err)
+	}
+	diff, _ := state["diff"].(string)
+	assertContains(t, "current commit's change", diff, "+function goodbye()")
+	assertNotContains(t, "not earlier commits", diff, "+function hello()")
+}
+
+func TestStart_RoleTagsReviewer(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "start", "-a", "alice", "--role", "security")
+	worktree := filepath.Join(dir, ".git", "review", "worktrees", "alice")
+	mustRunGR(t, worktree, "add", "Check input validation")
+
+	assertContains(t, "list author tag", mustRunGR(t, dir, "list"), "Check input validation @alice(security)")
+	assertContains(t, "status reviewer", mustRunGR(t, dir, "status"), "Reviewer alice(security): 1/3")
+
+	// Rejoining with another role changes it
+	mustRunGR(t, dir, "start", "-a", "alice", "--role", "perf")
+	assertContains(t, "role changed", mustRunGR(t, dir, "list"), "@alice(perf)")
+
+	if _, err := runGR(t, dir, "start", "--role", "perf"); err == nil {
+		t.Error("--role without -a should fail while a review is in progress")
+	}
+}
+
+func TestAdd_ResolveRepliesAndResolves(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Use bcrypt")
+
+	// IDs made close together share their short form, so give the root one of its own
+	root := "00000000-0000-7000-8000-000000000001"
+	conn, err := sql.Open("sqlite", filepath.Join(dir, ".git", "review", "review.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("UPDATE comments SET id = ? WHERE body = 'Use bcrypt'", root); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	mustRunGR(t, dir, "add", "-r", root, "Asked in a reply")
+	reply := findCommentByBody(stateComments(t, loadState(t, dir)), "Asked in a reply")["id"].(string)
+
+	// Replying to a reply resolves the thread's root
+	output := mustRunGR(t, dir, "add", "-r", reply, "--resolve", "-a", "implementer", "Fixed: switched to bcrypt")
+	assertContains(t, "names the resolved root", output, "Resolved ["+root[:8]+"]")
+	comments := stateComments(t, loadState(t, dir))
+	if findCommentByBody(comments, "Fixed: switched to bcrypt") == nil {
+		t.Fatal("reply not saved")
+	}
+	if got := findCommentByBody(comments, "Use bcrypt")["resolvedBy"]; got != "implementer" {
+		t.Errorf("thread should be resolved by the reply's author, got %v", got)
+	}
+
+	if _, err := runGR(t, dir, "add", "-r", root, "--resolve", "Again"); err == nil {
+		t.Error("--resolve on a resolved thread should fail")
+	}
+	if findCommentByBody(stateComments(t, loadState(t, dir)), "Again") != nil {
+		t.Error("the reply should not be saved when resolving fails")
+	}
+	if _, err := runGR(t, dir, "add", "--resolve", "No thread"); err == nil {
+		t.Error("--resolve without --reply-to should fail")
+	}
+}
+
+func TestList_CSVExportsFilteredComments(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "Quote \"this\", please\nsecond line")
+	root := findCommentByBody(stateComments(t, loadState(t, dir)), "Quote \"this\", please\nsecond line")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", root, "-a", "bob", "Done")
+	mustRunGR(t, dir, "resolve", root)
+	mustRunGR(t, dir, "add", "Still open")
+
+	rows, err := csv.NewReader(strings.NewReader(mustRunGR(t, dir, "list", "--format", "csv"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0][0] != "id" || rows[0][6] != "body" {
+		t.Fatalf("expected a header and 3 rows, got %v", rows)
+	}
+	if rows[1][0] != root || rows[1][3] != "app.js" || rows[1][4] != "1" || rows[1][6] != "Quote \"this\", please\nsecond line" || rows[1][9] == "" {
+		t.Errorf("unexpected thread row: %v", rows[1])
+	}
+	if rows[2][1] != root || rows[2][7] != "bob" {
+		t.Errorf("unexpected reply row: %v", rows[2])
+	}
+
+	rows, err = csv.NewReader(strings.NewReader(mustRunGR(t, dir, "list", "--format", "csv", "--unresolved"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1][6] != "Still open" {
+		t.Errorf("--unresolved should leave only the open thread, got %v", rows)
+	}
+}
+
+func TestStart_SkipsMergesUnlessIncluded(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	gitCmd(t, dir, "checkout", "-b", "side", "feature/test~1")
+	writeFile(t, dir, "lib.js", "export const side = 1;\n")
+	gitCmd(t, dir, "add", ".")
+	gitCmd(t, dir, "commit", "-m", "Add side library")
+	gitCmd(t, dir, "checkout", "feature/test")
+	gitCmd(t, dir, "merge", "--no-ff", "-m", "Merge side", "side")
+
+	output := mustRunGR(t, dir, "main")
+	assertContains(t, "merge skipped", output, "Skipping 1 merge commit")
+	if n := len(loadState(t, dir)["commits"].([]interface{})); n != 4 {
+		t.Errorf("expected the 4 non-merge commits, got %d", n)
+	}
+	mustRunGR(t, dir, "abort")
+
+	mustRunGR(t, dir, "main", "--include-merges")
+	commits := loadState(t, dir)["commits"].([]interface{})
+	if len(commits) != 5 {
+		t.Fatalf("expected 5 commits with the merge, got %d", len(commits))
+	}
+	mustRunGR(t, dir, "jump", "5")
+	staged := gitCmd(t, dir, "diff", "--staged", "--name-only")
+	if strings.TrimSpace(staged) != "lib.js" {
+		t.Errorf("the merge should be diffed against its first parent, staged: %q", staged)
+	}
+}
+
+func TestAdd_FileCommentOnMergeCommit(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	gitCmd(t, dir, "checkout", "-b", "side", "feature/test~1")
+	writeFile(t, dir, "lib.js", "export const side = 1;\n")
+	gitCmd(t, dir, "add", ".")
+	gitCmd(t, dir, "commit", "-m", "Add side library")
+	gitCmd(t, dir, "checkout", "feature/test")
+	gitCmd(t, dir, "merge", "--no-ff", "-m", "Merge side", "side")
+
+	mustRunGR(t, dir, "main", "--include-merges")
+	mustRunGR(t, dir, "jump", "5")
+	mustRunGR(t, dir, "add", "-f", "lib.js", "-l", "1", "Export a function instead")
+
+	if _, err := runGR(t, dir, "add", "-f", "app.js", "Not in the merge"); err == nil {
+		t.Error("a file the merge does not change against its first parent should be rejected")
+	}
+	output := mustRunGR(t, dir, "list")
+	assertContains(t, "comment on merge", output, "Export a function instead")
+}
+
+func TestFinish_TemplateRendersSummary(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "Open thread")
+	mustRunGR(t, dir, "add", "Fixed thread")
+	mustRunGR(t, dir, "resolve", findCommentByBody(stateComments(t, loadState(t, dir)), "Fixed thread")["id"].(string))
+
+	tmplDir := t.TempDir()
+	writeFile(t, tmplDir, "bad.tmpl", "{{.NoSuchField}}")
+	if _, err := runGR(t, dir, "finish", "--template", filepath.Join(tmplDir, "bad.tmpl")); err == nil {
+		t.Fatal("a template using an unknown field should fail")
+	}
+	mustRunGR(t, dir, "status") // the review is still running
+
+	writeFile(t, tmplDir, "signoff.tmpl", "Sign-off for {{.Branch}}: {{.CommitCount}} commits, {{.Unresolved}} open\n"+
+		"{{range .Comments}}- {{if .Resolved}}[x]{{else}}[ ]{{end}} {{.File}}{{if .Lines}}:{{.Lines}}{{end}} {{.Body}}\n{{end}}")
+	out := filepath.Join(tmplDir, "signoff.md")
+	mustRunGR(t, dir, "finish", "--template", filepath.Join(tmplDir, "signoff.tmpl"), "--output", out)
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, "header", string(data), "Sign-off for feature/test: 3 commits, 1 open")
+	assertContains(t, "open thread", string(data), "- [ ] app.js:1 Open thread")
+	assertContains(t, "resolved thread", string(data), "- [x]  Fixed thread")
+}
+
+func TestRepoFlag_OperatesOnAnotherDirectory(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	elsewhere := t.TempDir()
+
+	mustRunGR(t, elsewhere, "-C", dir, "-a", "alice")
+	worktree := filepath.Join(dir, ".git", "review", "worktrees", "alice")
+	out := mustRunGR(t, elsewhere, "--repo", worktree, "whoami")
+	assertContains(t, "worktree reviewer", out, "alice")
+	mustRunGR(t, elsewhere, "-C", worktree, "add", "Reviewed from afar")
+
+	state := loadState(t, dir)
+	c := findCommentByBody(stateComments(t, state), "Reviewed from afar")
+	if c == nil || c["createdBy"] != "alice" {
+		t.Fatalf("comment should be made by alice in %s, got %v", dir, c)
+	}
+
+	if _, err := runGR(t, elsewhere, "-C", filepath.Join(elsewhere, "missing"), "status"); err == nil {
+		t.Error("--repo should reject a directory that does not exist")
+	}
+}
+
+func TestList_OnelineCollapsesThreads(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "Discussed thread")
+	root := findCommentByBody(stateComments(t, loadState(t, dir)), "Discussed thread")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", root, "First reply")
+	mustRunGR(t, dir, "add", "-r", root, "Second reply")
+	mustRunGR(t, dir, "resolve", root)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", strings.Repeat("long ", 30))
+
+	output := mustRunGR(t, dir, "list", "--oneline")
+	assertContains(t, "resolved thread line", output, "1/3 ")
+	assertContains(t, "location and body", output, "app.js:1: Discussed thread (+2) [resolved by")
+	assertNotContains(t, "replies are not expanded", output, "First reply")
+	assertContains(t, "long body truncated", output, "2/3 ")
+	assertContains(t, "long body truncated", output, "long…")
+
+	if _, err := runGR(t, dir, "list", "--oneline", "--format=csv"); err == nil {
+		t.Error("--oneline should be rejected with --format=csv")
+	}
+}
+
+func TestAdd_LineOnBinaryFileNeedsForce(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	writeFile(t, dir, "logo.png", "\x89PNG\x00\x00binary")
+	gitCmd(t, dir, "add", "logo.png")
+	gitCmd(t, dir, "commit", "-m", "Add logo")
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "jump", "4")
+
+	out, err := runGR(t, dir, "add", "-f", "logo.png", "-l", "5", "Pixel off")
+	if err == nil {
+		t.Fatal("a line comment on a binary file should be refused")
+	}
+	assertContains(t, "reason", out, "logo.png is a binary file")
+
+	mustRunGR(t, dir, "add", "-f", "logo.png", "Use an SVG")
+	mustRunGR(t, dir, "add", "-f", "logo.png", "-l", "5", "--force", "Pixel off")
+	comments := stateComments(t, loadState(t, dir))
+	if findCommentByBody(comments, "Use an SVG") == nil || findCommentByBody(comments, "Pixel off") == nil {
+		t.Fatal("file-level and forced comments should be saved")
+	}
+}
+
+func TestResolve_InteractiveReadsScriptedChoices(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	for _, body := range []string{"First thread", "Second thread", "Third thread"} {
+		mustRunGR(t, dir, "add", body)
+	}
+
+	// Resolve the first, skip the second, and let end of input stop at the third
+	out, err := runGRWithInput(t, dir, "r\ns\n", "resolve", "-i")
+	if err != nil {
+		t.Fatalf("resolve -i: %v\n%s", err, out)
+	}
+	assertContains(t, "tally", out, "Resolved 1 of 3 threads")
+	comments := stateComments(t, loadState(t, dir))
+	for body, resolved := range map[string]bool{"First thread": true, "Second thread": false, "Third thread": false} {
+		if got := findCommentByBody(comments, body)["resolvedAt"] != nil; got != resolved {
+			t.Errorf("%s: resolved = %v, want %v", body, got, resolved)
+		}
+	}
+
+	// A walk that fails reports the error, not a tally
+	out, err = runGRWithInputEnv(t, dir, "p\n", []string{"GIT_EDITOR=false"}, "resolve", "-i")
+	if err == nil {
+		t.Fatalf("a failing editor should fail the walk:\n%s", out)
+	}
+	assertNotContains(t, "no tally after an error", out, "Resolved 0 of")
+}
+
+func TestUndo_RevertsLastActionOfThisWorktree(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Root comment")
+	root := findCommentByBody(stateComments(t, loadState(t, dir)), "Root comment")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", root, "A reply")
+	mustRunGR(t, dir, "resolve", root)
+
+	out := mustRunGR(t, dir, "undo")
+	assertContains(t, "resolve undone", out, "Undid resolve of")
+	if c := findCommentByBody(stateComments(t, loadState(t, dir)), "Root comment"); c["resolvedAt"] != nil {
+		t.Errorf("undo should reopen the thread, got resolvedAt %v", c["resolvedAt"])
+	}
+
+	mustRunGR(t, dir, "delete", root)
+	mustRunGR(t, dir, "undo")
+	comments := stateComments(t, loadState(t, dir))
+	reply := findCommentByBody(comments, "A reply")
+	if findCommentByBody(comments, "Root comment") == nil || reply == nil || reply["parentId"] != root {
+		t.Fatalf("undo should restore the deleted thread with its reply, got %v", comments)
+	}
+
+	// Another worktree's reply is not ours to undo, and blocks undoing in its thread
+	mustRunGR(t, dir, "start", "-a", "alice")
+	worktree := filepath.Join(dir, ".git", "review", "worktrees", "alice")
+	mustRunGR(t, worktree, "add", "-r", root, "Alice chimes in")
+	if _, err := runGR(t, dir, "undo"); err == nil {
+		t.Error("undo should be refused while the thread has alice's newer reply")
+	}
+	mustRunGR(t, worktree, "undo")
+	mustRunGR(t, dir, "undo")
+	mustRunGR(t, dir, "undo")
+	if n := len(stateComments(t, loadState(t, dir))); n != 0 {
+		t.Errorf("every comment should be undone, %d left", n)
+	}
+	out = mustRunGR(t, dir, "undo")
+	assertContains(t, "empty log", out, "Nothing to undo.")
+}
+
+func TestUndo_LeavesOtherReviewersChangesAlone(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Shared thread")
+	shared := findCommentByBody(stateComments(t, loadState(t, dir)), "Shared thread")["id"].(string)
+	mustRunGR(t, dir, "resolve", shared)
+
+	mustRunGR(t, dir, "start", "-a", "alice")
+	worktree := filepath.Join(dir, ".git", "review", "worktrees", "alice")
+	mustRunGR(t, worktree, "unresolve", shared)
+
+	out, err := runGR(t, dir, "undo")
+	if err == nil {
+		t.Fatal("undo of the resolve should be refused once alice has reopened the thread")
+	}
+	assertContains(t, "reason", out, "changed since")
+	log := mustRunGR(t, dir, "log")
+	assertContains(t, "alice's reopen kept", log, "unresolved")
+
+	// Undoing an action in another thread leaves this one and its history as it is
+	mustRunGR(t, dir, "add", "Unrelated thread")
+	mustRunGR(t, dir, "undo")
+	comments := stateComments(t, loadState(t, dir))
+	if findCommentByBody(comments, "Unrelated thread") != nil {
+		t.Error("undo should delete the comment it added")
+	}
+	if c := findCommentByBody(comments, "Shared thread"); c == nil || c["resolvedAt"] != nil {
+		t.Errorf("the shared thread should stay reopened, got %v", c)
+	}
+	if after := mustRunGR(t, dir, "log"); after != log {
+		t.Errorf("the history should be unchanged, got\n%s\nwant\n%s", after, log)
+	}
+}
+
+func TestStatus_CompactForShellPrompts(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+
+	for name, where := range map[string]string{"no review": dir, "not a repository": t.TempDir()} {
+		out, err := runGR(t, where, "status", "--compact")
+		if err != nil || out != "" {
+			t.Errorf("%s: want no output and success, got %q (%v)", name, out, err)
+		}
+	}
+
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "Open thread")
+	out := mustRunGR(t, dir, "--color", "status", "--compact")
+	assertContains(t, "forced color", out, "\033[")
+	out = mustRunGR(t, dir, "status", "--compact")
+	if out != "review 2/3 (1!)\n" {
+		t.Errorf("got %q, want %q", out, "review 2/3 (1!)\n")
+	}
+
+	mustRunGR(t, dir, "resolve", findCommentByBody(stateComments(t, loadState(t, dir)), "Open thread")["id"].(string))
+	if out := mustRunGR(t, dir, "status", "--compact"); out != "review 2/3\n" {
+		t.Errorf("got %q, want %q", out, "review 2/3\n")
+	}
+}
+
+func TestReplies_ShowsOnlyTheSubDiscussion(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Root comment")
+	root := findCommentByBody(stateComments(t, loadState(t, dir)), "Root comment")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", root, "Side question")
+	side := findCommentByBody(stateComments(t, loadState(t, dir)), "Side question")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", side, "Side answer")
+	mustRunGR(t, dir, "add", "-r", root, "Main answer")
+
+	output := mustRunGR(t, dir, "replies", side)
+	assertContains(t, "reply below", output, "Side answer")
+	assertNotContains(t, "the comment itself", output, "Side question")
+	assertNotContains(t, "the root above", output, "Root comment")
+	assertNotContains(t, "a sibling branch", output, "Main answer")
+
+	leaf := findCommentByBody(stateComments(t, loadState(t, dir)), "Main answer")["id"].(string)
+	assertContains(t, "leaf", mustRunGR(t, dir, "replies", leaf), "No replies to")
+}
+
+func TestResolveUnresolveDelete_AcceptSeveralIDs(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "First thread")
+	mustRunGR(t, dir, "add", "Second thread")
+	comments := stateComments(t, loadState(t, dir))
+	first := findCommentByBody(comments, "First thread")["id"].(string)
+	second := findCommentByBody(comments, "Second thread")["id"].(string)
+
+	out, err := runGR(t, dir, "resolve", first, "ffffffff", second)
+	if err == nil {
+		t.Fatal("resolve should fail when one of the IDs matches nothing")
+	}
+	assertContains(t, "unknown ID reported", out, "ffffffff:")
+	assertContains(t, "failure count", out, "1 of 3 threads could not be resolved")
+	for _, c := range stateComments(t, loadState(t, dir)) {
+		if c["resolvedAt"] == nil {
+			t.Errorf("%v should be resolved despite the bad ID", c["body"])
+		}
+	}
+
+	mustRunGR(t, dir, "unresolve", first, second)
+	for _, c := range stateComments(t, loadState(t, dir)) {
+		if c["resolvedAt"] != nil {
+			t.Errorf("%v should be unresolved", c["body"])
+		}
+	}
+
+	out = mustRunGR(t, dir, "delete", first, second)
+	assertContains(t, "deletions reported", out, "Deleted [")
+	if n := len(stateComments(t, loadState(t, dir))); n != 0 {
+		t.Errorf("both comments should be deleted, %d left", n)
+	}
+	out = mustRunGR(t, dir, "undo")
+	assertContains(t, "one undo for the batch", out, "Undid delete of 2 comments.")
+	if n := len(stateComments(t, loadState(t, dir))); n != 2 {
+		t.Errorf("undo should restore both comments, got %d", n)
+	}
+}
+
+func TestList_ReviewedOnlyStopsAtCurrentPosition(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "On the first commit")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "On the last commit")
+	mustRunGR(t, dir, "jump", "2")
+
+	out := mustRunGR(t, dir, "list", "--reviewed-only")
+	assertContains(t, "reviewed commit", out, "On the first commit")
+	assertNotContains(t, "commit not reached", out, "On the last commit")
+}
+
+func TestNotes_ShowsFinishedReview(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Feedback on the first commit")
+	mustRunGR(t, dir, "finish")
+
+	out := mustRunGR(t, dir, "notes")
+	assertContains(t, "commit heading", out, "## Commit 1/3")
+	assertContains(t, "note body", out, "Feedback on the first commit")
+
+	if _, err := runGR(t, dir, "notes", "HEAD"); err == nil {
+		t.Error("notes on a commit without one should fail")
+	}
+	out = mustRunGR(t, dir, "notes", "--ref", "other")
+	assertContains(t, "empty ref", out, "No review notes")
+}
+
+func TestStart_BranchReviewsAnotherBranch(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	gitCmd(t, dir, "checkout", "main")
+
+	mustRunGR(t, dir, "start", "--branch", "feature/test")
+	if n := len(loadState(t, dir)["commits"].([]interface{})); n != 3 {
+		t.Fatalf("expected the 3 commits of feature/test, got %d", n)
+	}
+	out := mustRunGR(t, dir, "status")
+	assertContains(t, "reviewed branch", out, "feature/test")
+
+	mustRunGR(t, dir, "add", "Feedback on someone else's work")
+	mustRunGR(t, dir, "finish")
+	if branch := gitCmd(t, dir, "branch", "--show-current"); branch != "main" {
+		t.Errorf("finish should return to main, got %q", branch)
+	}
+	notes := gitCmd(t, dir, "log", "--notes", "--format=%N", "feature/test")
+	assertContains(t, "note on the reviewed branch", notes, "Feedback on someone else's work")
+}
+
+func TestFinish_ContextQuotesSourceInNotes(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "Name this better")
+	mustRunGR(t, dir, "finish", "--context", "2")
+
+	notes := gitCmd(t, dir, "log", "--notes", "--format=%N")
+	assertContains(t, "comment", notes, "app.js:1 -- Name this better")
+	assertContains(t, "quoted line", notes, "> 1 | function hello()")
+}
+
+func TestFinish_ContextReadsEachFileOnce(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "Name this better")
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "And document it")
+	mustRunGR(t, dir, "add", "-f", "app.js", "Consider a module")
+
+	// Count git's own runs: the file is read once however many comments quote it
+	trace := filepath.Join(t.TempDir(), "trace")
+	if output, err := runGRWithEnv(t, dir, []string{"GIT_TRACE=" + trace}, "finish", "--context", "1"); err != nil {
+		t.Fatalf("finish: %v\n%s", err, output)
+	}
+	data, err := os.ReadFile(trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reads := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.Contains(line, "trace: built-in: git cat-file -p ") && strings.Contains(line, ":app.js") {
+			reads++
+		}
+	}
+	if reads != 1 {
+		t.Errorf("finish --context read app.js %d times, want once", reads)
+	}
+}
+
+func TestAdd_FixupLinksCommitAndResolves(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	if _, err := runGR(t, dir, "add", "--fixup", "no-such-commit", "Broken"); err == nil {
+		t.Fatal("--fixup with an unknown commit should fail")
+	}
+
+	mustRunGR(t, dir, "add", "Needs a fix")
+	root := findCommentByBody(stateComments(t, loadState(t, dir)), "Needs a fix")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", root, "--fixup", "main", "Fixed separately")
+
+	comments := stateComments(t, loadState(t, dir))
+	if findCommentByBody(comments, "Needs a fix")["resolvedAt"] == nil {
+		t.Error("--fixup should resolve the thread")
+	}
+	mainSHA := gitCmd(t, dir, "rev-parse", "main")
+	if got := findCommentByBody(comments, "Fixed separately")["fixup"]; got != mainSHA {
+		t.Errorf("fixup = %v, want %s", got, mainSHA)
+	}
+	out := mustRunGR(t, dir, "list")
+	assertContains(t, "fixup rendered", out, "Fixed separately → fixed in "+mainSHA[:7])
+}
+
+func TestStart_SinceTagUsesLastTagAsBase(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	gitCmd(t, dir, "tag", "v1.0.0", "HEAD~1")
+
+	out := mustRunGR(t, dir, "start", "--since-tag")
+	assertContains(t, "tag as base", out, "Base: v1.0.0")
+	if n := len(loadState(t, dir)["commits"].([]interface{})); n != 1 {
+		t.Errorf("expected the 1 commit after the tag, got %d", n)
+	}
+}
+
+func TestStart_SinceTagSkipsTagOnHead(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	gitCmd(t, dir, "tag", "v1.0.0", "HEAD~2")
+	gitCmd(t, dir, "tag", "v1.1.0", "HEAD")
+
+	out := mustRunGR(t, dir, "start", "--since-tag")
+	assertContains(t, "previous tag as base", out, "Base: v1.0.0")
+	if n := len(loadState(t, dir)["commits"].([]interface{})); n != 2 {
+		t.Errorf("expected the 2 commits up to the tagged HEAD, got %d", n)
+	}
+}
+
+func TestImport_ProtectsLocalChanges(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Exported comment")
+	path := filepath.Join(t.TempDir(), "review.json")
+	if err := os.WriteFile(path, []byte(mustRunGR(t, dir, "state")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustRunGR(t, dir, "abort", "--force")
+	writeFile(t, dir, "app.js", "// work in progress\n")
+
+	output, err := runGR(t, dir, "import", path)
+	if err == nil {
+		t.Fatalf("expected import to refuse a dirty working tree\n%s", output)
+	}
+	assertContains(t, "suggests autostash", output, "--autostash")
+	if loadState(t, dir) != nil {
+		t.Error("a refused import should not leave a review behind")
+	}
+
+	output = mustRunGR(t, dir, "import", "--autostash", path)
+	assertContains(t, "reports the stash", output, "Stashed local changes")
+	mustRunGR(t, dir, "import", "--force", path)
+
+	output = mustRunGR(t, dir, "abort", "--force")
+	assertContains(t, "restored after a replacing import", output, "Restored the local changes")
+	data, err := os.ReadFile(filepath.Join(dir, "app.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "// work in progress\n" {
+		t.Errorf("expected local change restored, got %q", data)
+	}
+}
+
+func TestImport_RestoresStashWhenImportFails(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Orphaned reply")
+	state := loadState(t, dir)
+	stateComments(t, state)[0]["parentId"] = "00000000-0000-7000-8000-000000000001"
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "review.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustRunGR(t, dir, "abort", "--force")
+	writeFile(t, dir, "app.js", "// work in progress\n")
+
+	if output, err := runGR(t, dir, "import", "--autostash", path); err == nil {
+		t.Fatalf("expected import of a reply without its parent to fail\n%s", output)
+	}
+	if got := gitCmd(t, dir, "stash", "list"); strings.TrimSpace(got) != "" {
+		t.Errorf("the failed import should leave no stash behind, got %q", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "app.js")); string(data) != "// work in progress\n" {
+		t.Errorf("expected local change restored, got %q", data)
+	}
+}
+
+func TestOpen_MigratesBaselineDatabase(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "Made by the first release")
+	first := findCommentByBody(stateComments(t, loadState(t, dir)), "Made by the first release")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", first, "A reply from then")
+
+	// Store the same review the way the first release did: the baseline schema, no version
+	reviewDir := filepath.Join(dir, ".git", "review")
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Rename(filepath.Join(reviewDir, "review.db"+suffix), filepath.Join(reviewDir, "old.db"+suffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.Fatal(err)
+		}
+	}
+	schema, err := os.ReadFile(filepath.Join("testdata", "baseline_schema.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sql.Open("sqlite", filepath.Join(reviewDir, "review.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		string(schema),
+		"ATTACH DATABASE '" + filepath.Join(reviewDir, "old.db") + "' AS old",
+		"INSERT INTO session SELECT base_ref, branch, created_at FROM old.session",
+		"INSERT INTO commits SELECT sha, message, position FROM old.commits",
+		"INSERT INTO reviewers SELECT name, current_sha FROM old.reviewers",
+		`INSERT INTO comments SELECT id, parent_id, "commit", file, start_line, end_line, body, resolved_at, resolved_by, created_at, created_by FROM old.comments`,
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("%v\n%s", err, stmt)
+		}
+	}
+	conn.Close()
+
+	output := mustRunGR(t, dir, "list")
+	assertContains(t, "root kept", output, "L1: Made by the first release")
+	assertContains(t, "reply kept", output, "A reply from then")
+	assertContains(t, "status", mustRunGR(t, dir, "status"), "Add hello function (2 comments)")
+	mustRunGR(t, dir, "add", "-r", first, "--resolve", "Reply after the upgrade")
+	mustRunGR(t, dir, "add", "--summary", "Summary after the upgrade")
+	mustRunGR(t, dir, "abort", "--force")
+	if branch := gitCmd(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "feature/test" {
+		t.Errorf("abort should return to the branch, on %s", branch)
+	}
+}
+
+func TestAuthorEnv_AttributesAddAndResolve(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	env := []string{"GIT_REVIEW_AUTHOR=ci-bot"}
+
+	if _, err := runGRWithEnv(t, dir, env, "add", "From CI"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runGRWithEnv(t, dir, env, "add", "-a", "alice", "From alice"); err != nil {
+		t.Fatal(err)
+	}
+	comments := stateComments(t, loadState(t, dir))
+	fromCI := findCommentByBody(comments, "From CI")
+	if fromCI["createdBy"] != "ci-bot" {
+		t.Errorf("expected the env author, got %v", fromCI["createdBy"])
+	}
+	if by := findCommentByBody(comments, "From alice")["createdBy"]; by != "alice" {
+		t.Errorf("-a should override the env author, got %v", by)
+	}
+
+	if _, err := runGRWithEnv(t, dir, env, "resolve", fromCI["id"].(string)); err != nil {
+		t.Fatal(err)
+	}
+	if by := findCommentByBody(stateComments(t, loadState(t, dir)), "From CI")["resolvedBy"]; by != "ci-bot" {
+		t.Errorf("expected the thread resolved by the env author, got %v", by)
+	}
+
+	// Reopening is attributed the same way, while undo stays with the worktree
+	if _, err := runGRWithEnv(t, dir, env, "unresolve", fromCI["id"].(string)); err != nil {
+		t.Fatal(err)
+	}
+	log := mustRunGR(t, dir, "log", "--creator", "ci-bot")
+	assertContains(t, "reopen by the env author", log, "unresolved")
+	assertContains(t, "undo from the worktree", mustRunGR(t, dir, "undo"), "Undid unresolve of")
+
+	output, _ := runGRWithEnv(t, dir, env, "whoami")
+	assertContains(t, "whoami", output, "author     ci-bot (from GIT_REVIEW_AUTHOR)")
+}
+
+func TestFinish_WarnsWhenBranchChangedSinceStart(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Noted")
+	dropped := gitCmd(t, dir, "rev-parse", "--short=7", "feature/test")
+	late := gitCmd(t, dir, "commit-tree", "feature/test~1^{tree}", "-p", "feature/test~1", "-m", "Late fix")
+	gitCmd(t, dir, "update-ref", "refs/heads/feature/test", late)
+
+	output, err := runGR(t, dir, "finish", "--strict")
+	if err == nil {
+		t.Fatalf("--strict should refuse a branch that changed:\n%s", output)
+	}
+	assertContains(t, "added commit", output, "+ "+late[:7]+" Late fix")
+	assertContains(t, "dropped commit", output, "- "+dropped+" Add main entry")
+	if loadState(t, dir) == nil {
+		t.Fatal("a refused finish should leave the review running")
+	}
+
+	output = mustRunGR(t, dir, "finish")
+	assertContains(t, "warning", output, "Warning: feature/test changed since the review started")
+
+	// The later commits --single leaves out were already there, so they are not new
+	mustRunGR(t, dir, "start", "--single", "feature/test~2")
+	output = mustRunGR(t, dir, "finish", "--strict")
+	assertNotContains(t, "--single", output, "changed since the review started")
+
+	// With --branch it is the reviewed branch that is compared, not the one checked out
+	gitCmd(t, dir, "checkout", "main")
+	mustRunGR(t, dir, "start", "--branch", "feature/test")
+	output = mustRunGR(t, dir, "finish", "--strict")
+	assertNotContains(t, "--branch unchanged", output, "changed since the review started")
+	mustRunGR(t, dir, "start", "--branch", "feature/test")
+	later := gitCmd(t, dir, "commit-tree", "feature/test^{tree}", "-p", "feature/test", "-m", "Later fix")
+	gitCmd(t, dir, "update-ref", "refs/heads/feature/test", later)
+	output, err = runGR(t, dir, "finish", "--strict")
+	if err == nil {
+		t.Fatalf("--strict should refuse when the reviewed branch gained a commit:\n%s", output)
+	}
+	assertContains(t, "added commit with --branch", output, "+ "+later[:7]+" Later fix")
+}
+
+func TestJump_FileGoesToFirstCommitChangingIt(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	writeFile(t, dir, "docs.md", "# Docs\n")
+	gitCmd(t, dir, "add", "docs.md")
+	gitCmd(t, dir, "commit", "-m", "Add docs")
+	mustRunGR(t, dir)
+
+	output := mustRunGR(t, dir, "jump", "--file", "docs.md")
+	assertContains(t, "commit adding docs", output, "[4/4]")
+	output = mustRunGR(t, dir, "jump", "-f", "app.js")
+	assertContains(t, "first of several", output, "[1/4]")
+
+	output, err := runGR(t, dir, "jump", "--file", "README.md")
+	if err == nil {
+		t.Fatalf("a file no reviewed commit changes should be rejected:\n%s", output)
+	}
+	assertContains(t, "reason", output, "README.md is not changed in any reviewed commit")
+
+	// Reviewing another branch from main, the branch's own history is searched
+	mustRunGR(t, dir, "abort")
+	gitCmd(t, dir, "checkout", "main")
+	mustRunGR(t, dir, "start", "--branch", "feature/test")
+	output = mustRunGR(t, dir, "jump", "--file", "docs.md")
+	assertContains(t, "commit adding docs with --branch", output, "[4/4]")
+}
+
+func TestPorcelain_PrintsStableLines(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	first := gitCmd(t, dir, "rev-parse", "feature/test~2")
+
+	out := mustRunGR(t, dir, "--porcelain", "add", "Needs a test")
+	fields := strings.Split(strings.TrimSpace(out), "\t")
+	if len(fields) != 3 || fields[0] != "added" || len(fields[1]) != 36 || fields[2] != first {
+		t.Fatalf("expected added<TAB>id<TAB>commit, got %q", out)
+	}
+	id := fields[1]
+
+	out = mustRunGR(t, dir, "--porcelain", "add", "-r", id, "--resolve", "Added one")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || lines[1] != "resolved\t"+id {
+		t.Errorf("expected the reply and the resolved thread, got %q", out)
+	}
+	mustRunGR(t, dir, "unresolve", id)
+	if out := mustRunGR(t, dir, "resolve", "--porcelain", id); strings.TrimSpace(out) != "resolved\t"+id {
+		t.Errorf("unexpected resolve output %q", out)
+	}
+	if out := mustRunGR(t, dir, "delete", "--porcelain", id); strings.TrimSpace(out) != "deleted\t"+id {
+		t.Errorf("unexpected delete output %q", out)
+	}
+}
+
+func TestAdd_OnBaseListsUnderBaseAndNotesFirstCommit(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "--on-base", "-f", "README.md", "-l", "1", "Heading predates the branch")
+	if _, err := runGR(t, dir, "add", "--on-base", "-f", "app.js", "Not there yet"); err == nil {
+		t.Error("a file missing at the base should be rejected")
+	}
+
+	out := mustRunGR(t, dir, "list")
+	base := strings.Index(out, "## Base "+gitCmd(t, dir, "rev-parse", "--short=7", "main"))
+	if base < 0 || base > strings.Index(out, "## Commit 1/3") {
+		t.Fatalf("expected a Base section before the commits, got:\n%s", out)
+	}
+	assertContains(t, "base comment", out[base:strings.Index(out, "## Commit 1/3")], "L1: Heading predates the branch")
+	assertContains(t, "tagged elsewhere", mustRunGR(t, dir, "list", "--flat"), "(base)")
+
+	mustRunGR(t, dir, "finish")
+	notes := gitCmd(t, dir, "notes", "show", "feature/test~2")
+	assertContains(t, "note on first commit", notes, "(base) README.md:1 -- Heading predates the branch")
+}
+
+func TestLog_ShowsActivityInOrder(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "First thought")
+	root := findCommentByBody(stateComments(t, loadState(t, dir)), "First thought")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", root, "-a", "bob", "Answer from bob")
+	mustRunGR(t, dir, "resolve", root)
+
+	out := mustRunGR(t, dir, "log")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 events, got:\n%s", out)
+	}
+	assertContains(t, "comment", lines[0], "commented  ["+root[:8]+"] 1/3")
+	assertContains(t, "reply", lines[1], "replied    ")
+	assertContains(t, "reply author", lines[1], "Answer from bob @bob")
+	assertContains(t, "resolution", lines[2], "resolved   ")
+
+	out = mustRunGR(t, dir, "log", "--creator", "bob")
+	assertContains(t, "bob's reply", out, "Answer from bob")
+	assertNotContains(t, "others' activity", out, "First thought")
+
+	out = mustRunGR(t, dir, "log", "--until", "2000-01-01")
+	assertNotContains(t, "date filter", out, "First thought")
+	if _, err := runGR(t, dir, "log", "--since", "last week"); err == nil {
+		t.Error("an unparsable date should be rejected")
+	}
+}
+
+func TestNext_AutoFinishAfterLastCommit(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Noted")
+	mustRunGR(t, dir, "next", "--auto-finish")
+	mustRunGR(t, dir, "next", "--auto-finish")
+	if loadState(t, dir) == nil {
+		t.Fatal("the review should still run while commits are left")
+	}
+
+	out := mustRunGR(t, dir, "next", "--auto-finish")
+	assertContains(t, "finish output", out, "Review Complete")
+	if loadState(t, dir) != nil {
+		t.Error("the review should be finished")
+	}
+	notes := gitCmd(t, dir, "log", "--notes", "--format=%N")
+	assertContains(t, "notes", notes, "Noted")
+}
+
+func TestAdd_AssignDirectsCommentAtSomeone(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Unassigned thread")
+	mustRunGR(t, dir, "add", "Root")
+	root := findCommentByBody(stateComments(t, loadState(t, dir)), "Root")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", root, "--assign", "@bob", "bob, please look")
+
+	c := findCommentByBody(stateComments(t, loadState(t, dir)), "bob, please look")
+	if c["assignee"] != "bob" {
+		t.Errorf("assignee = %v, want bob", c["assignee"])
+	}
+
+	out := mustRunGR(t, dir, "list", "--assigned-to", "bob")
+	assertContains(t, "assigned thread", out, "Root")
+	assertContains(t, "assignee tag", out, "bob, please look →@bob")
+	assertNotContains(t, "other thread", out, "Unassigned thread")
+}
+
+func TestFinish_BlockOnRefusesMatchingOpenThreads(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Looks fine")
+	mustRunGR(t, dir, "add", "Needs work")
+	root := findCommentByBody(stateComments(t, loadState(t, dir)), "Needs work")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", root, "BLOCKER: this leaks")
+
+	out, err := runGR(t, dir, "finish", "--block-on", "BLOCKER")
+	if err == nil {
+		t.Fatalf("finish should refuse while a blocker is open:\n%s", out)
+	}
+	assertContains(t, "blocked thread", out, "["+root[:8]+"]")
+	assertContains(t, "matching comment", out, "BLOCKER: this leaks")
+	if loadState(t, dir) == nil {
+		t.Fatal("a refused finish should leave the review running")
+	}
+
+	mustRunGR(t, dir, "resolve", root)
+	mustRunGR(t, dir, "finish", "--block-on", "BLOCKER")
+}
+
+func TestStart_RecordsCommitAuthors(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	gitCmd(t, dir, "commit", "--allow-empty", "--author", "Alice <alice@example.com>", "-m", "Alice's change")
+	mustRunGR(t, dir)
+
+	state := loadState(t, dir)
+	authors := state["commitAuthors"].(map[string]interface{})
+	commits := state["commits"].([]interface{})
+	if got := authors[commits[0].(string)]; got != "Test <test@test.com>" {
+		t.Errorf("first commit author = %v", got)
+	}
+	if got := authors[commits[3].(string)]; got != "Alice <alice@example.com>" {
+		t.Errorf("last commit author = %v", got)
+	}
+
+	out := mustRunGR(t, dir, "status")
+	assertContains(t, "status", out, "Alice's change by Alice")
+}
+
+func TestDelete_DryRunShowsEffects(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Root")
+	root := findCommentByBody(stateComments(t, loadState(t, dir)), "Root")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", root, "Reply")
+	reply := findCommentByBody(stateComments(t, loadState(t, dir)), "Reply")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", reply, "Nested")
+
+	out := mustRunGR(t, dir, "delete", "--dry-run", root)
+	assertContains(t, "cascade", out, "and its 2 replies")
+	out = mustRunGR(t, dir, "delete", "--dry-run", reply)
+	assertContains(t, "re-parent", out, "would move under ["+root[:8])
+	out = mustRunGR(t, dir, "delete", "--dry-run", "--promote", root)
+	assertContains(t, "promote", out, "would become the root")
+
+	if n := len(stateComments(t, loadState(t, dir))); n != 3 {
+		t.Errorf("dry run should delete nothing, %d comments left", n)
+	}
+}
+
+func TestStart_AnnotateOnlyLeavesWorktreeAlone(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	head := gitCmd(t, dir, "rev-parse", "HEAD")
+	writeFile(t, dir, "README.md", "local edit\n")
+
+	mustRunGR(t, dir, "start", "--annotate-only")
+	out := mustRunGR(t, dir, "next")
+	assertContains(t, "diffstat of the commit", out, "app.js")
+	out = mustRunGR(t, dir, "add", "-f", "app.js", "-l", "2", "On the second commit")
+	assertNotContains(t, "no drift warning", out, "checked out elsewhere")
+
+	if got := gitCmd(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Errorf("HEAD moved from %s to %s", head, got)
+	}
+	if st := gitCmd(t, dir, "status", "--porcelain"); st != "M README.md" {
+		t.Errorf("worktree should only hold the local edit, got %q", st)
+	}
+	c := findCommentByBody(stateComments(t, loadState(t, dir)), "On the second commit")
+	if msg := gitCmd(t, dir, "log", "-1", "--format=%s", c["commit"].(string)); msg != "Add goodbye function" {
+		t.Errorf("comment should be on the position's commit, got %q", msg)
+	}
+
+	mustRunGR(t, dir, "finish")
+	if branch := gitCmd(t, dir, "branch", "--show-current"); branch != "feature/test" {
+		t.Errorf("expected to stay on feature/test, got %q", branch)
+	}
+	if st := gitCmd(t, dir, "status", "--porcelain"); st != "M README.md" {
+		t.Errorf("finish should keep the local edit, got %q", st)
+	}
+}
+
+func TestJSON_ReportsErrorsAsJSON(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+
+	out, err := runGR(t, dir, "--json", "add", "--fixup", "no-such-ref", "Fixed")
+	if err == nil {
+		t.Fatalf("add with a bad --fixup should fail:\n%s", out)
+	}
+	var report struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &report); err != nil {
+		t.Fatalf("expected a JSON error report: %v\n%s", err, out)
+	}
+	if report.Error.Code != "InvalidRef" || report.Error.Message == "" {
+		t.Errorf("unexpected error report: %+v", report.Error)
+	}
+
+	out, _ = runGR(t, dir, "add", "--fixup", "no-such-ref", "Fixed")
+	assertContains(t, "plain error", out, "Error: ")
+	assertNotContains(t, "plain error", out, `"error"`)
+
+	// The global flag also selects the JSON output commands already offer
+	out = mustRunGR(t, dir, "--json", "status")
+	assertContains(t, "status", out, `"branch"`)
+}
+
+func TestThreads_ListsRootsByRecentActivity(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Older thread that gets a reply")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "2", "Newer thread\nwith a second line")
+	state := loadState(t, dir)
+	older := findCommentByBody(stateComments(t, state), "Older thread that gets a reply")["id"].(string)
+	newer := findCommentByBody(stateComments(t, state), "Newer thread\nwith a second line")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", older, "Reply")
+
+	commits := state["commits"].([]interface{})
+	output := mustRunGR(t, dir, "threads")
+	assertContains(t, "reply count", output, "["+older[:8]+"] open     1/3 "+commits[0].(string)[:7]+" Older thread that gets a reply (1 reply)")
+	assertContains(t, "location and first line", output, "["+newer[:8]+"] open     2/3 "+commits[1].(string)[:7]+" app.js:2: Newer thread … (0 replies)")
+	assertNotContains(t, "no reply bodies", output, "Reply (")
+	if strings.Index(output, "Older thread") > strings.Index(output, "Newer thread") {
+		t.Errorf("the thread with the latest reply should come first:\n%s", output)
+	}
+
+	mustRunGR(t, dir, "resolve", older)
+	output = mustRunGR(t, dir, "threads", "--unresolved")
+	assertNotContains(t, "resolved thread hidden", output, "Older thread")
+	assertContains(t, "open thread kept", output, "Newer thread")
+}
+
+func TestPatch_AnnotatesDiffWithComments(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Overall fine")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "-a", "alice", "-f", "app.js", "-l", "2", "Rename to farewell")
+
+	output := mustRunGR(t, dir, "patch")
+	assertContains(t, "general comment in commit header", output, "# Commit 1/3")
+	assertContains(t, "general comment body", output, "] Overall fine")
+	assertContains(t, "comment below its line", output,
+		"+function goodbye() { return \"bye\"; }\n# review: [")
+	assertContains(t, "comment author and body", output, "] @alice Rename to farewell")
+	assertNotContains(t, "commits without comments skipped", output, "# Commit 3/3")
+}
+
+func TestMove_RetargetsThread(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "Meant for the second commit")
+
+	state := loadState(t, dir)
+	root := findCommentByBody(stateComments(t, state), "Meant for the second commit")["id"].(string)
+	mustRunGR(t, dir, "add", "--reply-to", root, "Agreed")
+	second := state["commits"].([]interface{})[1].(string)
+
+	output := mustRunGR(t, dir, "move", root, second[:7])
+	assertContains(t, "reports move", output, "Add goodbye function (with 1 reply)")
+
+	comments := stateComments(t, loadState(t, dir))
+	for _, body := range []string{"Meant for the second commit", "Agreed"} {
+		if got := findCommentByBody(comments, body)["commit"]; got != second {
+			t.Errorf("%q commit = %v, want %s", body, got, second)
+		}
+	}
+	assertNotContains(t, "moved comment is not pre-amend", mustRunGR(t, dir, "list"), "[pre-amend]")
+}
+
+func TestMove_RejectsSummariesAndBaseComments(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "--summary", "Looks good overall")
+	mustRunGR(t, dir, "add", "--on-base", "Already odd before the branch")
+
+	state := loadState(t, dir)
+	second := state["commits"].([]interface{})[1].(string)
+	comments := stateComments(t, state)
+
+	summary := findCommentByBody(comments, "Looks good overall")["id"].(string)
+	output, err := runGR(t, dir, "move", summary, second[:7])
+	if err == nil {
+		t.Error("moving a review summary onto a commit should fail")
+	}
+	assertContains(t, "explains summary", output, "is a review summary")
+
+	base := findCommentByBody(comments, "Already odd before the branch")["id"].(string)
+	output, err = runGR(t, dir, "move", base, second[:7])
+	if err == nil {
+		t.Error("moving a base comment off the first commit should fail")
+	}
+	assertContains(t, "explains base comment", output, "base comments stay on the first commit")
+
+	comments = stateComments(t, loadState(t, dir))
+	if got := findCommentByBody(comments, "Looks good overall")["commit"]; got != nil {
+		t.Errorf("summary should stay off the commits, got %v", got)
+	}
+	if got := findCommentByBody(comments, "Already odd before the branch")["commit"]; got == second {
+		t.Error("base comment should stay on the first commit")
+	}
+}
+
+func TestAbort_FallsBackToOriginalCommitWhenBranchDeleted(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	tip := strings.TrimSpace(gitCmd(t, dir, "rev-parse", "HEAD"))
+	mustRunGR(t, dir)
+
+	gitCmd(t, dir, "branch", "-D", "feature/test")
+
+	output := mustRunGR(t, dir, "abort")
+	assertContains(t, "reports missing branch", output, "feature/test no longer exists")
+
+	if head := strings.TrimSpace(gitCmd(t, dir, "rev-parse", "HEAD")); head != tip {
+		t.Errorf("HEAD = %s, want original tip %s", head, tip)
+	}
+	if status := gitCmd(t, dir, "status", "--porcelain"); strings.TrimSpace(status) != "" {
+		t.Errorf("working tree should be clean at the original commit, got:\n%s", status)
+	}
+}
+
+func TestList_SARIF(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "Open finding")
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "Fixed finding")
+	mustRunGR(t, dir, "add", "General remark")
+
+	state := loadState(t, dir)
+	mustRunGR(t, dir, "resolve", findCommentByBody(stateComments(t, state), "Fixed finding")["id"].(string))
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Invocations []struct {
+				ToolExecutionNotifications []struct {
+					Message struct{ Text string } `json:"message"`
+				} `json:"toolExecutionNotifications"`
+			} `json:"invocations"`
+			Results []struct {
+				RuleID    string                `json:"ruleId"`
+				Message   struct{ Text string } `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string } `json:"artifactLocation"`
+						Region           struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(mustRunGR(t, dir, "list", "--format=sarif")), &log); err != nil {
+		t.Fatalf("invalid SARIF JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF envelope: %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Results) != 1 {
+		t.Fatalf("expected only the unresolved file comment, got %d results", len(run.Results))
+	}
+	r := run.Results[0]
+	if r.Message.Text != "Open finding" || r.RuleID == "" {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	if loc := r.Locations[0].PhysicalLocation; loc.ArtifactLocation.URI != "app.js" || loc.Region.StartLine != 1 {
+		t.Errorf("unexpected location: %+v", loc)
+	}
+	if n := run.Invocations[0].ToolExecutionNotifications; len(n) != 1 || n[0].Message.Text != "General remark" {
+		t.Errorf("general comment should be a notification, got %+v", n)
+	}
+
+	assertContains(t, "include resolved", mustRunGR(t, dir, "list", "--format=sarif", "--include-resolved"), `"kind": "external"`)
+
+	// With --by, replies from others stay out of the author's findings
+	open := findCommentByBody(stateComments(t, state), "Open finding")["id"].(string)
+	mustRunGR(t, dir, "add", "-a", "alice", "-r", open, "Alice's take")
+	alice := findCommentByBody(stateComments(t, loadState(t, dir)), "Alice's take")["id"].(string)
+	mustRunGR(t, dir, "add", "-a", "bob", "-r", alice, "Bob's answer")
+	out := mustRunGR(t, dir, "list", "--format=sarif", "--by", "alice")
+	assertContains(t, "alice's reply", out, "Alice's take")
+	assertNotContains(t, "bob's reply", out, "Bob's answer")
+}
+
+func TestFinish_WritesGitNotes(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "Good function naming")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "finish")
+
+	// Check git notes on the first commit (Add hello function)
+	notes := gitCmd(t, dir, "log", "--notes", "--format=%N", "main..feature/test")
+	assertContains(t, "notes contain comment", notes, "Good function naming")
+}
+
+func TestFinish_ReplaceOverwritesEarlierNotes(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	first := gitCmd(t, dir, "rev-parse", "feature/test~2")
+	gitCmd(t, dir, "notes", "add", "-m", "Note from an earlier review", first)
+
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Fresh comment")
+	mustRunGR(t, dir, "finish", "--replace")
+
+	notes := gitCmd(t, dir, "notes", "show", first)
+	assertContains(t, "new note", notes, "Fresh comment")
+	assertNotContains(t, "earlier note replaced", notes, "Note from an earlier review")
+}
+
+func TestFinish_SquashNoteConsolidatesComments(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "On the first commit")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "2", "On the second commit")
+
+	output := mustRunGR(t, dir, "finish", "--squash-note", "HEAD")
+	assertContains(t, "reports squash note", output, "one note on")
+
+	notes := gitCmd(t, dir, "notes", "show", "feature/test")
+	assertContains(t, "tip note has first commit comment", notes, "On the first commit")
+	assertContains(t, "tip note has second commit comment", notes, "app.js:2 -- On the second commit")
+	assertContains(t, "tip note names source commit", notes, "Add hello function")
+}
+
+func TestFinish_SummaryNoteOnBranchTip(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-a", "alice", "Rename this")
+	mustRunGR(t, dir, "add", "-a", "bob", "Missing test")
+	mustRunGR(t, dir, "add", "-a", "bob", "Typo")
+
+	state := loadState(t, dir)
+	typo := findCommentByBody(stateComments(t, state), "Typo")["id"].(string)
+	mustRunGR(t, dir, "resolve", typo)
+
+	output := mustRunGR(t, dir, "finish", "--summary-note")
+	assertContains(t, "reports summary note", output, "Review summary written")
+
+	notes := gitCmd(t, dir, "notes", "show", "feature/test")
+	assertContains(t, "counts", notes, "Threads: 3 (2 open, 1 resolved)")
+	assertContains(t, "alice", notes, "alice: 1 comment, 1 open, 0 resolved")
+	assertContains(t, "bob", notes, "bob: 2 comments, 1 open, 1 resolved")
+	assertContains(t, "links per-commit notes", notes, "Add hello function")
+}
+
+func TestAdd_SummaryIsReviewWide(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "On the first commit")
+	mustRunGR(t, dir, "add", "--summary", "LGTM overall, ship it")
+
+	if _, err := runGR(t, dir, "add", "--summary", "-f", "app.js", "Nope"); err == nil {
+		t.Error("expected --summary with --file to fail")
+	}
+
+	output := mustRunGR(t, dir, "list")
+	assertContains(t, "summary section", output, "## Review Summary")
+	if strings.Index(output, "LGTM overall") > strings.Index(output, "## Commit 1/3") {
+		t.Errorf("summary should be listed before the commits:\n%s", output)
+	}
+
+	summary := findCommentByBody(stateComments(t, loadState(t, dir)), "LGTM overall, ship it")
+	if summary == nil || summary["commit"] != nil {
+		t.Errorf("summary in state should have a null commit, got %v", summary)
+	}
+
+	mustRunGR(t, dir, "finish", "--summary-note")
+	notes := gitCmd(t, dir, "notes", "show", "feature/test")
+	assertContains(t, "summary note has verdict", notes, "LGTM overall, ship it")
+	if strings.Index(notes, "LGTM overall") > strings.Index(notes, "Threads:") {
+		t.Errorf("verdict should be the preamble of the summary note:\n%s", notes)
+	}
+	first := gitCmd(t, dir, "notes", "show", "feature/test~2")
+	assertNotContains(t, "verdict not on a commit", first, "LGTM overall")
+}
+
+func TestFinish_SummaryWithoutSummaryNoteGoesOnBranchTip(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "--summary", "Needs another pass")
+
+	output := mustRunGR(t, dir, "finish")
+	assertContains(t, "reports note", output, "Review summary written")
+	assertContains(t, "tip note", gitCmd(t, dir, "notes", "show", "feature/test"), "Needs another pass")
+}
+
+func TestFinish_AutoResolveBySeverity(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "--severity", "nit", "Trailing whitespace")
+	mustRunGR(t, dir, "add", "--severity", "blocker", "Breaks the build")
+
+	assertContains(t, "list shows severity", mustRunGR(t, dir, "list"), "Trailing whitespace [nit]")
+
+	// --strict refuses before resolving anything, since the blocker would stay open
+	if _, err := runGR(t, dir, "finish", "--auto-resolve", "nit", "--strict"); err == nil {
+		t.Fatal("expected --strict to block finish while the blocker is open")
+	}
+	comments := stateComments(t, loadState(t, dir))
+	if findCommentByBody(comments, "Trailing whitespace")["resolvedAt"] != nil {
+		t.Error("a refused finish should not auto-resolve the nit")
+	}
+
+	// Without --strict the nit is resolved by the policy and the blocker stays open
+	out := mustRunGR(t, dir, "finish", "--auto-resolve", "nit", "--summary-note")
+	assertContains(t, "reports auto-resolution", out, "Auto-resolved 1 thread at or below nit")
+	notes := gitCmd(t, dir, "notes", "show", "feature/test")
+	assertContains(t, "nit resolved, blocker open", notes, "Threads: 2 (1 open, 1 resolved)")
+}
+
+func TestAdd_RejectsUnknownSeverity(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+
+	if _, err := runGR(t, dir, "add", "--severity", "critical", "Hmm"); err == nil {
+		t.Fatal("expected error for unknown severity")
+	}
+}
+
+func TestAdd_AlsoCommitReferencesBothEndpoints(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	first := gitCmd(t, dir, "rev-parse", "--short=7", "feature/test~2")
+	second := gitCmd(t, dir, "rev-parse", "--short=7", "feature/test~1")
+	mustRunGR(t, dir, "next")
+
+	if _, err := runGR(t, dir, "add", "--also-commit", second, "Same commit"); err == nil {
+		t.Error("expected --also-commit on the current commit to fail")
+	}
+
+	output := mustRunGR(t, dir, "add", "-f", "app.js", "-l", "2", "--also-commit", first, "Changed from the first version")
+	span := "(" + second + "↔" + first + ")"
+	assertContains(t, "add output", output, span)
+	assertContains(t, "list shows both commits", mustRunGR(t, dir, "list"), span+" L2: Changed")
+
+	c := findCommentByBody(stateComments(t, loadState(t, dir)), "Changed from the first version")
+	if c["alsoCommit"] == nil || !strings.HasPrefix(c["alsoCommit"].(string), first) {
+		t.Errorf("alsoCommit = %v, want %s", c["alsoCommit"], first)
+	}
+
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "finish")
+	notes := gitCmd(t, dir, "notes", "show", second)
+	assertContains(t, "note shows both commits", notes, span+" app.js:2 -- Changed from the first version")
+}
+
+func TestAdd_ReadsMessageFromFileOrStdin(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	path := filepath.Join(t.TempDir(), "comment.md")
+	if err := os.WriteFile(path, []byte("From a file\n\nSecond paragraph\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mustRunGR(t, dir, "add", "@"+path)
+	if _, err := runGRWithInput(t, dir, "From stdin\n", "add", "@-"); err != nil {
+		t.Fatal(err)
+	}
+	mustRunGR(t, dir, "add", `\@alice please check`)
+	if _, err := runGR(t, dir, "add", "-F", path, "Both"); err == nil {
+		t.Error("expected a message argument with --message-file to fail")
+	}
+
+	comments := stateComments(t, loadState(t, dir))
+	for _, body := range []string{"From a file\n\nSecond paragraph", "From stdin", "@alice please check"} {
+		if findCommentByBody(comments, body) == nil {
+			t.Errorf("expected a comment with body %q", body)
+		}
+	}
+}
+
+func TestWhoami_ReportsWorktreeReviewer(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+
+	output := mustRunGR(t, dir, "whoami")
+	assertContains(t, "main worktree", output, "reviewer   (default)")
+
+	mustRunGR(t, dir, "start", "-a", "security")
+	wt := filepath.Join(dir, ".git", "review", "worktrees", "security")
+	output = mustRunGR(t, wt, "whoami")
+	assertContains(t, "worktree reviewer", output, "reviewer   security")
+	assertContains(t, "worktree path", output, filepath.Join("worktrees", "security"))
+	assertContains(t, "common dir", output, "commonDir  ")
+}
+
+func TestAdd_WarnsWhenWorktreeDrifted(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir, "start", "-a", "security")
+	wt := filepath.Join(dir, ".git", "review", "worktrees", "security")
+
+	output := mustRunGR(t, wt, "add", "On the reviewed commit")
+	assertNotContains(t, "no warning in place", output, "checked out elsewhere")
+
+	gitCmd(t, wt, "checkout", "--force", "--detach", "feature/test")
+	output = mustRunGR(t, wt, "add", "After a manual checkout")
+	assertContains(t, "warns about drift", output, "checked out elsewhere")
+	assertContains(t, "names the recorded commit", output, "git review jump 1")
+}
+
+func TestAdd_RepeatedFileCoversSeveralFiles(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	second := gitCmd(t, dir, "rev-parse", "feature/test~1")
+
+	if _, err := runGR(t, dir, "add", "-f", "app.js", "-f", "lib.js", "--force", "-l", "1", "Rename"); err == nil {
+		t.Error("expected --line with several files to fail")
+	}
+	output := mustRunGR(t, dir, "add", "-f", "app.js", "-f", "lib.js", "--force", "Rename this in both")
+	assertContains(t, "add output", output, "app.js, lib.js Rename this in both")
+
+	assertContains(t, "list section", mustRunGR(t, dir, "list"), "app.js, lib.js\n")
+	assertContains(t, "by-file lists it under each file", mustRunGR(t, dir, "list", "--by-file", "--file", "lib.js"), "## lib.js")
+
+	c := findCommentByBody(stateComments(t, loadState(t, dir)), "Rename this in both")
+	if c["file"] != "app.js" {
+		t.Errorf("file = %v, want the first file", c["file"])
+	}
+	if files, _ := c["files"].([]interface{}); len(files) != 2 || files[1] != "lib.js" {
+		t.Errorf("files = %v, want [app.js lib.js]", c["files"])
+	}
+
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "finish")
+	notes := gitCmd(t, dir, "notes", "show", gitCmd(t, dir, "rev-parse", second+"~1"))
+	assertContains(t, "note lists both files", notes, "app.js, lib.js -- Rename this in both")
+}
+
+func TestQuietDropsDecorativeOutput(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+
+	if output := mustRunGR(t, dir, "--quiet"); strings.TrimSpace(output) != "" {
+		t.Errorf("start --quiet should print nothing, got:\n%s", output)
+	}
+	if output := mustRunGR(t, dir, "--quiet", "next"); strings.TrimSpace(output) != "" {
+		t.Errorf("next --quiet should print nothing, got:\n%s", output)
+	}
+	mustRunGR(t, dir, "--quiet", "add", "-q", "Still a question?")
+	c := findCommentByBody(stateComments(t, loadState(t, dir)), "Still a question?")
+	if c == nil || c["needsResponse"] != true {
+		t.Errorf("add -q should still mark a question under --quiet, got %v", c)
+	}
+
+	assertContains(t, "list output is kept", mustRunGR(t, dir, "--quiet", "list"), "Still a question?")
+	if _, err := runGR(t, dir, "--quiet", "jump", "99"); err == nil {
+		t.Error("errors should still fail under --quiet")
+	}
+	if output, err := runGR(t, dir, "status", "--quiet", "--fail-on-unresolved"); err == nil || strings.TrimSpace(output) != "" {
+		t.Errorf("status --quiet should print nothing and fail, got err=%v:\n%s", err, output)
+	}
+}
+
+func TestColorFlagsOverrideDetection(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Colorful")
+
+	assertNotContains(t, "no color when piped", mustRunGR(t, dir, "list"), "\033[")
+	output, err := runGRWithEnv(t, dir, []string{"NO_COLOR=1"}, "--color", "list")
+	if err != nil {
+		t.Fatalf("list --color: %v\n%s", err, output)
+	}
+	assertContains(t, "--color forces color", output, "\033[")
+	assertNotContains(t, "--no-color", mustRunGR(t, dir, "--no-color", "list"), "\033[")
+
+	if _, err := runGR(t, dir, "--color", "--no-color", "list"); err == nil {
+		t.Error("expected --color and --no-color together to fail")
+	}
+}
+
+func TestImport_RestoresExportedReview(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "-f", "app.js", "-l", "1", "--severity", "nit", "Root on first")
+	root := findCommentByBody(stateComments(t, loadState(t, dir)), "Root on first")["id"].(string)
+	mustRunGR(t, dir, "add", "-r", root, "A reply")
+	mustRunGR(t, dir, "resolve", root)
+	mustRunGR(t, dir, "next")
+	mustRunGR(t, dir, "add", "--summary", "Looks good")
+
+	export := mustRunGR(t, dir, "state")
+	path := filepath.Join(t.TempDir(), "review.json")
+	if err := os.WriteFile(path, []byte(export), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := runGR(t, dir, "import", path)
+	if err == nil {
+		t.Fatalf("expected import over an active review to fail\n%s", output)
+	}
+	assertContains(t, "suggests force", output, "--force")
+
+	mustRunGR(t, dir, "abort", "--force")
+	output = mustRunGR(t, dir, "import", path)
+	assertContains(t, "reports import", output, "Imported review of feature/test: 3 commits, 3 comments")
+
+	var before, after map[string]interface{}
+	if err := json.Unmarshal([]byte(export), &before); err != nil {
+		t.Fatal(err)
+	}
+	after = loadState(t, dir)
+	if after["current"] != before["current"] {
+		t.Errorf("current = %v, want %v", after["current"], before["current"])
+	}
+	for _, want := range stateComments(t, before) {
+		got := findCommentByBody(stateComments(t, after), want["body"].(string))
+		if got == nil {
+			t.Errorf("comment %q missing after import", want["body"])
+			continue
+		}
+		for _, key := range []string{"id", "parentId", "commit", "resolvedAt", "createdAt", "severity"} {
+			if got[key] != want[key] {
+				t.Errorf("%q %s = %v, want %v", want["body"], key, got[key], want[key])
+			}
+		}
+	}
+	assertContains(t, "worktree shows the restored commit", gitCmd(t, dir, "diff", "--staged"), "goodbye")
+
+	mustRunGR(t, dir, "import", "--force", path)
+	if n := len(stateComments(t, loadState(t, dir))); n != 3 {
+		t.Errorf("import --force should replace the review, got %d comments", n)
+	}
+}
+
+func TestStart_UsesConfiguredBaseBranchesAndReviewer(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	gitCmd(t, dir, "branch", "-m", "main", "trunk")
+
+	if _, err := runGR(t, dir); err == nil {
+		t.Fatal("expected base detection to fail without main/master/develop")
+	}
+
+	mustRunGR(t, dir, "config", "baseBranches", "release", "trunk")
+	mustRunGR(t, dir, "config", "defaultReviewer", "alice")
+	assertContains(t, "shows settings", mustRunGR(t, dir, "config"), "baseBranches     release trunk")
+
+	output := mustRunGR(t, dir)
+	assertContains(t, "detects configured base", output, "Base: trunk")
+	assertFileExists(t, filepath.Join(dir, ".git", "review", "worktrees", "alice"))
+
+	state := loadState(t, dir)
+	if n := len(state["commits"].([]interface{})); n != 3 {
+		t.Errorf("expected 3 commits against trunk, got %d", n)
+	}
+}
+
+func TestAdd_RejectsFileNotChangedByCommit(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	writeFile(t, dir, "util.js", "function util() {}\n")
+	gitCmd(t, dir, "add", ".")
+	gitCmd(t, dir, "commit", "-m", "Add util")
+	mustRunGR(t, dir)
+
+	out, err := runGR(t, dir, "add", "-f", "util.js", "-l", "1", "Wrong commit")
+	if err == nil {
+		t.Fatal("expected an error commenting on a file the commit does not change")
+	}
+	assertContains(t, "names the file", out, "util.js is not changed in commit")
+	assertContains(t, "names the changing commit", out, "Add util")
+
+	out = mustRunGR(t, dir, "add", "--force", "-f", "util.js", "-l", "1", "On purpose")
+	assertNotContains(t, "force suppresses the check", out, "not changed")
+	if findCommentByBody(stateComments(t, loadState(t, dir)), "On purpose") == nil {
+		t.Error("--force should add the comment")
+	}
+}
+
+func TestCompletion_BashScript(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+
+	output := mustRunGR(t, dir, "completion", "bash")
+	assertContains(t, "registers command", output, "complete -F _git_review git-review")
+	assertContains(t, "defines dynamic hook", output, "__git_review_complete()")
+	assertContains(t, "completes subcommands", output, "unresolve")
+}
+
+func TestCompletion_CompletesIDsAndCommits(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+	mustRunGR(t, dir, "add", "Test comment")
+
+	state := loadState(t, dir)
+	shortID := stateComments(t, state)[0]["id"].(string)[:8]
+	firstSHA := state["commits"].([]interface{})[0].(string)
+
+	assertContains(t, "completes comment IDs", mustRunGR(t, dir, "__complete", "ids"), shortID)
+	assertContains(t, "completes commit SHAs", mustRunGR(t, dir, "__complete", "commits"), firstSHA[:7])
+	assertContains(t, "completes changed files", mustRunGR(t, dir, "__complete", "files"), "app.js")
+}
+
+func TestNext_ErrorsWhenHistoryRewritten(t *testing.T) {
+	t.Parallel()
+	dir := setupTestRepo(t)
+	mustRunGR(t, dir)
+
+	// Move the branch away from the reviewed commits and prune them.
+	gitCmd(t, dir, "branch", "-f", "feature/test", "main")
+	gitCmd(t, dir, "reflog", "expire", "--expire=now", "--all")
+	gitCmd(t, dir, "gc", "--prune=now", "--quiet")
+
+	output, err := runGR(t, dir, "next")
+	if err == nil {
+		t.Fatalf("expected error after history rewrite, got:\n%s", output)
+	}
+	assertContains(t, "explains stale history", output, "history changed")
+	assertContains(t, "suggests abort", output, "git review abort")
 }
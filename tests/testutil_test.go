@@ -96,6 +96,34 @@ func runGR(t *testing.T, dir string, args ...string) (string, error) {
 	return string(out), err
 }
 
+// runGRWithEnv runs git-review with extra environment variables.
+func runGRWithEnv(t *testing.T, dir string, env []string, args ...string) (string, error) {
+	t.Helper()
+	cmd := exec.Command(binaryPath, args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), "TERM=dumb"), env...)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// runGRWithInput runs git-review with the given stdin and returns stdout only.
+func runGRWithInput(t *testing.T, dir, input string, args ...string) (string, error) {
+	t.Helper()
+	return runGRWithInputEnv(t, dir, input, nil, args...)
+}
+
+// runGRWithInputEnv runs git-review with the given stdin and extra environment
+// variables, and returns stdout only.
+func runGRWithInputEnv(t *testing.T, dir, input string, env []string, args ...string) (string, error) {
+	t.Helper()
+	cmd := exec.Command(binaryPath, args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), "TERM=dumb"), env...)
+	cmd.Stdin = strings.NewReader(input)
+	out, err := cmd.Output()
+	return string(out), err
+}
+
 func mustRunGR(t *testing.T, dir string, args ...string) string {
 	t.Helper()
 	out, err := runGR(t, dir, args...)
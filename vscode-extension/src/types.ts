@@ -22,11 +22,15 @@ export interface ReviewComment {
   createdAt: string;
   /** Creator name (reviewer role). */
   createdBy: string;
+  /** True while the comment is a question awaiting a response. */
+  needsResponse?: boolean;
 }
 
 /** In-memory representation of the full review state. */
 export interface ReviewState {
   baseRef: string;
+  /** "<short-sha> <subject>" of the base commit. */
+  baseOneline?: string;
   branch: string;
   commits: string[];
   current: number | null;